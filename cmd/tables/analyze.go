@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mymyka/tables/internal/analyze"
+	"github.com/spf13/cobra"
+)
+
+var analyzeOpts struct {
+	checks     []string
+	sample     int
+	deprecated []string
+	format     string
+}
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Report columns that are candidates for cleanup",
+	Long: `Report the columns of the selected tables that fail a check, for schema cleanup:
+
+  index       the column is in no index
+  fk          the column is on neither side of a foreign key
+  null        every one of the first --sample rows is NULL
+  deprecated  the name matches a --deprecated pattern, such as *_old or legacy_*
+
+index, fk and deprecated run by default, and null too when --sample is set. A
+column failing any check is listed with every check it fails. Findings are
+leads rather than verdicts: most data columns are neither indexed nor keys, so
+narrow the report with --checks, e.g. --checks null,deprecated --sample 1000.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runAnalyze,
+}
+
+func init() {
+	analyzeCmd.Flags().StringSliceVar(&analyzeOpts.checks, "checks", nil, "Checks to run: index, fk, null and deprecated")
+	analyzeCmd.Flags().IntVar(&analyzeOpts.sample, "sample", 0, "Rows per table the null check reads (default: no null check)")
+	analyzeCmd.Flags().StringSliceVar(&analyzeOpts.deprecated, "deprecated", nil, "Deprecated column name patterns (default: "+strings.Join(analyze.DefaultDeprecated, ", ")+")")
+	analyzeCmd.Flags().StringVar(&analyzeOpts.format, "format", "text", "Output format: text or json")
+
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	if analyzeOpts.format != "text" && analyzeOpts.format != "json" {
+		return withCode(exitUsage, fmt.Errorf("unknown format %q, expected text or json", analyzeOpts.format))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if cfg.Connection == "" {
+		return errNoConnection
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := checkPrivileges(db, cfg); err != nil {
+		return err
+	}
+
+	tables, err := readTables(db, cfg)
+	if err != nil {
+		return err
+	}
+
+	findings, err := analyze.Columns(db, tables, analyze.Options{
+		Checks:     analyzeOpts.checks,
+		Sample:     analyzeOpts.sample,
+		Deprecated: analyzeOpts.deprecated,
+	})
+	if err != nil {
+		return err
+	}
+
+	if analyzeOpts.format == "json" {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		if findings == nil {
+			findings = []analyze.Finding{}
+		}
+		if err := out.Encode(findings); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		return nil
+	}
+
+	for _, f := range findings {
+		fmt.Printf("%s.%s.%s: %s\n", f.Schema, f.Table, f.Column, strings.Join(f.Checks, ", "))
+	}
+	fmt.Printf("\n%d columns flagged in %d tables\n", len(findings), len(tables))
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/mymyka/tables/internal/synthetic"
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+var benchOpts struct {
+	tables  int
+	columns int
+	workers int
+	dir     string
+	target  time.Duration
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure generation speed on a synthetic schema",
+	Long: `Generate code for a synthetic schema of --tables tables with --columns columns each,
+without a database, and report the time and memory of every phase: building, writing into
+an empty directory, and writing again with nothing changed. Fails when the whole run takes
+longer than --target, so it can guard performance in CI.
+
+Combine with --profile-cpu and --profile-mem to see where the time goes.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runBench,
+}
+
+func init() {
+	flags := benchCmd.Flags()
+	flags.IntVar(&benchOpts.tables, "tables", 10000, "Number of tables")
+	flags.IntVar(&benchOpts.columns, "columns", 20, "Number of columns per table")
+	flags.IntVar(&benchOpts.workers, "workers", runtime.NumCPU(), "Number of packages generated and written concurrently")
+	flags.StringVar(&benchOpts.dir, "dir", "", "Directory to write into (default: a temporary directory, removed afterwards)")
+	flags.DurationVar(&benchOpts.target, "target", time.Minute, "Fail when the run takes longer, 0 to disable")
+
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	dir := benchOpts.dir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "tables-bench-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	tables := synthetic.Tables(benchOpts.tables, benchOpts.columns)
+	opts := gen.Options{Workers: benchOpts.workers}
+	write := writer.Options{Workers: benchOpts.workers}
+
+	fmt.Printf("%d tables, %d columns each, %d workers\n\n", benchOpts.tables, benchOpts.columns, benchOpts.workers)
+	fmt.Printf("%-10s %10s %12s %10s\n", "PHASE", "TIME", "TABLES/S", "HEAP MB")
+
+	var total time.Duration
+	phase := func(name string, run func() error) error {
+		start := time.Now()
+		if err := run(); err != nil {
+			return err
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		fmt.Printf("%-10s %10s %12.0f %10d\n", name, elapsed.Round(time.Millisecond), float64(len(tables))/elapsed.Seconds(), mem.HeapAlloc>>20)
+		return nil
+	}
+
+	var c map[string]string
+	err := phase("build", func() (err error) {
+		c, err = gen.Build(tables, opts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = phase("write", func() error {
+		_, err := writer.Write(dir, c, write)
+		return err
+	})
+	if err == nil {
+		err = phase("rewrite", func() error {
+			_, err := writer.Write(dir, c, write)
+			return err
+		})
+	}
+	if err != nil {
+		return withCode(exitWrite, err)
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Printf("%-10s %10s %12.0f %10s\n\nMemory obtained from the OS: %d MB\n", "total", total.Round(time.Millisecond), float64(len(tables))/total.Seconds(), "", mem.Sys>>20)
+
+	if benchOpts.target > 0 && total > benchOpts.target {
+		return fmt.Errorf("run took %s, more than the target of %s", total.Round(time.Millisecond), benchOpts.target)
+	}
+
+	return nil
+}
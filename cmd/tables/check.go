@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/mymyka/tables/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkSnapshot string
+	checkHash     bool
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Fail if the generated code on disk is out of date",
+	Long: `Regenerate in memory from the live database (or a snapshot) and compare
+the result with the generated files on disk. Exits non-zero with a summary of
+the differences when they have drifted, so CI can block stale models.
+
+With --hash, only compare the schema and settings hashes recorded in the
+manifest by generate, which is cheaper but does not notice edits to the
+generated files themselves.`,
+	RunE: runCheck,
+}
+
+func init() {
+	addOutputFlags(checkCmd)
+	checkCmd.Flags().StringVar(&checkSnapshot, "snapshot", "", "Compare against a schema snapshot instead of the database")
+	checkCmd.Flags().BoolVar(&checkHash, "hash", false, "Compare hashes with the manifest instead of regenerating")
+
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	cfgs, err := loadConfigs(cmd)
+	if err != nil {
+		return err
+	}
+
+	stale := false
+	for _, cfg := range cfgs {
+		upToDate, err := checkProfile(cfg)
+		if err != nil {
+			return err
+		}
+		if !upToDate {
+			stale = true
+		}
+	}
+
+	if stale {
+		fmt.Printf("Run `tables generate` to update it.\n")
+		return withCode(exitDrift, errors.New("generated code is out of date"))
+	}
+
+	return nil
+}
+
+// checkProfile compares the generated code of one profile with the files on
+// disk, reporting whether it is up to date.
+func checkProfile(cfg *config.Config) (bool, error) {
+	if cfg.Output.Dir == "" {
+		return false, errNoOutput
+	}
+
+	var tables []schema.Table
+	var err error
+	if checkSnapshot != "" {
+		tables, err = snapshot.Load(checkSnapshot)
+		tables = introspect.Select(tables, cfg.Include, cfg.Exclude)
+	} else {
+		if cfg.Connection == "" {
+			return false, fmt.Errorf("%w, or compare against --snapshot", errNoConnection)
+		}
+		tables, err = readSchema(cfg)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if checkHash {
+		return checkManifest(cfg, tables)
+	}
+
+	opts := buildOptions(cfg)
+	block, err := gen.Build(tables, opts)
+	if err != nil {
+		return false, err
+	}
+
+	changes, err := writer.DiffMoves(cfg.Output.Dir, block, packageMoves(cfg, tables, opts), cfg.Output.FileSuffix)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare generated files: %w", err)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("Generated code in %s is up to date.\n", cfg.Output.Dir)
+		return true, nil
+	}
+
+	fmt.Printf("Generated code in %s is out of date:\n", cfg.Output.Dir)
+	for _, c := range changes {
+		path := c.Path
+		if c.From != "" {
+			path = c.From + " -> " + c.Path
+		}
+		fmt.Printf("  %-8s %s (+%d -%d)\n", c.Kind, path, c.Added, c.Removed)
+	}
+
+	return false, nil
+}
+
+// checkManifest compares the manifest in the output directory with the
+// hashes of tables and cfg, reporting whether they match.
+func checkManifest(cfg *config.Config, tables []schema.Table) (bool, error) {
+	recorded, err := writer.ReadManifest(cfg.Output.Dir)
+	if err != nil {
+		return false, err
+	}
+	if recorded == nil {
+		fmt.Printf("Generated code in %s has no %s.\n", cfg.Output.Dir, writer.ManifestFile)
+		return false, nil
+	}
+
+	current, err := newManifest(cfg, tables)
+	if err != nil {
+		return false, err
+	}
+
+	if recorded.SchemaHash == current.SchemaHash && recorded.OptionsHash == current.OptionsHash && recorded.ToolVersion == current.ToolVersion {
+		fmt.Printf("Generated code in %s is up to date (%s).\n", cfg.Output.Dir, current.SchemaHash)
+		return true, nil
+	}
+
+	fmt.Printf("Generated code in %s is out of date:\n", cfg.Output.Dir)
+	if recorded.ToolVersion != current.ToolVersion {
+		fmt.Printf("  generated by tables %s, this is %s\n", recorded.ToolVersion, current.ToolVersion)
+	}
+	if recorded.OptionsHash != current.OptionsHash {
+		fmt.Printf("  settings changed\n")
+	}
+
+	var names []string
+	for name := range current.Tables {
+		names = append(names, name)
+	}
+	for name := range recorded.Tables {
+		if _, ok := current.Tables[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		was, ok := recorded.Tables[name]
+		now, exists := current.Tables[name]
+		switch {
+		case !ok:
+			fmt.Printf("  %-8s %s\n", "added", name)
+		case !exists:
+			fmt.Printf("  %-8s %s\n", "removed", name)
+		case was != now:
+			fmt.Printf("  %-8s %s\n", "changed", name)
+		}
+	}
+
+	return false, nil
+}
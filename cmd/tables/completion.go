@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/spf13/cobra"
+)
+
+// Cobra provides the completion command for bash, zsh, fish and powershell.
+// The functions below add dynamic completion of schema and table names,
+// read from the database given by --db, the environment or the config file.
+
+// registerCompletions runs once the persistent flags exist.
+func registerCompletions() {
+	rootCmd.RegisterFlagCompletionFunc("schemas", completeNames(func(p *introspect.SchemaParser) ([]string, error) {
+		return p.ListSchemas()
+	}))
+
+	tables := completeNames(func(p *introspect.SchemaParser) ([]string, error) {
+		return p.ListTables()
+	})
+	rootCmd.RegisterFlagCompletionFunc("include", tables)
+	rootCmd.RegisterFlagCompletionFunc("exclude", tables)
+	rootCmd.RegisterFlagCompletionFunc("env", completeEnvs)
+
+	// Sources are snapshot files or the configured database
+	sources := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 2 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return []string{"db"}, cobra.ShellCompDirectiveDefault
+	}
+	diffCmd.ValidArgsFunction = sources
+	migratePlanCmd.ValidArgsFunction = sources
+}
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeNames returns a completion function listing names from the
+// database. Without a reachable database nothing is suggested.
+func completeNames(list func(p *introspect.SchemaParser) ([]string, error)) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// Logs would corrupt the completion output
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		// Profiles of one config normally share the database, the first will do
+		cfgs, err := loadConfigs(cmd)
+		if err != nil || cfgs[0].Connection == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cfg := cfgs[0]
+
+		db, err := openDB(cfg)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer db.Close()
+
+		names, err := list(introspect.NewSchemaParser(db, introspect.Options{Schemas: cfg.Schemas}))
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeEnvs lists the named connections of the config file.
+func completeEnvs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, _, err := findConfig()
+	if err != nil || cfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for name := range cfg.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/diff"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var diffFormat string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <from> <to>",
+	Short: "Compare two schema sources",
+	Long: `Report tables and columns added, removed or changed between two schema
+sources. A source is a snapshot file, a connection string, or "db" for the
+connection from the config file. Tables listed under renames in the config
+file are reported as renamed instead of removed and added.`,
+	Args: usageArgs(cobra.ExactArgs(2)),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text or json")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if diffFormat != "text" && diffFormat != "json" {
+		return withCode(exitUsage, fmt.Errorf("unknown format %q, expected text or json", diffFormat))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	from, err := loadSource(cfg, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load source %s: %w", args[0], err)
+	}
+	to, err := loadSource(cfg, args[1])
+	if err != nil {
+		return fmt.Errorf("failed to load source %s: %w", args[1], err)
+	}
+
+	result := diff.CompareRenamed(from, to, cfg.Renames)
+
+	if diffFormat == "json" {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		if err := out.Encode(result); err != nil {
+			return fmt.Errorf("failed to encode diff: %w", err)
+		}
+		return nil
+	}
+
+	printDiff(result)
+
+	return nil
+}
+
+// loadSource reads tables from a snapshot file, a connection string, or the
+// configured connection when source is "db".
+func loadSource(cfg *config.Config, source string) ([]schema.Table, error) {
+	if source == "db" {
+		if cfg.Connection == "" {
+			return nil, fmt.Errorf("source db: %w", errNoConnection)
+		}
+		return readSchema(cfg)
+	}
+
+	if _, err := os.Stat(source); err == nil {
+		return snapshot.Load(source)
+	}
+
+	if strings.Contains(source, "://") || strings.Contains(source, "=") {
+		dbCfg := *cfg
+		dbCfg.Connection = source
+		return readSchema(&dbCfg)
+	}
+
+	return nil, fmt.Errorf("source %q is neither a snapshot file nor a connection string", source)
+}
+
+func printDiff(r diff.Result) {
+	if r.Empty() {
+		fmt.Println("No differences.")
+		return
+	}
+
+	for _, t := range r.AddedTables {
+		fmt.Printf("+ table %s.%s\n", t.Schema, t.Name)
+		for _, c := range t.Columns {
+			fmt.Printf("    + column %s\n", describeColumn(c))
+		}
+	}
+
+	for _, t := range r.RemovedTables {
+		fmt.Printf("- table %s.%s\n", t.Schema, t.Name)
+	}
+
+	for _, t := range r.RenamedTables {
+		fmt.Printf("> table %s.%s -> %s.%s\n", t.FromSchema, t.FromName, t.Schema, t.Name)
+		printColumnChanges(t.TableChange)
+	}
+
+	for _, t := range r.ChangedTables {
+		fmt.Printf("~ table %s.%s\n", t.Schema, t.Name)
+		printColumnChanges(t)
+	}
+
+	fmt.Printf("\n%d added, %d removed, %d renamed, %d changed tables\n", len(r.AddedTables), len(r.RemovedTables), len(r.RenamedTables), len(r.ChangedTables))
+}
+
+func printColumnChanges(t diff.TableChange) {
+	for _, c := range t.AddedColumns {
+		fmt.Printf("    + column %s\n", describeColumn(c))
+	}
+	for _, c := range t.RemovedColumns {
+		fmt.Printf("    - column %s\n", c.Name)
+	}
+	for _, c := range t.ChangedColumns {
+		fmt.Printf("    ~ column %s: %s -> %s\n", c.Name, describeType(c.From), describeType(c.To))
+	}
+}
+
+func describeColumn(c schema.Column) string {
+	return c.Name + " " + describeType(c)
+}
+
+func describeType(c schema.Column) string {
+	desc := c.Type + " NOT NULL"
+	if c.Nullable {
+		desc = c.Type + " NULL"
+	}
+	if c.Default != "" {
+		desc += " DEFAULT " + c.Default
+	}
+	return desc
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the tables command. They are part of its interface, so
+// scripts can branch on the outcome; never renumber them.
+const (
+	exitOK             = 0
+	exitError          = 1  // any failure not listed below, e.g. an invalid config
+	exitUsage          = 2  // unknown flag or wrong number of arguments
+	exitPartialFailure = 3  // --keep-going skipped tables or files
+	exitConnection     = 4  // the database could not be reached
+	exitIntrospection  = 5  // the schema could not be read
+	exitWrite          = 6  // generated files could not be written
+	exitDrift          = 7  // check found out-of-date generated code
+	exitLint           = 8  // lint-schema found naming violations
+	exitCompile        = 9  // generated code does not compile, found by selftest or --verify-build
+	exitWarnings       = 10 // --warnings-as-errors and the run had warnings
+)
+
+// Errors shared by several commands
+var (
+	errNoConnection = errors.New("database connection string is required, use --db, --env, DB_CONNECTION_STRING or connection in the config file")
+	errNoOutput     = errors.New("output path is required, use --output or output.dir in the config file")
+)
+
+// codedError carries the exit code of a failure up to main.
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// withCode tags err with an exit code. Wrapping the result with %w keeps the
+// code, so only the place that knows the kind of failure needs to tag it.
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &codedError{code: code, err: err}
+}
+
+// exitCode returns the exit code for an error returned by a command.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var e *codedError
+	if errors.As(err, &e) {
+		return e.code
+	}
+
+	return exitError
+}
+
+// usageArgs tags the errors of an argument validator as usage errors.
+func usageArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, a []string) error {
+		return withCode(exitUsage, args(cmd, a))
+	}
+}
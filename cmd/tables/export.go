@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/mymyka/tables/internal/docs"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var exportFile string
+
+// exportDir is the directory export csv writes its files to.
+var exportDir string
+
+// docsFormat is the format of docs, markdown or html, and docsDir the
+// directory of the HTML site.
+var docsFormat, docsDir string
+
+// exportStats reads the approximate row count and size of every table for
+// export json and docs.
+var exportStats bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the introspected schema in other formats",
+}
+
+var exportJSONCmd = &cobra.Command{
+	Use:   "json",
+	Short: "Export the schema as JSON, in the snapshot format",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tables, err := exportTables(cmd)
+		if err != nil {
+			return err
+		}
+
+		data, err := snapshot.Marshal(tables)
+		if err != nil {
+			return err
+		}
+		return writeExport(data)
+	},
+}
+
+var exportCSVCmd = &cobra.Command{
+	Use:   "csv",
+	Short: "Export a data dictionary as tables.csv, columns.csv and fks.csv",
+	Long: `Write the schema as spreadsheet files into a directory: tables.csv with a row
+per table, columns.csv with a row per column and fks.csv with a row per foreign
+key. The files are UTF-8 with a byte order mark and CRLF line endings, so Excel
+opens them directly. --stats adds row counts and sizes to tables.csv.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tables, err := exportTables(cmd)
+		if err != nil {
+			return err
+		}
+
+		files, err := docs.CSV(tables)
+		if err != nil {
+			return err
+		}
+
+		return writeExportFiles(exportDir, files)
+	},
+}
+
+var exportPlantUMLCmd = &cobra.Command{
+	Use:   "plantuml",
+	Short: "Export an entity-relationship diagram of the schema as PlantUML",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tables, err := exportTables(cmd)
+		if err != nil {
+			return err
+		}
+		return writeExport([]byte(docs.PlantUML(tables)))
+	},
+}
+
+var exportDBMLCmd = &cobra.Command{
+	Use:   "dbml",
+	Short: "Export the schema as DBML, for dbdiagram.io and dbdocs.io",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tables, err := exportTables(cmd)
+		if err != nil {
+			return err
+		}
+		return writeExport([]byte(docs.DBML(tables)))
+	},
+}
+
+var exportJSONSchemaCmd = &cobra.Command{
+	Use:   "json-schema",
+	Short: "Print the JSON Schema of the snapshot and export format",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeExport(schema.JSONSchema)
+	},
+}
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate a Markdown data dictionary or an HTML schema browser",
+	Long: `Generate a data dictionary of the schema. The default Markdown format is a
+single document written to --file. --format html writes a static site into
+--dir instead: a searchable list of the tables, a page per table with its
+columns, the foreign keys to and from it and its partitions, and an
+entity-relationship diagram linking to the pages. The site has no external
+assets, so any static file host or bucket can serve it.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if docsFormat != "markdown" && docsFormat != "html" {
+			return withCode(exitUsage, fmt.Errorf("unknown format %q, expected markdown or html", docsFormat))
+		}
+
+		tables, err := exportTables(cmd)
+		if err != nil {
+			return err
+		}
+
+		if docsFormat == "html" {
+			files, err := docs.HTML(tables)
+			if err != nil {
+				return err
+			}
+			return writeExportFiles(docsDir, files)
+		}
+
+		return writeExport([]byte(docs.Markdown(tables)))
+	},
+}
+
+func init() {
+	exportCmd.PersistentFlags().StringVarP(&exportFile, "file", "f", "-", "File to write, - for stdout")
+	docsCmd.Flags().StringVarP(&exportFile, "file", "f", "-", "File to write, - for stdout")
+	exportJSONCmd.Flags().BoolVar(&exportStats, "stats", false, "Include approximate row counts and on-disk sizes")
+	docsCmd.Flags().BoolVar(&exportStats, "stats", false, "Include approximate row counts and on-disk sizes")
+	docsCmd.Flags().StringVar(&docsFormat, "format", "markdown", "Output format: markdown or html")
+	docsCmd.Flags().StringVar(&docsDir, "dir", "site", "Directory to write the HTML site to")
+	exportCSVCmd.Flags().StringVar(&exportDir, "dir", ".", "Directory to write the CSV files to")
+	exportCSVCmd.Flags().BoolVar(&exportStats, "stats", false, "Include approximate row counts and on-disk sizes")
+
+	exportCmd.AddCommand(exportJSONCmd)
+	exportCmd.AddCommand(exportJSONSchemaCmd)
+	exportCmd.AddCommand(exportCSVCmd)
+	exportCmd.AddCommand(exportPlantUMLCmd)
+	exportCmd.AddCommand(exportDBMLCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(docsCmd)
+}
+
+// exportTables introspects the configured database for an export command.
+func exportTables(cmd *cobra.Command) ([]schema.Table, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Connection == "" {
+		return nil, errNoConnection
+	}
+
+	return readSchema(cfg)
+}
+
+func writeExport(data []byte) error {
+	if exportFile == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(exportFile, data, 0644); err != nil {
+		return withCode(exitWrite, fmt.Errorf("failed to write export: %w", err))
+	}
+	slog.Info("Wrote export", "file", exportFile)
+
+	return nil
+}
+
+// writeExportFiles writes the files of a multi-file export, keyed by path,
+// under dir.
+func writeExportFiles(dir string, files map[string][]byte) error {
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return withCode(exitWrite, fmt.Errorf("failed to create export directory: %w", err))
+		}
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return withCode(exitWrite, fmt.Errorf("failed to write export: %w", err))
+		}
+	}
+	slog.Info("Wrote export", "dir", dir, "files", len(files))
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mymyka/tables/internal/fixtures"
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var fixturesOpts struct {
+	tables []string
+	limit  int
+	format string
+	output string
+	pkg    string
+	noMask bool
+}
+
+var dumpFixturesCmd = &cobra.Command{
+	Use:   "dump-fixtures",
+	Short: "Dump real rows as Go or YAML fixtures",
+	Long: `Read the first rows of tables, ordered by primary key, and write them as
+fixtures, masked with the masking settings of the config file.
+
+--format go writes a file declaring a slice of Row literals per table, e.g.
+var Users = []users.Row{...}, importing the generated packages. --format yaml
+writes testfixtures-style files, <table>.yml per table, each a list of records
+keyed by column name.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runDumpFixtures,
+}
+
+func init() {
+	dumpFixturesCmd.Flags().StringSliceVar(&fixturesOpts.tables, "table", nil, "Table to dump, by name or schema.name; repeatable")
+	dumpFixturesCmd.Flags().IntVar(&fixturesOpts.limit, "limit", 50, "Number of rows dumped per table")
+	dumpFixturesCmd.Flags().StringVar(&fixturesOpts.format, "format", "go", "Output format: go or yaml")
+	dumpFixturesCmd.Flags().StringVar(&fixturesOpts.output, "output", "", "File to write Go fixtures to, or directory of YAML fixtures (default: stdout)")
+	dumpFixturesCmd.Flags().StringVar(&fixturesOpts.pkg, "package", "fixtures", "Package name of Go fixtures")
+	dumpFixturesCmd.Flags().BoolVar(&fixturesOpts.noMask, "no-mask", false, "Dump real values even when masking is configured")
+
+	rootCmd.AddCommand(dumpFixturesCmd)
+}
+
+func runDumpFixtures(cmd *cobra.Command, args []string) error {
+	switch {
+	case fixturesOpts.format != "go" && fixturesOpts.format != "yaml":
+		return withCode(exitUsage, fmt.Errorf("unknown format %q, expected go or yaml", fixturesOpts.format))
+	case len(fixturesOpts.tables) == 0:
+		return withCode(exitUsage, errors.New("select the tables to dump with --table"))
+	case fixturesOpts.format == "yaml" && fixturesOpts.output == "" && len(fixturesOpts.tables) > 1:
+		return withCode(exitUsage, errors.New("YAML fixtures of several tables need an --output directory"))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if cfg.Connection == "" {
+		return errNoConnection
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := checkPrivileges(db, cfg); err != nil {
+		return err
+	}
+
+	all, err := readTables(db, cfg)
+	if err != nil {
+		return err
+	}
+
+	opts := buildOptions(cfg)
+	if fixturesOpts.noMask {
+		opts.Masking = nil
+	} else if opts.Masking == nil {
+		slog.Warn("No masking configured, fixtures hold the values as they are")
+	}
+
+	var dumped []gen.FixtureTable
+	for _, name := range fixturesOpts.tables {
+		tables := introspect.Select(all, []string{name}, nil)
+		if len(tables) == 0 {
+			return fmt.Errorf("table %s not found", name)
+		}
+
+		for _, t := range tables {
+			rows, err := fixtures.Read(db, t, fixturesOpts.limit)
+			if err != nil {
+				return err
+			}
+			if err := gen.MaskRows(t, rows, opts); err != nil {
+				return err
+			}
+
+			slog.Info("Read rows", "table", t.Schema+"."+t.Name, "rows", len(rows))
+			dumped = append(dumped, gen.FixtureTable{Table: t, Rows: rows})
+		}
+	}
+
+	if fixturesOpts.format == "yaml" {
+		return writeYAMLFixtures(dumped)
+	}
+
+	src, err := gen.BuildFixtures(fixturesOpts.pkg, dumped, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeFixture(fixturesOpts.output, []byte(src))
+}
+
+// writeYAMLFixtures writes <table>.yml per table into the output directory,
+// or the only table to stdout.
+func writeYAMLFixtures(dumped []gen.FixtureTable) error {
+	for _, ft := range dumped {
+		data, err := fixtures.YAML(ft.Table, ft.Rows)
+		if err != nil {
+			return err
+		}
+
+		path := ""
+		if fixturesOpts.output != "" {
+			path = filepath.Join(fixturesOpts.output, fixtureFile(ft.Table, dumped))
+			if err := os.MkdirAll(fixturesOpts.output, 0755); err != nil {
+				return withCode(exitWrite, fmt.Errorf("failed to create fixture directory: %w", err))
+			}
+		}
+		if err := writeFixture(path, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fixtureFile names the YAML file of t after the table, qualified by schema
+// when another dumped table has the same name.
+func fixtureFile(t schema.Table, dumped []gen.FixtureTable) string {
+	for _, other := range dumped {
+		if other.Table.Name == t.Name && other.Table.Schema != t.Schema {
+			return t.Schema + "." + t.Name + ".yml"
+		}
+	}
+
+	return t.Name + ".yml"
+}
+
+// writeFixture writes a fixture file, or to stdout when path is empty.
+func writeFixture(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return withCode(exitWrite, fmt.Errorf("failed to write fixtures: %w", err))
+	}
+	slog.Info("Wrote fixtures", "file", path)
+
+	return nil
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/diff"
+	"github.com/mymyka/tables/internal/telemetry"
+	"github.com/mymyka/tables/internal/version"
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/plugin"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/mymyka/tables/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+// Output flags of generate and check
+var (
+	outputPath    string
+	initModule    string
+	modulePath    string
+	packagePrefix string
+	templatesDir  string
+	goVersion     string
+	buildTags     string
+	fileSuffix    string
+	watchEnabled  bool
+	watchInterval time.Duration
+	watchChannel  string
+	summaryFile   string
+	workers       int
+
+	failOnUnknownType bool
+	keepGoing         bool
+	incremental       bool
+	verifyBuildFlag   bool
+	warningsAsErrors  bool
+	pluginFlags       []string
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate Go types, reading tables.yaml when present",
+	RunE:  runGenerate,
+}
+
+func init() {
+	addOutputFlags(generateCmd)
+	generateCmd.Flags().BoolVarP(&watchEnabled, "watch", "w", false, "Keep running and regenerate whenever the schema changes")
+	generateCmd.Flags().DurationVar(&watchInterval, "watch-interval", 2*time.Second, "How often --watch polls the schema")
+	generateCmd.Flags().StringVar(&watchChannel, "watch-channel", "", "LISTEN on this channel and regenerate on notification, in addition to polling")
+	generateCmd.Flags().StringVar(&summaryFile, "summary", "", "Write a JSON run summary to this file, - for stdout")
+	generateCmd.Flags().BoolVar(&failOnUnknownType, "fail-on-unknown-type", false, "Fail without writing when a column type has no Go mapping")
+	generateCmd.Flags().BoolVar(&warningsAsErrors, "warnings-as-errors", false, "Fail without writing, with exit code 10, when the run has warnings such as unmapped types or name collisions")
+	generateCmd.Flags().BoolVar(&keepGoing, "keep-going", false, "Skip tables and files that fail instead of aborting, and exit with code 3")
+	generateCmd.Flags().BoolVar(&incremental, "incremental", false, "Only regenerate the tables whose hash changed since the manifest was written")
+	generateCmd.Flags().StringSliceVar(&pluginFlags, "plugin", nil, "Run the tables-gen-<name> plugin writing into dir, as name=dir; repeatable")
+	generateCmd.Flags().BoolVar(&verifyBuildFlag, "verify-build", false, "Compile the generated code before writing it and fail, writing nothing, when it does not build")
+	generateCmd.Flags().IntVar(&workers, "workers", runtime.NumCPU(), "Number of packages generated and written concurrently")
+
+	rootCmd.AddCommand(generateCmd)
+}
+
+// addOutputFlags registers the flags that override the output section of the config.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output directory path")
+	cmd.Flags().StringVar(&initModule, "init-module", "", "Write go.mod/go.sum declaring this module path into the output directory")
+	cmd.Flags().StringVar(&modulePath, "module-path", "", "Import path of the output directory (default: inferred from the nearest go.mod)")
+	cmd.Flags().StringVar(&packagePrefix, "package-prefix", "", "Prefix for generated package names")
+	cmd.Flags().StringVar(&templatesDir, "templates", "", "Directory of templates overriding the built-in ones")
+	cmd.Flags().StringVar(&goVersion, "go-version", "", "Oldest Go version the generated code must build with (default "+gen.DefaultGoVersion+")")
+	cmd.Flags().StringVar(&buildTags, "build-tags", "", "Build constraint put on every generated file, e.g. !codeanalysis")
+	cmd.Flags().StringVar(&fileSuffix, "file-suffix", "", "Suffix of generated file names, e.g. _gen for users/users_gen.go")
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	cfgs, err := loadConfigs(cmd)
+	if err != nil {
+		return err
+	}
+
+	dirs := make(map[string]*config.Config)
+	for _, cfg := range cfgs {
+		if cfg.Connection == "" {
+			return errNoConnection
+		}
+
+		if cfg.Output.Dir == "" {
+			return errNoOutput
+		}
+
+		// Each profile prunes its output directory and every directory
+		// below it, so they cannot share one or write into another's
+		dir, err := filepath.Abs(cfg.Output.Dir)
+		if err != nil {
+			return err
+		}
+		for _, path := range slices.Sorted(maps.Keys(dirs)) {
+			other := dirs[path]
+			switch {
+			case path == dir:
+				return fmt.Errorf("profiles %s and %s write to the same output directory %s", other.Name, cfg.Name, cfg.Output.Dir)
+			case within(path, dir):
+				return fmt.Errorf("profile %s writes into %s, inside the output directory %s of profile %s", cfg.Name, cfg.Output.Dir, other.Output.Dir, other.Name)
+			case within(dir, path):
+				return fmt.Errorf("profile %s writes into %s, inside the output directory %s of profile %s", other.Name, other.Output.Dir, cfg.Output.Dir, cfg.Name)
+			}
+		}
+		dirs[dir] = cfg
+	}
+
+	if watchEnabled {
+		if len(cfgs) > 1 {
+			return withCode(exitUsage, errors.New("--watch works on a single profile, select one with --profile"))
+		}
+
+		return watchTypes(cfgs[0])
+	}
+
+	return generateTypes(cfgs)
+}
+
+// within reports whether dir is below parent; both are absolute and clean.
+func within(parent, dir string) bool {
+	rel, err := filepath.Rel(parent, dir)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// generateTypes generates every profile. Profiles reading the same database
+// and schemas share a single introspection pass.
+func generateTypes(cfgs []*config.Config) error {
+	start := time.Now()
+
+	var groups [][]*config.Config
+	index := make(map[string]int)
+	for _, cfg := range cfgs {
+		key := cfg.Connection + "\x00" + strings.Join(cfg.Schemas, ",")
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], cfg)
+	}
+
+	// Introspect and filter every profile before writing anything, so the
+	// strict type check can stop the run with the output untouched
+	var targets []target
+	for _, group := range groups {
+		// Read every table once and apply each profile's filters afterwards
+		source := *group[0]
+		if len(group) > 1 {
+			source.Include, source.Exclude = nil, nil
+		}
+
+		tables, err := readSchema(&source)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Found tables", "count", len(tables))
+
+		for _, cfg := range group {
+			targets = append(targets, target{cfg: cfg, tables: introspect.Select(tables, cfg.Include, cfg.Exclude)})
+		}
+	}
+
+	return generateTargets(targets, start)
+}
+
+// target is a profile with the tables it generates.
+type target struct {
+	cfg    *config.Config
+	tables []schema.Table
+}
+
+// generateTargets writes the packages and plugin output of every target,
+// after the --fail-on-unknown-type and --warnings-as-errors checks, which
+// stop the run before anything is written. start is when the run began.
+func generateTargets(targets []target, start time.Time) error {
+	var unmapped []gen.Unmapped
+	var warnings []gen.Warning
+	count := 0
+	for _, t := range targets {
+		unmapped = append(unmapped, gen.FindUnmapped(t.tables, buildOptions(t.cfg))...)
+		warnings = append(warnings, gen.FindWarnings(t.tables, buildOptions(t.cfg))...)
+		count += len(t.tables)
+	}
+
+	if failOnUnknownType && len(unmapped) > 0 {
+		reportUnmapped(unmapped)
+		return fmt.Errorf("%d columns have unmapped types, add them to types in the config file", len(unmapped))
+	}
+
+	if all := runWarnings(warnings); warningsAsErrors && len(all) > 0 {
+		reportUnmapped(unmapped)
+		reportWarnings(all)
+		if summaryFile != "" {
+			summary := newRunSummary(count, unmapped, warnings, writer.Result{}, time.Since(start))
+			if err := writeSummary(summaryFile, summary); err != nil {
+				return withCode(exitWrite, fmt.Errorf("failed to write summary: %w", err))
+			}
+		}
+		return withCode(exitWarnings, fmt.Errorf("%d warnings with --warnings-as-errors, nothing was written", len(all)))
+	}
+
+	var all writer.Result
+	for _, t := range targets {
+		if t.cfg.Name != "" {
+			slog.Info("Generating profile", "profile", t.cfg.Name, "tables", len(t.tables))
+		}
+
+		result, err := writeTypes(t.cfg, t.tables)
+		if err != nil && t.cfg.Name != "" {
+			return fmt.Errorf("profile %s: %w", t.cfg.Name, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		all.Written = append(all.Written, result.Written...)
+		all.Skipped = append(all.Skipped, result.Skipped...)
+		all.Pruned = append(all.Pruned, result.Pruned...)
+
+		for _, f := range result.Failed {
+			failures = append(failures, runFailure{Stage: "write", Path: f.Path, Error: f.Error})
+		}
+
+		for _, p := range t.cfg.Plugins {
+			result, err := runPlugin(p, t.tables)
+			if err != nil && keepGoing {
+				slog.Warn("Skipping plugin", "plugin", p.Name, "error", err)
+				failures = append(failures, runFailure{Stage: "plugin", Path: p.Out, Error: err.Error()})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			all.Written = append(all.Written, result.Written...)
+			all.Skipped = append(all.Skipped, result.Skipped...)
+		}
+	}
+
+	slog.Info("Successfully generated types", "tables", count, "written", len(all.Written), "pruned", len(all.Pruned))
+	reportUnmapped(unmapped)
+	reportWarnings(warnings)
+
+	if summaryFile != "" {
+		summary := newRunSummary(count, unmapped, warnings, all, time.Since(start))
+		if err := writeSummary(summaryFile, summary); err != nil {
+			return withCode(exitWrite, fmt.Errorf("failed to write summary: %w", err))
+		}
+	}
+
+	if len(failures) > 0 {
+		slog.Warn("Some tables or files were skipped", "count", len(failures))
+		for _, f := range failures {
+			slog.Warn("Skipped", "stage", f.Stage, "table", f.Table, "path", f.Path, "error", f.Error)
+		}
+		return withCode(exitPartialFailure, fmt.Errorf("%d tables or files were skipped", len(failures)))
+	}
+
+	return nil
+}
+
+// reportUnmapped logs every column whose type fell through to the default
+// mapping, so mapping gaps show up at the end of the run.
+func reportUnmapped(unmapped []gen.Unmapped) {
+	if len(unmapped) == 0 {
+		return
+	}
+
+	slog.Warn("Some column types have no Go mapping and fell back to a default type", "columns", len(unmapped))
+	for _, u := range unmapped {
+		slog.Warn("Unmapped column type", "column", u.Schema+"."+u.Table+"."+u.Column, "type", u.Type, "go_type", u.GoType)
+	}
+}
+
+// reportWarnings logs warnings but the unmapped types, which
+// reportUnmapped details.
+func reportWarnings(warnings []gen.Warning) {
+	for _, w := range warnings {
+		if w.Kind == gen.WarnUnmappedType {
+			continue
+		}
+		slog.Warn("Warning", "kind", w.Kind, "table", w.Table, "column", w.Column, "message", w.Message)
+	}
+}
+
+// writeTypes generates and writes the packages for tables. With
+// --incremental, the packages of tables unchanged since the manifest was
+// written are left as they are.
+func writeTypes(cfg *config.Config, tables []schema.Table) (writer.Result, error) {
+	slog.Info("Generating Go types")
+
+	opts := buildOptions(cfg)
+
+	var unchanged map[string]string
+	if incremental {
+		var keep map[string]bool
+		var err error
+		if unchanged, keep, err = unchangedPackages(cfg, tables, opts); err != nil {
+			return writer.Result{}, err
+		}
+		if unchanged != nil {
+			slog.Info("Regenerating changed tables", "changed", len(tables)-len(keep), "unchanged", len(keep))
+			opts.Only = func(t schema.Table) bool { return !keep[t.Schema+"."+t.Name] }
+		}
+	}
+
+	span := telemetry.Start("generate", "tables.tables", len(tables))
+	block, err := gen.Build(tables, opts)
+	span.Set("tables.packages", len(block))
+	span.End(err)
+	if err != nil {
+		return writer.Result{}, err
+	}
+	for _, pkg := range writer.Packages(block) {
+		slog.Debug("Generated package", "package", pkg)
+	}
+
+	// Packages left out by --incremental are compiled and required too
+	content := block
+	if unchanged != nil {
+		content = maps.Clone(block)
+		maps.Copy(content, unchanged)
+	}
+
+	moves := packageMoves(cfg, tables, opts)
+	if verifyBuildFlag {
+		span := telemetry.Start("verify-build", "tables.packages", len(content))
+		err := verifyBuild(cfg, content, moves)
+		span.End(err)
+		if err != nil {
+			return writer.Result{}, err
+		}
+	}
+
+	slog.Info("Writing files", "dir", cfg.Output.Dir)
+
+	span = telemetry.Start("write")
+	result, err := writeFiles(cfg, block, unchanged, content, moves)
+	span.Set("tables.written", len(result.Written), "tables.skipped", len(result.Skipped), "tables.pruned", len(result.Pruned))
+	span.End(err)
+	if err != nil {
+		return result, err
+	}
+
+	manifest, err := newManifest(cfg, tables)
+	if err != nil {
+		return result, err
+	}
+	if _, err := writer.WriteManifest(cfg.Output.Dir, manifest); err != nil {
+		return result, withCode(exitWrite, err)
+	}
+
+	return result, nil
+}
+
+// writeFiles moves the packages of renamed tables and writes block, with
+// the go.mod of a standalone module requiring what content imports.
+func writeFiles(cfg *config.Config, block, unchanged, content map[string]string, moves map[string]string) (writer.Result, error) {
+	for _, from := range slices.Sorted(maps.Keys(moves)) {
+		moved, err := writer.Move(cfg.Output.Dir, from, moves[from], cfg.Output.FileSuffix)
+		if err != nil {
+			return writer.Result{}, withCode(exitWrite, fmt.Errorf("failed to move package %s to %s: %w", from, moves[from], err))
+		}
+		if moved {
+			slog.Info("Moved package of renamed table", "from", from, "to", moves[from])
+		}
+	}
+
+	result, err := writer.Write(cfg.Output.Dir, block, writer.Options{Progress: newProgress("Writing"), Workers: workers, KeepGoing: keepGoing, Unchanged: writer.Packages(unchanged), FileSuffix: cfg.Output.FileSuffix})
+	if err != nil {
+		return result, withCode(exitWrite, fmt.Errorf("failed to write files: %w", err))
+	}
+
+	if cfg.Output.Module != "" {
+		slog.Info("Writing go.mod", "module", cfg.Output.Module)
+
+		if err := writer.WriteModuleGo(cfg.Output.Dir, cfg.Output.Module, cfg.Output.GoVersion, content); err != nil {
+			return result, withCode(exitWrite, fmt.Errorf("failed to write module files: %w", err))
+		}
+	}
+
+	return result, nil
+}
+
+// packageMoves returns the package paths of the tables renamed according to
+// cfg.Renames, from the old table's package to the new one's. Renames whose
+// old table still exists are left out.
+func packageMoves(cfg *config.Config, tables []schema.Table, opts gen.Options) map[string]string {
+	if len(cfg.Renames) == 0 {
+		return nil
+	}
+
+	exists := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		exists[t.Schema+"."+t.Name] = true
+	}
+
+	moves := make(map[string]string)
+	for _, t := range tables {
+		for old := range cfg.Renames {
+			from := schema.Table{Schema: t.Schema, Name: old}
+			if schemaName, name, ok := strings.Cut(old, "."); ok {
+				from = schema.Table{Schema: schemaName, Name: name}
+			}
+			if !exists[from.Schema+"."+from.Name] && diff.Renamed(cfg.Renames, from, t) {
+				moves[gen.PackagePath(from, opts)] = gen.PackagePath(t, opts)
+			}
+		}
+	}
+
+	return moves
+}
+
+// unchangedPackages returns the files of the packages that the manifest in
+// the output directory records as generated from the current definition of
+// their table, keyed by package path, and the schema.name of those tables.
+// It returns nil when everything has to be generated: without a manifest,
+// or when the tables version or the settings changed.
+func unchangedPackages(cfg *config.Config, tables []schema.Table, opts gen.Options) (map[string]string, map[string]bool, error) {
+	recorded, err := writer.ReadManifest(cfg.Output.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recorded == nil {
+		slog.Info("No manifest in the output directory, generating every table")
+		return nil, nil, nil
+	}
+	if recorded.ToolVersion != version.String() {
+		slog.Info("Generated by another version of tables, generating every table", "version", recorded.ToolVersion)
+		return nil, nil, nil
+	}
+
+	options, err := optionsHash(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recorded.OptionsHash != options {
+		slog.Info("Settings changed, generating every table")
+		return nil, nil, nil
+	}
+
+	names := make(map[string]string)
+	var pkgs []string
+	for _, t := range tables {
+		name := t.Schema + "." + t.Name
+		if recorded.Tables[name] == t.Hash() {
+			pkg := gen.PackagePath(t, opts)
+			names[pkg] = name
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	// A deleted file is generated again
+	files, err := writer.Read(cfg.Output.Dir, pkgs, cfg.Output.FileSuffix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read generated files: %w", err)
+	}
+
+	keep := make(map[string]bool, len(files))
+	for pkg := range files {
+		keep[names[pkg]] = true
+	}
+
+	return files, keep, nil
+}
+
+// runPlugin runs an external generator on tables and writes its files.
+func runPlugin(p config.Plugin, tables []schema.Table) (writer.Result, error) {
+	if p.Out == "" {
+		return writer.Result{}, fmt.Errorf("plugin %s has no output directory", p.Name)
+	}
+
+	slog.Info("Running plugin", "plugin", p.Name, "dir", p.Out)
+
+	files, err := plugin.Run(p.Name, plugin.Request{
+		TablesVersion: version.String(),
+		Tables:        tables,
+		Options:       p.Options,
+	})
+	if err != nil {
+		return writer.Result{}, err
+	}
+
+	result, err := writer.WriteFiles(p.Out, files)
+	if err != nil {
+		return result, withCode(exitWrite, fmt.Errorf("failed to write files of plugin %s: %w", p.Name, err))
+	}
+
+	return result, nil
+}
+
+// buildOptions maps the config onto builder options.
+func buildOptions(cfg *config.Config) gen.Options {
+	opts := gen.Options{
+		Types:            cfg.Types,
+		JSONMaps:         cfg.JSONMaps,
+		Money:            cfg.Money,
+		ColumnTags:       cfg.ColumnTags,
+		Initialisms:      cfg.Naming.Initialisms,
+		Rename:           cfg.Naming.Rename,
+		PackagePrefix:    cfg.Output.PackagePrefix,
+		Layout:           cfg.Output.Layout,
+		SearchPath:       cfg.Output.SearchPath,
+		FieldOrder:       cfg.Output.FieldOrder,
+		GoVersion:        cfg.Output.GoVersion,
+		BuildTags:        cfg.Output.BuildTags,
+		Nolint:           cfg.Output.Nolint,
+		NoStutter:        cfg.Output.NoStutter,
+		MaxLineLength:    cfg.Output.MaxLineLength,
+		ModulePath:       outputModulePath(cfg),
+		OrderPackage:     cfg.Output.OrderPackage,
+		ResetPackage:     cfg.Output.ResetPackage,
+		ResetCascade:     cfg.Output.ResetCascade,
+		FixturesPackage:  cfg.Output.FixturesPackage,
+		StmtCachePackage: cfg.Output.StmtCachePackage,
+		ReplicaPackage:   cfg.Output.ReplicaPackage,
+		RetryPackage:     cfg.Output.RetryPackage,
+		TenantPackage:    cfg.Output.TenantPackage,
+		WherePackage:     cfg.Output.WherePackage,
+		Placeholder:      cfg.Output.Placeholder,
+		Tags:             cfg.Output.Tags,
+		Features:         cfg.Output.Features,
+		Workers:          workers,
+	}
+
+	for name, t := range cfg.Tables {
+		if t.Features != nil {
+			if opts.TableFeatures == nil {
+				opts.TableFeatures = make(map[string][]string)
+			}
+			opts.TableFeatures[name] = t.Features
+		}
+		if t.Notify != "" {
+			if opts.Notify == nil {
+				opts.Notify = make(map[string]string)
+			}
+			opts.Notify[name] = t.Notify
+		}
+		if t.History != "" {
+			if opts.History == nil {
+				opts.History = make(map[string]string)
+			}
+			opts.History[name] = t.History
+		}
+		if t.DTO != nil {
+			if opts.DTO == nil {
+				opts.DTO = make(map[string][]string)
+			}
+			opts.DTO[name] = t.DTO
+		}
+		if t.Sort != nil {
+			if opts.Sort == nil {
+				opts.Sort = make(map[string][]string)
+			}
+			opts.Sort[name] = t.Sort
+		}
+		if t.Filter != nil {
+			if opts.Filter == nil {
+				opts.Filter = make(map[string][]string)
+			}
+			opts.Filter[name] = t.Filter
+		}
+		if t.Aggregates != nil {
+			if opts.Aggregates == nil {
+				opts.Aggregates = make(map[string][]string)
+			}
+			opts.Aggregates[name] = t.Aggregates
+		}
+	}
+
+	for name, e := range cfg.Enums {
+		if opts.Enums == nil {
+			opts.Enums = make(map[string]gen.EnumDoc)
+		}
+		opts.Enums[name] = gen.EnumDoc{Labels: e.Labels, Deprecated: e.Deprecated}
+	}
+
+	if m := cfg.Masking; m != nil {
+		opts.Masking = &gen.Masking{Tags: m.Tags, Strategy: m.Strategy, Columns: m.Columns}
+	}
+
+	for _, other := range cfg.Counterparts {
+		opts.Converters = append(opts.Converters, gen.Converter{Name: other.Name, Options: buildOptions(other)})
+	}
+
+	if cfg.Output.Templates != "" {
+		opts.Templates = os.DirFS(cfg.Output.Templates)
+	}
+
+	return opts
+}
+
+// outputModulePath returns the import path of the output directory: the
+// configured one, the module written into it, or its path in the module of
+// the nearest go.mod above it. It is empty when none applies.
+func outputModulePath(cfg *config.Config) string {
+	if cfg.Output.ModulePath != "" {
+		return cfg.Output.ModulePath
+	}
+	if cfg.Output.Module != "" {
+		return cfg.Output.Module
+	}
+
+	dir, err := filepath.Abs(cfg.Output.Dir)
+	if err != nil {
+		return ""
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			module := goModulePath(data)
+			rel, err := filepath.Rel(root, dir)
+			if module == "" || err != nil {
+				return ""
+			}
+
+			importPath := path.Join(module, filepath.ToSlash(rel))
+			slog.Debug("Inferred module path", "go.mod", filepath.Join(root, "go.mod"), "path", importPath)
+			return importPath
+		}
+
+		if filepath.Dir(root) == root {
+			return ""
+		}
+	}
+}
+
+// goModulePath returns the path declared by the module directive of a
+// go.mod file, or an empty string.
+func goModulePath(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+
+		if module, err := strconv.Unquote(fields[1]); err == nil {
+			return module
+		}
+		return fields[1]
+	}
+
+	return ""
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/internal/version"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/mymyka/tables/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+var hashOpts struct {
+	snapshot string
+	tables   bool
+}
+
+var hashCmd = &cobra.Command{
+	Use:   "hash",
+	Short: "Print the canonical hash of the schema",
+	Long: `Print a hash of the introspected schema that only changes when a table or column
+does. The same hash is recorded in the manifest next to the generated code, which
+check --hash compares against.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runHash,
+}
+
+func init() {
+	hashCmd.Flags().StringVar(&hashOpts.snapshot, "snapshot", "", "Hash a schema snapshot instead of the database")
+	hashCmd.Flags().BoolVar(&hashOpts.tables, "tables", false, "Also print the hash of every table")
+
+	rootCmd.AddCommand(hashCmd)
+}
+
+func runHash(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var tables []schema.Table
+	if hashOpts.snapshot != "" {
+		tables, err = snapshot.Load(hashOpts.snapshot)
+		tables = introspect.Select(tables, cfg.Include, cfg.Exclude)
+	} else {
+		if cfg.Connection == "" {
+			return fmt.Errorf("%w, or hash a --snapshot", errNoConnection)
+		}
+		tables, err = readSchema(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(schema.Hash(tables))
+
+	if hashOpts.tables {
+		schema.Sort(tables)
+		for _, t := range tables {
+			fmt.Printf("%s  %s.%s\n", t.Hash(), t.Schema, t.Name)
+		}
+	}
+
+	return nil
+}
+
+// newManifest describes the code generated for tables with cfg.
+func newManifest(cfg *config.Config, tables []schema.Table) (writer.Manifest, error) {
+	options, err := optionsHash(cfg)
+	if err != nil {
+		return writer.Manifest{}, err
+	}
+
+	m := writer.Manifest{
+		ToolVersion: version.String(),
+		SchemaHash:  schema.Hash(tables),
+		OptionsHash: options,
+		Tables:      make(map[string]string, len(tables)),
+	}
+	for _, t := range tables {
+		m.Tables[t.Schema+"."+t.Name] = t.Hash()
+	}
+
+	return m, nil
+}
+
+// optionsHash fingerprints the settings of cfg that shape the generated
+// code, including the content of its templates.
+func optionsHash(cfg *config.Config) (string, error) {
+	h := sha256.New()
+
+	settings := struct {
+		Types            map[string]string
+		JSONMaps         []string
+		Money            string
+		ColumnTags       map[string][]string
+		Masking          *config.Masking
+		Naming           config.Naming
+		Layout           string
+		SearchPath       bool
+		FieldOrder       string
+		GoVersion        string
+		BuildTags        string
+		Nolint           []string
+		NoStutter        bool
+		MaxLineLength    int
+		PackagePrefix    string
+		ModulePath       string
+		OrderPackage     string
+		ResetPackage     string
+		ResetCascade     bool
+		FixturesPackage  string
+		StmtCachePackage string
+		ReplicaPackage   string
+		RetryPackage     string
+		TenantPackage    string
+		WherePackage     string
+		Placeholder      string
+		Tags             []string
+		Features         []string
+		Tables           map[string]config.Table
+		Enums            map[string]config.Enum
+		Convert          []string
+	}{cfg.Types, cfg.JSONMaps, cfg.Money, cfg.ColumnTags, cfg.Masking, cfg.Naming, cfg.Output.Layout, cfg.Output.SearchPath, cfg.Output.FieldOrder, cfg.Output.GoVersion, cfg.Output.BuildTags, cfg.Output.Nolint, cfg.Output.NoStutter, cfg.Output.MaxLineLength, cfg.Output.PackagePrefix, outputModulePath(cfg), cfg.Output.OrderPackage, cfg.Output.ResetPackage, cfg.Output.ResetCascade, cfg.Output.FixturesPackage, cfg.Output.StmtCachePackage, cfg.Output.ReplicaPackage, cfg.Output.RetryPackage, cfg.Output.TenantPackage, cfg.Output.WherePackage, cfg.Output.Placeholder, cfg.Output.Tags, cfg.Output.Features, cfg.Tables, cfg.Enums, cfg.Convert}
+	if err := json.NewEncoder(h).Encode(settings); err != nil {
+		return "", err
+	}
+
+	if cfg.Output.Templates != "" {
+		dir := os.DirFS(cfg.Output.Templates)
+		matches, err := fs.Glob(dir, "*.tmpl")
+		if err != nil {
+			return "", err
+		}
+		for _, name := range matches {
+			data, err := fs.ReadFile(dir, name)
+			if err != nil {
+				return "", fmt.Errorf("failed to read template: %w", err)
+			}
+			fmt.Fprintf(h, "%s %d\n", name, len(data))
+			h.Write(data)
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/mymyka/tables/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var historyOpts struct {
+	dir    string
+	format string
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [table[.column]...]",
+	Short: "Show when tables and columns appeared or changed across snapshots",
+	Long: `Replay the snapshots saved by snapshot --history, oldest first, and list when
+every table and column was added, changed, renamed or removed. Arguments are
+patterns against table, schema.table, table.column or schema.table.column that
+limit the output; a table pattern includes the table's columns.`,
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyOpts.dir, "dir", "schema_history", "History directory written by snapshot --history")
+	historyCmd.Flags().StringVar(&historyOpts.format, "format", "text", "Output format: text or json")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyOpts.format != "text" && historyOpts.format != "json" {
+		return withCode(exitUsage, fmt.Errorf("unknown format %q, expected text or json", historyOpts.format))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	versions, err := history.List(historyOpts.dir)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no snapshots in %s, add one with tables snapshot --history %s", historyOpts.dir, historyOpts.dir)
+	}
+
+	events, err := history.Events(versions, cfg.Renames)
+	if err != nil {
+		return err
+	}
+
+	var selected []history.Event
+	for _, e := range events {
+		if len(args) == 0 || matchEvent(args, e) {
+			selected = append(selected, e)
+		}
+	}
+
+	if historyOpts.format == "json" {
+		if selected == nil {
+			selected = []history.Event{}
+		}
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		if err := out.Encode(selected); err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+		return nil
+	}
+
+	table := ""
+	for _, e := range selected {
+		if name := e.Schema + "." + e.Table; name != table {
+			table = name
+			fmt.Println(table)
+		}
+
+		what := "table " + e.Kind
+		if e.Column != "" {
+			what = "column " + e.Column + " " + e.Kind
+		}
+		if e.Detail != "" {
+			what += ": " + e.Detail
+		}
+		fmt.Printf("  %s  %s\n", e.Version, what)
+	}
+
+	return nil
+}
+
+// matchEvent reports whether one of patterns matches the table of e or, for
+// a column event, its column.
+func matchEvent(patterns []string, e history.Event) bool {
+	candidates := []string{e.Table, e.Schema + "." + e.Table}
+	if e.Column != "" {
+		candidates = append(candidates, e.Table+"."+e.Column, e.Schema+"."+e.Table+"."+e.Column)
+	}
+
+	for _, p := range patterns {
+		for _, c := range candidates {
+			if ok, _ := path.Match(p, c); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var initOpts struct {
+	output     string
+	schemas    []string
+	goGenerate string
+	force      bool
+	yes        bool
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a starter tables.yaml",
+	Long: `Write a starter tables.yaml in the current directory. The database is
+detected from DB_CONNECTION_STRING, DATABASE_URL or the PG* variables. Missing
+values are prompted for unless --yes is given or stdin is not a terminal.`,
+	RunE: runInit,
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initOpts.output, "output", "o", "gen/tables", "Output directory path")
+	initCmd.Flags().StringSliceVar(&initOpts.schemas, "schemas", []string{"public"}, "Comma-separated list of schemas to read")
+	initCmd.Flags().StringVar(&initOpts.goGenerate, "go-generate", "", "Package directory to add a go:generate directive to")
+	initCmd.Flags().BoolVarP(&initOpts.force, "force", "f", false, "Overwrite an existing config file")
+	initCmd.Flags().BoolVarP(&initOpts.yes, "yes", "y", false, "Accept defaults without prompting")
+
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	path := config.FileNames[0]
+	if existing := config.Find("."); existing != "" && !initOpts.force {
+		return fmt.Errorf("config file %s already exists, use --force to overwrite it", existing)
+	}
+
+	connection := dbConnectionString
+	if connection == "" {
+		detected, source := config.DetectConnection()
+		if detected != "" {
+			slog.Info("Detected database", "source", source)
+		}
+		connection = detected
+	}
+
+	schemas := strings.Join(initOpts.schemas, ",")
+	output := initOpts.output
+	goGenerate := initOpts.goGenerate
+
+	// Ask for anything not given as a flag
+	if !initOpts.yes && isTerminal(os.Stdin) {
+		in := bufio.NewReader(os.Stdin)
+		flags := cmd.Flags()
+
+		if !flags.Changed("db") {
+			connection = prompt(in, "Database connection", connection)
+		}
+		if !flags.Changed("schemas") {
+			schemas = prompt(in, "Schemas", schemas)
+		}
+		if !flags.Changed("output") {
+			output = prompt(in, "Output directory", output)
+		}
+		if !flags.Changed("go-generate") {
+			goGenerate = prompt(in, "Package to add go:generate to (empty to skip)", goGenerate)
+		}
+	}
+
+	content := config.Starter(connection, strings.Split(schemas, ","), output)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return withCode(exitWrite, fmt.Errorf("failed to write config: %w", err))
+	}
+	slog.Info("Wrote config", "file", path)
+
+	if goGenerate != "" {
+		file, err := writeGoGenerate(goGenerate)
+		if err != nil {
+			return withCode(exitWrite, fmt.Errorf("failed to add go:generate directive: %w", err))
+		}
+		slog.Info("Wrote go:generate directive", "file", file)
+	}
+
+	return nil
+}
+
+// writeGoGenerate adds a file holding a go:generate directive to the package
+// in dir. The directive runs tables from the directory holding the config.
+func writeGoGenerate(dir string) (string, error) {
+	pkg, err := packageName(dir)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := filepath.Abs(".")
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(abs, root)
+	if err != nil {
+		return "", err
+	}
+
+	content := "package " + pkg + "\n\n"
+	content += "//go:generate sh -c \"cd " + filepath.ToSlash(rel) + " && tables generate\"\n"
+
+	path := filepath.Join(dir, "tables_generate.go")
+	if _, err := os.Stat(path); err == nil && !initOpts.force {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
+
+// packageName reads the package clause of the Go files in dir, falling back
+// to the directory name for an empty package.
+func packageName(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	for name := range pkgs {
+		return name, nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(filepath.Base(abs), "-", "_"), nil
+}
+
+func prompt(in *bufio.Reader, label, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", label, def)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+
+	line, _ := in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
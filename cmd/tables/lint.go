@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mymyka/tables/internal/lint"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var lintOpts struct {
+	snapshot string
+	since    string
+	rules    []string
+	format   string
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint-schema",
+	Short: "Check table and column names against naming conventions",
+	Long: `Check the schema against naming conventions and exit with code 8 on violations:
+
+  snake-case  table and column names are lower snake_case
+  pk-id       every table has a single-column primary key named id
+  fk-name     a single-column foreign key is named <table>_id, singular or not
+  reserved    no table or column is named after a PostgreSQL reserved word
+
+Rules and ignored tables or columns are set under lint in the config file.
+--since reports only the tables and columns missing from a snapshot, so an
+existing schema can adopt the conventions for new migrations first.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintOpts.snapshot, "snapshot", "", "Lint a schema snapshot instead of the database")
+	lintCmd.Flags().StringVar(&lintOpts.since, "since", "", "Only report tables and columns not in this snapshot")
+	lintCmd.Flags().StringSliceVar(&lintOpts.rules, "rules", nil, "Rules to check (default: lint.rules, or all of them)")
+	lintCmd.Flags().StringVar(&lintOpts.format, "format", "text", "Output format: text or json")
+
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintOpts.format != "text" && lintOpts.format != "json" {
+		return withCode(exitUsage, fmt.Errorf("unknown format %q, expected text or json", lintOpts.format))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var tables []schema.Table
+	if lintOpts.snapshot != "" {
+		tables, err = snapshot.Load(lintOpts.snapshot)
+		tables = introspect.Select(tables, cfg.Include, cfg.Exclude)
+	} else {
+		if cfg.Connection == "" {
+			return fmt.Errorf("%w, or lint a --snapshot", errNoConnection)
+		}
+		tables, err = readSchema(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	rules := cfg.Lint.Rules
+	if len(lintOpts.rules) > 0 {
+		rules = lintOpts.rules
+	}
+	violations, err := lint.Check(tables, lint.Options{Rules: rules, Ignore: cfg.Lint.Ignore})
+	if err != nil {
+		return err
+	}
+
+	if lintOpts.since != "" {
+		baseline, err := snapshot.Load(lintOpts.since)
+		if err != nil {
+			return err
+		}
+		violations = newViolations(violations, baseline)
+	}
+
+	if lintOpts.format == "json" {
+		if violations == nil {
+			violations = []lint.Violation{}
+		}
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		if err := out.Encode(violations); err != nil {
+			return fmt.Errorf("failed to encode violations: %w", err)
+		}
+	} else {
+		for _, v := range violations {
+			name := v.Schema + "." + v.Table
+			if v.Column != "" {
+				name += "." + v.Column
+			}
+			fmt.Printf("%s: %s (%s)\n", name, v.Message, v.Rule)
+		}
+	}
+
+	if len(violations) > 0 {
+		return withCode(exitLint, fmt.Errorf("%d naming violations", len(violations)))
+	}
+
+	return nil
+}
+
+// newViolations drops the violations of the tables and columns baseline
+// already has. A table-level violation is kept only for a new table.
+func newViolations(violations []lint.Violation, baseline []schema.Table) []lint.Violation {
+	existing := make(map[string]bool)
+	for _, t := range baseline {
+		existing[t.Schema+"."+t.Name] = true
+		for _, c := range t.Columns {
+			existing[t.Schema+"."+t.Name+"."+c.Name] = true
+		}
+	}
+
+	var kept []lint.Violation
+	for _, v := range violations {
+		name := v.Schema + "." + v.Table
+		if v.Column != "" {
+			name += "." + v.Column
+		}
+		if !existing[name] {
+			kept = append(kept, v)
+		}
+	}
+
+	return kept
+}
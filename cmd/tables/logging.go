@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/mymyka/tables/internal/redact"
+)
+
+// Logging flags shared by every command
+var (
+	verbose   bool
+	quiet     bool
+	logFormat string
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.BoolVarP(&verbose, "verbose", "v", false, "Log per-table progress and the SQL issued")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "Only log warnings and errors")
+	flags.StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+}
+
+// setupLogging installs the default logger. Logs always go to stderr so
+// stdout stays free for command output such as diffs and exports.
+func setupLogging() error {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	if quiet {
+		level = slog.LevelWarn
+	}
+
+	var handler slog.Handler
+	switch logFormat {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: level,
+			// Timestamps only add noise to interactive output
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", logFormat)
+	}
+
+	// Connection strings in messages and errors never show their password
+	slog.SetDefault(slog.New(redact.Handler(handler)))
+	return nil
+}
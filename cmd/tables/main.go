@@ -3,98 +3,412 @@ package main
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strings"
 
-	"github.com/mymyka/tables/internal/builder"
-	"github.com/mymyka/tables/internal/parser"
-	"github.com/mymyka/tables/internal/writer"
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/redact"
+	"github.com/mymyka/tables/internal/telemetry"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/mymyka/tables/pkg/writer"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// Connection and introspection flags shared by every command
 var (
 	dbConnectionString string
-	outputPath         string
+	configPath         string
+	profileNames       []string
+	envName            string
+	passwordFile       string
+	passwordPrompt     bool
+	passwordCommand    string
+	setRole            string
+	maxConns           int
+	maxIdleConns       int
+	singleConn         bool
+	schemas            []string
+	includeTables      []string
+	excludeTables      []string
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "datatypes",
+	Use:   "tables",
 	Short: "Generate Go types from PostgreSQL database schema",
 	Long: `A CLI tool that connects to a PostgreSQL database, reads the schema,
 and generates Go type definitions for each table with proper type mappings.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if dbConnectionString == "" {
-			log.Fatal("Database connection string is required. Use --db flag or set DB_CONNECTION_STRING environment variable.")
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return withCode(exitUsage, err)
 		}
-
-		if outputPath == "" {
-			log.Fatal("Output path is required. Use --output flag.")
+		if err := startTelemetry(cmd); err != nil {
+			return withCode(exitUsage, err)
 		}
-
-		generateTypes()
+		return startProfiling()
 	},
+	RunE: runRoot,
+
+	// Errors are logged by main, with the exit code they carry
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func init() {
-	// Add flags
-	rootCmd.Flags().StringVarP(&dbConnectionString, "db", "d", "", "PostgreSQL connection string (required)")
-	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output directory path (required)")
+	flags := rootCmd.PersistentFlags()
+	flags.StringVarP(&dbConnectionString, "db", "d", "", "PostgreSQL connection string")
+	flags.StringVarP(&configPath, "config", "c", "", "Config file path (default: tables.yaml, tables.yml or tables.toml)")
+	flags.StringSliceVarP(&profileNames, "profile", "p", nil, "Comma-separated list of config profiles to apply")
+	flags.StringVarP(&envName, "env", "e", "", "Named connection from the config file to use (e.g. dev, staging, prod)")
+	flags.StringVar(&passwordFile, "password-file", "", "Read the database password from this file")
+	flags.StringVar(&passwordCommand, "password-cmd", "", "Run this shell command and use its output as the database password")
+	flags.BoolVar(&passwordPrompt, "password-prompt", false, "Prompt for the database password, or read it from stdin when not a terminal")
+	flags.StringVar(&setRole, "set-role", "", "Run SET ROLE to this role after connecting, to read the schema with its privileges")
+	flags.IntVar(&maxConns, "max-conns", 0, "Maximum number of open database connections (default: unlimited)")
+	flags.IntVar(&maxIdleConns, "max-idle-conns", 0, "Maximum number of idle database connections (default: 2)")
+	flags.BoolVar(&singleConn, "single-conn", false, "Use a single database session for everything, e.g. behind pgbouncer in transaction mode")
+	flags.StringSliceVar(&schemas, "schemas", nil, "Comma-separated list of schemas to read (default: public)")
+	flags.StringSliceVar(&includeTables, "include", nil, "Comma-separated list of tables to include")
+	flags.StringSliceVar(&excludeTables, "exclude", nil, "Comma-separated list of tables to exclude")
+	registerCompletions()
 
-	// Mark flags as required
-	rootCmd.MarkFlagRequired("db")
-	rootCmd.MarkFlagRequired("output")
+	// Output flags of the deprecated root invocation
+	addOutputFlags(rootCmd)
+	rootCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Hidden = true
+	})
+}
 
-	// Allow environment variable for db connection
-	if envDB := os.Getenv("DB_CONNECTION_STRING"); envDB != "" {
-		dbConnectionString = envDB
+// runRoot keeps `tables --db ... --output ...` working as it did before
+// generate became a subcommand.
+func runRoot(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().NFlag() == 0 {
+		return cmd.Help()
 	}
+
+	slog.Warn("Running tables without a subcommand is deprecated, use `tables generate` instead")
+	return runGenerate(cmd, args)
 }
 
-func generateTypes() {
-	fmt.Printf("Connecting to database...\n")
+// loadConfig is loadConfigs for commands that work on a single profile.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfgs, err := loadConfigs(cmd)
+	if err != nil {
+		return nil, err
+	}
 
-	// Connect to database
-	db, err := sql.Open("postgres", dbConnectionString)
+	if len(cfgs) > 1 {
+		return nil, fmt.Errorf("%s works on a single profile, select one with --profile", cmd.CommandPath())
+	}
+
+	return cfgs[0], nil
+}
+
+// loadConfigs reads the config file, resolves the selected profiles, and then
+// overrides each of them with the environment and any flags set on the
+// command line.
+func loadConfigs(cmd *cobra.Command) ([]*config.Config, error) {
+	cfgs := []*config.Config{{}}
+
+	loaded, _, err := findConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded != nil {
+		cfgs, err = loaded.Resolve(profileNames)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(profileNames) > 0 {
+		return nil, fmt.Errorf("profile %q requires a config file", profileNames[0])
+	} else if envName != "" {
+		return nil, fmt.Errorf("env %q requires a config file", envName)
+	}
+
+	for _, cfg := range cfgs {
+		if err := applyOverrides(cmd, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfgs, nil
+}
+
+// findConfig loads the config file given with --config, or else the config
+// files discovered from the working directory up, so that tables also works
+// from a package directory under go generate. It returns the path of the
+// file, or of the nearest discovered one, and nil without any config file.
+func findConfig() (*config.Config, string, error) {
+	if configPath == "" {
+		return config.Discover(".")
+	}
+
+	cfg, err := config.Load(configPath)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return nil, "", err
+	}
+
+	return cfg, configPath, nil
+}
+
+// applyOverrides overrides cfg with the environment and any flags set on the
+// command line.
+func applyOverrides(cmd *cobra.Command, cfg *config.Config) error {
+	// --db wins, then --env, then the environment variable, then the config
+	flags := cmd.Flags()
+	envDB := os.Getenv("DB_CONNECTION_STRING")
+	switch {
+	case flags.Changed("db"):
+		cfg.Connection = dbConnectionString
+	case envName == "" && envDB != "":
+		cfg.Connection = envDB
+	default:
+		conn, err := cfg.Env(envName)
+		if err != nil {
+			return err
+		}
+		cfg.Connection = conn
+	}
+
+	if err := applyPassword(cmd, cfg); err != nil {
+		return err
+	}
+
+	if flags.Changed("set-role") {
+		cfg.Role = setRole
+	}
+	if flags.Changed("max-conns") {
+		cfg.Pool.MaxOpen = maxConns
+	}
+	if flags.Changed("max-idle-conns") {
+		cfg.Pool.MaxIdle = maxIdleConns
+	}
+	if flags.Changed("single-conn") {
+		cfg.Pool.Single = singleConn
+	}
+	if cfg.Pool.MaxOpen < 0 || cfg.Pool.MaxIdle < 0 {
+		return withCode(exitUsage, fmt.Errorf("connection limits must not be negative"))
+	}
+	if flags.Changed("schemas") {
+		cfg.Schemas = schemas
+	}
+	if flags.Changed("include") {
+		cfg.Include = includeTables
+	}
+	if flags.Changed("exclude") {
+		cfg.Exclude = excludeTables
+	}
+
+	// Output flags only exist on commands that write or compare code
+	if flags.Lookup("output") != nil {
+		if flags.Changed("output") {
+			cfg.Output.Dir = outputPath
+		}
+		if flags.Changed("init-module") {
+			cfg.Output.Module = initModule
+		}
+		if flags.Changed("module-path") {
+			cfg.Output.ModulePath = modulePath
+		}
+		if flags.Changed("package-prefix") {
+			cfg.Output.PackagePrefix = packagePrefix
+		}
+		if flags.Changed("templates") {
+			cfg.Output.Templates = templatesDir
+		}
+		if flags.Changed("go-version") {
+			cfg.Output.GoVersion = goVersion
+		}
+		if flags.Changed("build-tags") {
+			cfg.Output.BuildTags = buildTags
+		}
+		if flags.Changed("file-suffix") {
+			cfg.Output.FileSuffix = fileSuffix
+		}
+	}
+
+	if flags.Lookup("plugin") != nil {
+		for _, p := range pluginFlags {
+			name, out, ok := strings.Cut(p, "=")
+			if !ok || name == "" || out == "" {
+				return withCode(exitUsage, fmt.Errorf("invalid --plugin %q, expected name=dir", p))
+			}
+			cfg.Plugins = append(cfg.Plugins, config.Plugin{Name: name, Out: out})
+		}
+	}
+
+	switch cfg.Output.Layout {
+	case "", "flat", "schema":
+	default:
+		return fmt.Errorf("unknown output layout %q, expected flat or schema", cfg.Output.Layout)
+	}
+
+	if err := writer.CheckFileSuffix(cfg.Output.FileSuffix); err != nil {
+		return err
+	}
+
+	switch cfg.Money {
+	case "", "decimal", "cents", "string":
+	default:
+		return fmt.Errorf("unknown money mapping %q, expected decimal, cents or string", cfg.Money)
+	}
+
+	return nil
+}
+
+// readSchema connects to the configured database and reads its tables.
+func readSchema(cfg *config.Config) ([]schema.Table, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
 	}
 	defer db.Close()
 
+	if err := checkPrivileges(db, cfg); err != nil {
+		return nil, err
+	}
+
+	return readTables(db, cfg)
+}
+
+// connect opens and pings the configured database.
+func connect(cfg *config.Config) (*sql.DB, error) {
+	slog.Info("Connecting to database")
+	redact.Register(redact.Password(cfg.Connection))
+
+	span := telemetry.Start("connect", "db.system", "postgresql")
+
+	// Connect to database
+	db, err := openDB(cfg)
+	if err != nil {
+		err = withCode(exitConnection, fmt.Errorf("failed to connect to database: %w", err))
+		span.End(err)
+		return nil, err
+	}
+
 	// Test connection
 	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
+		db.Close()
+		err = withCode(exitConnection, fmt.Errorf("failed to ping database: %w", err))
+		span.End(err)
+		return nil, err
 	}
+	span.End(nil)
 
-	fmt.Printf("Connected successfully!\n")
-	fmt.Printf("Parsing database schema...\n")
+	slog.Info("Connected successfully")
 
-	inspector := parser.NewSchemaParser(db)
+	return db, nil
+}
 
-	tables, err := inspector.GetTables()
+// openDB opens the configured database with the role and connection limits
+// of cfg, without connecting yet.
+func openDB(cfg *config.Config) (*sql.DB, error) {
+	connector, err := pq.NewConnector(cfg.Connection)
 	if err != nil {
-		log.Fatal("Failed to get tables:", err)
+		return nil, err
 	}
 
-	fmt.Printf("Found %d tables\n", len(tables))
-	fmt.Printf("Generating Go types...\n")
+	var db *sql.DB
+	if cfg.Role != "" {
+		slog.Debug("Switching role", "role", cfg.Role)
+		db = sql.OpenDB(roleConnector{connector, cfg.Role})
+	} else {
+		db = sql.OpenDB(connector)
+	}
 
-	block := builder.Build(tables)
+	// One connection kept idle is reused for every query
+	if cfg.Pool.Single {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		return db, nil
+	}
+	if cfg.Pool.MaxOpen > 0 {
+		db.SetMaxOpenConns(cfg.Pool.MaxOpen)
+	}
+	if cfg.Pool.MaxIdle > 0 {
+		db.SetMaxIdleConns(cfg.Pool.MaxIdle)
+	}
 
-	fmt.Printf("Writing files to %s...\n", outputPath)
+	return db, nil
+}
 
-	err = writer.Write(outputPath, block)
+// readTables reads the configured schemas from an open database.
+func readTables(db *sql.DB, cfg *config.Config) ([]schema.Table, error) {
+	slog.Debug("Parsing database schema", "schemas", cfg.Schemas)
+
+	inspector := introspect.NewSchemaParser(db, introspect.Options{
+		Schemas:  cfg.Schemas,
+		Include:  cfg.Include,
+		Exclude:  cfg.Exclude,
+		Progress: newProgress("Introspecting"),
+		Stats:    exportStats,
+
+		KeepGoing: keepGoing,
+		Skip: func(schemaName, tableName string, err error) {
+			failures = append(failures, runFailure{Stage: "introspect", Table: schemaName + "." + tableName, Error: err.Error()})
+		},
+	})
+
+	span := telemetry.Start("introspect", "tables.schemas", cfg.Schemas)
+	tables, err := inspector.GetTables()
 	if err != nil {
-		log.Fatal("Failed to write files:", err)
+		err = withCode(exitIntrospection, fmt.Errorf("failed to get tables: %w", err))
+		span.End(err)
+		return nil, err
 	}
+	span.Set("tables.tables", len(tables))
+	span.End(nil)
 
-	fmt.Printf("Successfully generated types for %d tables!\n", len(tables))
+	if !serverChecked {
+		if server, err := inspector.Server(); err == nil {
+			checkServer(cfg, server)
+		}
+		serverChecked = true
+	}
+
+	return tables, nil
+}
+
+// serverChecked is set once checkServer ran, so watch warns only once.
+var serverChecked bool
+
+// checkServer warns when the server is older than supported, or lacks a
+// feature the config asks for.
+func checkServer(cfg *config.Config, server introspect.Server) {
+	if server.Version < introspect.MinServerVersion {
+		slog.Warn("Unsupported PostgreSQL version, the schema may be read incompletely",
+			"version", server, "minimum", introspect.Server{Version: introspect.MinServerVersion})
+	}
+
+	if !server.Multiranges() {
+		for key := range cfg.Types {
+			if strings.HasSuffix(key, "multirange") {
+				slog.Warn("Type override for multiranges, which the server does not have", "type", key, "version", server, "minimum", "14")
+			}
+		}
+	}
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	// Flag errors are reported before PersistentPreRunE, so start with the
+	// default log format
+	setupLogging()
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withCode(exitUsage, fmt.Errorf("%w (see %s --help)", err, cmd.CommandPath()))
+	})
+
+	err := rootCmd.Execute()
+	stopProfiling()
+	stopTelemetry(err)
+
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(exitCode(err))
 	}
 }
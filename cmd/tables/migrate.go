@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mymyka/tables/internal/diff"
+	"github.com/mymyka/tables/internal/migrate"
+	"github.com/mymyka/tables/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateDir   string
+	migrateName  string
+	migrateEmbed bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Plan schema migrations",
+}
+
+var migratePlanCmd = &cobra.Command{
+	Use:   "plan <from> <to>",
+	Short: "Emit SQL that moves one schema state to another",
+	Long: `Emit CREATE/ALTER/DROP statements that move the schema from one source to
+another; sources work as in diff. With --dir, golang-migrate up and down files
+are written instead of printing the plan. Tables listed under renames in the
+config file are renamed instead of dropped and created. Always review the SQL
+before applying it.
+
+With --embed, the directory also gets an embed.go embedding its SQL files, with
+a Migrate(db) function applying them with golang-migrate.`,
+	Args: usageArgs(cobra.ExactArgs(2)),
+	RunE: runMigratePlan,
+}
+
+func init() {
+	migratePlanCmd.Flags().StringVar(&migrateDir, "dir", "", "Write golang-migrate up/down files into this directory")
+	migratePlanCmd.Flags().StringVar(&migrateName, "name", "schema_change", "Migration name used in file names")
+	migratePlanCmd.Flags().BoolVar(&migrateEmbed, "embed", false, "Also write an embed.go with a Migrate(db) helper into --dir")
+
+	migrateCmd.AddCommand(migratePlanCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigratePlan(cmd *cobra.Command, args []string) error {
+	if migrateEmbed && migrateDir == "" {
+		return withCode(exitUsage, errors.New("--embed needs --dir"))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	from, err := loadSource(cfg, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load source %s: %w", args[0], err)
+	}
+	to, err := loadSource(cfg, args[1])
+	if err != nil {
+		return fmt.Errorf("failed to load source %s: %w", args[1], err)
+	}
+
+	reversed := make(map[string]string, len(cfg.Renames))
+	for old, name := range cfg.Renames {
+		reversed[name] = old
+	}
+
+	up := migrate.Render(migrate.Statements(diff.CompareRenamed(from, to, cfg.Renames)))
+	down := migrate.Render(migrate.Statements(diff.CompareRenamed(to, from, reversed)))
+
+	if migrateDir == "" {
+		fmt.Print(up)
+		return nil
+	}
+
+	if err := os.MkdirAll(migrateDir, 0755); err != nil {
+		return withCode(exitWrite, fmt.Errorf("failed to create migration directory: %w", err))
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	for _, file := range []struct{ suffix, content string }{{"up", up}, {"down", down}} {
+		path := filepath.Join(migrateDir, version+"_"+migrateName+"."+file.suffix+".sql")
+		if err := os.WriteFile(path, []byte(file.content), 0644); err != nil {
+			return withCode(exitWrite, fmt.Errorf("failed to write migration: %w", err))
+		}
+		slog.Info("Wrote migration", "file", path)
+	}
+
+	if migrateEmbed {
+		if err := writeEmbed(migrateDir); err != nil {
+			return withCode(exitWrite, err)
+		}
+	}
+
+	slog.Warn("Review the migration before applying it")
+
+	return nil
+}
+
+// writeEmbed writes the embed.go of a migration directory, unless the
+// directory has a hand-written one.
+func writeEmbed(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, migrate.EmbedFile)
+	generated, err := writer.IsGenerated(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && !generated {
+		slog.Warn("Leaving hand-written file alone", "file", path)
+		return nil
+	}
+
+	src, err := migrate.EmbedSource(filepath.Base(abs))
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", migrate.EmbedFile, err)
+	}
+
+	result, err := writer.WriteFiles(dir, map[string]string{migrate.EmbedFile: src})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", migrate.EmbedFile, err)
+	}
+	if len(result.Written) > 0 {
+		slog.Info("Wrote embed file", "file", path)
+	}
+
+	return nil
+}
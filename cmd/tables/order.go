@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var orderOpts struct {
+	snapshot string
+	reverse  bool
+}
+
+var orderCmd = &cobra.Command{
+	Use:   "order",
+	Short: "Print the tables in foreign key order",
+	Long: `Print the tables one schema.name per line so that every table comes after the
+tables its foreign keys reference, the order to insert rows in. --reverse prints
+the order to delete or truncate them in.
+
+Tables referencing each other in a cycle cannot be ordered that way. They are
+printed next to each other and reported as a warning.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runOrder,
+}
+
+func init() {
+	orderCmd.Flags().StringVar(&orderOpts.snapshot, "snapshot", "", "Order the tables of a schema snapshot instead of the database")
+	orderCmd.Flags().BoolVar(&orderOpts.reverse, "reverse", false, "Print the delete order instead")
+
+	rootCmd.AddCommand(orderCmd)
+}
+
+func runOrder(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var tables []schema.Table
+	if orderOpts.snapshot != "" {
+		tables, err = snapshot.Load(orderOpts.snapshot)
+		tables = introspect.Select(tables, cfg.Include, cfg.Exclude)
+	} else {
+		if cfg.Connection == "" {
+			return fmt.Errorf("%w, or order a --snapshot", errNoConnection)
+		}
+		tables, err = readSchema(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	ordered, cycles := schema.InsertOrder(tables)
+	for _, cycle := range cycles {
+		slog.Warn("Tables reference each other in a cycle", "tables", strings.Join(cycle, ", "))
+	}
+
+	if orderOpts.reverse {
+		slices.Reverse(ordered)
+	}
+	for _, t := range ordered {
+		fmt.Println(t.Schema + "." + t.Name)
+	}
+
+	return nil
+}
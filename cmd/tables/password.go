@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/redact"
+	"github.com/mymyka/tables/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// prompted holds the password entered at the prompt, asked for only once
+// however many profiles are loaded.
+var prompted *string
+
+// applyPassword sets the password of --password-prompt, --password-file,
+// --password-cmd, password_file, password_command, vault or aws_secret, the
+// first set in that order, into the connection string of cfg, and registers
+// every password of cfg for redaction.
+func applyPassword(cmd *cobra.Command, cfg *config.Config) error {
+	var password string
+	var err error
+	switch {
+	case cfg.Connection == "":
+	case passwordPrompt && prompted != nil:
+		password = *prompted
+	case passwordPrompt:
+		password, err = promptPassword(cfg.Connection)
+		prompted = &password
+	case cmd.Flags().Changed("password-file"):
+		password, err = readPasswordFile(passwordFile)
+	case cmd.Flags().Changed("password-cmd"):
+		password, err = secrets.Command(passwordCommand)
+	case cfg.PasswordFile != "":
+		password, err = readPasswordFile(cfg.PasswordFile)
+	case cfg.PasswordCommand != "":
+		password, err = secrets.Command(cfg.PasswordCommand)
+	case cfg.Vault != nil:
+		password, err = secrets.Vault{Address: cfg.Vault.Address, Path: cfg.Vault.Path, Field: cfg.Vault.Field}.Read()
+	case cfg.AWSSecret != nil:
+		password, err = secrets.AWS{SecretID: cfg.AWSSecret.SecretID, Region: cfg.AWSSecret.Region, Field: cfg.AWSSecret.Field}.Read()
+	}
+	if err != nil {
+		return err
+	}
+	if password != "" {
+		redact.Register(password)
+		cfg.Connection = redact.WithPassword(cfg.Connection, password)
+	}
+
+	redact.Register(redact.Password(cfg.Connection))
+	for _, conn := range cfg.Connections {
+		redact.Register(redact.Password(conn))
+	}
+
+	return nil
+}
+
+// readPasswordFile reads a password from a file, without trailing newlines.
+func readPasswordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %w", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// promptPassword asks for the password of the connection string dsn on a
+// terminal without echoing it, or reads the first line of stdin when it is
+// not a terminal.
+func promptPassword(dsn string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Password for %s: ", redact.String(dsn))
+	password, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	return string(password), nil
+}
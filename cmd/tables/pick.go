@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/spf13/cobra"
+)
+
+var pickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Interactively pick the tables to generate",
+	Long: `List the tables of the database with checkboxes and write the picked
+tables back into the config file as schemas and include, replacing exclude.
+Without schemas in the config, every non-system schema is listed.`,
+	RunE: runPick,
+}
+
+func init() {
+	rootCmd.AddCommand(pickCmd)
+}
+
+func runPick(cmd *cobra.Command, args []string) error {
+	if !isTerminal(os.Stdin) {
+		return withCode(exitUsage, errors.New("tables pick needs an interactive terminal"))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Connection == "" {
+		return errNoConnection
+	}
+
+	_, path, err := findConfig()
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = config.FileNames[0]
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	schemaNames := cfg.Schemas
+	if len(schemaNames) == 0 {
+		if schemaNames, err = introspect.NewSchemaParser(db, introspect.Options{}).ListSchemas(); err != nil {
+			return withCode(exitIntrospection, err)
+		}
+	}
+
+	names, err := introspect.NewSchemaParser(db, introspect.Options{Schemas: schemaNames}).ListQualifiedTables()
+	if err != nil {
+		return withCode(exitIntrospection, err)
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("no tables found in schemas %s", strings.Join(schemaNames, ", "))
+	}
+
+	m := newPicker(names, cfg.Include, cfg.Exclude)
+	final, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return fmt.Errorf("failed to run picker: %w", err)
+	}
+
+	m = final.(picker)
+	if !m.saved {
+		fmt.Println("Selection discarded.")
+		return nil
+	}
+
+	// An empty include would select every table
+	picked, pickedSchemas := m.selection()
+	if len(picked) == 0 {
+		return errors.New("no tables picked, selection discarded")
+	}
+
+	if err := config.SaveSelection(path, pickedSchemas, picked); err != nil {
+		return withCode(exitWrite, err)
+	}
+
+	fmt.Printf("Saved %d tables to %s.\n", len(picked), path)
+
+	return nil
+}
+
+// pickItem is a table row of the picker.
+type pickItem struct {
+	schema   string
+	name     string
+	selected bool
+}
+
+// picker is the bubbletea model listing tables with checkboxes.
+type picker struct {
+	items  []pickItem
+	cursor int
+	offset int
+	height int
+	saved  bool
+}
+
+func newPicker(names, include, exclude []string) picker {
+	var items []pickItem
+	for _, n := range names {
+		schemaName, tableName, _ := strings.Cut(n, ".")
+		items = append(items, pickItem{
+			schema:   schemaName,
+			name:     tableName,
+			selected: introspect.Selected(include, exclude, schemaName, tableName),
+		})
+	}
+
+	return picker{items: items, height: 20}
+}
+
+func (m picker) Init() tea.Cmd {
+	return nil
+}
+
+func (m picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the header and help lines
+		m.height = max(msg.Height-4, 1)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "enter":
+			m.saved = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+		case " ", "x":
+			m.items[m.cursor].selected = !m.items[m.cursor].selected
+		case "s":
+			// Toggle every table of the schema under the cursor
+			schemaName := m.items[m.cursor].schema
+			all := true
+			for _, it := range m.items {
+				if it.schema == schemaName && !it.selected {
+					all = false
+				}
+			}
+			for i := range m.items {
+				if m.items[i].schema == schemaName {
+					m.items[i].selected = !all
+				}
+			}
+		case "a":
+			all := true
+			for _, it := range m.items {
+				all = all && it.selected
+			}
+			for i := range m.items {
+				m.items[i].selected = !all
+			}
+		}
+	}
+
+	// Keep the cursor on screen
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+
+	return m, nil
+}
+
+func (m picker) View() string {
+	var b strings.Builder
+
+	count := 0
+	for _, it := range m.items {
+		if it.selected {
+			count++
+		}
+	}
+	fmt.Fprintf(&b, "Pick tables to generate (%d of %d selected)\n\n", count, len(m.items))
+
+	end := min(m.offset+m.height, len(m.items))
+	for i := m.offset; i < end; i++ {
+		it := m.items[i]
+
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		check := " "
+		if it.selected {
+			check = "x"
+		}
+
+		fmt.Fprintf(&b, "%s [%s] %s.%s\n", cursor, check, it.schema, it.name)
+	}
+
+	b.WriteString("\nspace: toggle  s: toggle schema  a: toggle all  enter: save  q: quit\n")
+
+	return b.String()
+}
+
+// selection returns the include list and the schemas of the picked tables.
+// Tables are listed by bare name when they all live in one schema.
+func (m picker) selection() ([]string, []string) {
+	var schemaNames []string
+	seen := make(map[string]bool)
+	for _, it := range m.items {
+		if it.selected && !seen[it.schema] {
+			seen[it.schema] = true
+			schemaNames = append(schemaNames, it.schema)
+		}
+	}
+
+	var include []string
+	for _, it := range m.items {
+		if !it.selected {
+			continue
+		}
+		if len(schemaNames) > 1 {
+			include = append(include, it.schema+"."+it.name)
+		} else {
+			include = append(include, it.name)
+		}
+	}
+
+	return include, schemaNames
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Diagnostics flags shared by every command
+var (
+	cpuProfile string
+	memProfile string
+)
+
+// cpuProfileFile is the open CPU profile while one is recorded.
+var cpuProfileFile *os.File
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&cpuProfile, "profile-cpu", "", "Write a CPU profile to this file, for go tool pprof")
+	flags.StringVar(&memProfile, "profile-mem", "", "Write a heap profile to this file when the command ends, for go tool pprof")
+}
+
+// startProfiling starts the CPU profile requested with --profile-cpu.
+func startProfiling() error {
+	if cpuProfile == "" {
+		return nil
+	}
+
+	f, err := os.Create(cpuProfile)
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	cpuProfileFile = f
+
+	return nil
+}
+
+// stopProfiling finishes the CPU profile and writes the heap profile. It
+// runs however the command ended, so failures are only logged.
+func stopProfiling() {
+	if cpuProfileFile != nil {
+		pprof.StopCPUProfile()
+		cpuProfileFile.Close()
+		cpuProfileFile = nil
+	}
+
+	if memProfile == "" {
+		return
+	}
+
+	f, err := os.Create(memProfile)
+	if err != nil {
+		slog.Warn("Failed to create heap profile", "error", err)
+		return
+	}
+	defer f.Close()
+
+	// Collect garbage first so the profile shows live memory
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		slog.Warn("Failed to write heap profile", "error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/spf13/cobra"
+)
+
+var preflightOpts struct {
+	format string
+}
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Check that the database role can read the schema",
+	Long: `Check that the role tables connects as, or switches to with --set-role, can
+read every catalog introspection queries and use every configured schema, and
+list the selected tables it cannot see at all, which introspection would
+silently leave out. Each problem comes with the GRANT fixing it, and the
+command exits with code 5 when there is any.
+
+Commands reading the schema run the same check first, fail on missing catalog
+and schema privileges and warn about hidden tables.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runPreflight,
+}
+
+func init() {
+	preflightCmd.Flags().StringVar(&preflightOpts.format, "format", "text", "Output format: text or json")
+
+	rootCmd.AddCommand(preflightCmd)
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	if preflightOpts.format != "text" && preflightOpts.format != "json" {
+		return withCode(exitUsage, fmt.Errorf("unknown format %q, expected text or json", preflightOpts.format))
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if cfg.Connection == "" {
+		return errNoConnection
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	problems, err := preflightParser(db, cfg).Preflight()
+	if err != nil {
+		return withCode(exitIntrospection, err)
+	}
+
+	if preflightOpts.format == "json" {
+		if problems == nil {
+			problems = []introspect.Problem{}
+		}
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		if err := out.Encode(problems); err != nil {
+			return fmt.Errorf("failed to encode problems: %w", err)
+		}
+	} else {
+		printProblems(os.Stdout, problems)
+	}
+
+	if len(problems) > 0 {
+		return withCode(exitIntrospection, fmt.Errorf("%d privilege problems", len(problems)))
+	}
+
+	return nil
+}
+
+// checkPrivileges runs the preflight check before the schema is read,
+// failing with the report on fatal problems and warning about the others.
+// A server the check itself fails on is read anyway.
+func checkPrivileges(db *sql.DB, cfg *config.Config) error {
+	problems, err := preflightParser(db, cfg).Preflight()
+	if err != nil {
+		slog.Warn("Skipping the privilege check", "error", err)
+		return nil
+	}
+
+	fatal := 0
+	for _, p := range problems {
+		if p.Fatal {
+			fatal++
+		} else {
+			slog.Warn("Introspection is incomplete", "object", p.Object, "reason", p.Reason, "fix", p.Grant)
+		}
+	}
+	if fatal == 0 {
+		return nil
+	}
+
+	printProblems(os.Stderr, problems)
+
+	return withCode(exitIntrospection, fmt.Errorf("the database role cannot read the schema, %d privileges are missing", fatal))
+}
+
+// preflightParser returns a parser of the schemas and tables of cfg.
+func preflightParser(db *sql.DB, cfg *config.Config) *introspect.SchemaParser {
+	return introspect.NewSchemaParser(db, introspect.Options{
+		Schemas: cfg.Schemas,
+		Include: cfg.Include,
+		Exclude: cfg.Exclude,
+		Stats:   exportStats,
+	})
+}
+
+// printProblems writes the problems to w, followed by the grants fixing
+// them.
+func printProblems(w *os.File, problems []introspect.Problem) {
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "The database role can read the schema.")
+		return
+	}
+
+	fmt.Fprintln(w, "The database role cannot read all of the schema:")
+	var grants []string
+	for _, p := range problems {
+		fmt.Fprintf(w, "  %s: %s\n", p.Object, p.Reason)
+		if p.Grant != "" {
+			grants = append(grants, p.Grant)
+		}
+	}
+
+	if len(grants) > 0 {
+		fmt.Fprintln(w, "\nGrant them as an owner or superuser with:")
+		for _, g := range grants {
+			fmt.Fprintf(w, "  %s\n", g)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressInterval is how often progress is reported while a phase runs.
+const progressInterval = 2 * time.Second
+
+// newProgress returns a progress callback for a long-running phase. On a
+// terminal with text logs it draws a bar on stderr; otherwise it logs a line
+// every progressInterval. Phases finishing within the interval stay silent.
+func newProgress(phase string) func(done, total int) {
+	if quiet {
+		return nil
+	}
+
+	bar := logFormat == "text" && isTerminal(os.Stderr)
+	start := time.Now()
+	var last time.Time
+
+	return func(done, total int) {
+		now := time.Now()
+		finished := done >= total
+
+		if now.Sub(start) < progressInterval && !(finished && !last.IsZero()) {
+			return
+		}
+
+		if bar {
+			if now.Sub(last) < 100*time.Millisecond && !finished {
+				return
+			}
+			last = now
+			drawBar(phase, done, total, finished)
+			return
+		}
+
+		if now.Sub(last) < progressInterval && !finished {
+			return
+		}
+		last = now
+		slog.Info("Progress", "phase", phase, "done", done, "total", total)
+	}
+}
+
+func drawBar(phase string, done, total int, finished bool) {
+	const width = 30
+
+	filled := width
+	if total > 0 {
+		filled = done * width / total
+	}
+
+	fmt.Fprintf(os.Stderr, "\r%-12s [%s%s] %d/%d", phase, strings.Repeat("=", filled), strings.Repeat(" ", width-filled), done, total)
+	if finished {
+		fmt.Fprintln(os.Stderr)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// roleConnector opens connections with a pq.Connector and switches each to
+// role with SET ROLE before it is used, so every connection of the pool
+// reads the schema with the privileges of role rather than of the login
+// user.
+type roleConnector struct {
+	*pq.Connector
+	role string
+}
+
+func (c roleConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("driver cannot run SET ROLE")
+	}
+	if _, err := execer.ExecContext(ctx, "SET ROLE "+pq.QuoteIdentifier(c.role), nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set role %s: %w", c.role, err)
+	}
+
+	return conn, nil
+}
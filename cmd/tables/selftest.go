@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/mymyka/tables/pkg/writer"
+	"github.com/spf13/cobra"
+)
+
+// selftestModule is the module the self-test generates into.
+const selftestModule = "tables.selftest/gen"
+
+var selftestOpts struct {
+	snapshot string
+	keep     string
+}
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Check that the code generated for every table compiles",
+	Long: `Run the whole pipeline against the database (or a snapshot): introspect the
+schema, generate code with the config into a module of its own in a temporary
+directory and compile it with the go command on the PATH, along with the
+profiles it converts from. Every table whose package fails to generate or to
+compile is reported with the errors, and the command exits with code 9, so a
+schema that trips the generator is caught before it breaks a build.
+
+The database is only read. The generated module needs the modules generated
+code imports, which the go command downloads unless they are in its cache.
+Keep the generated code for a closer look with --keep.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: runSelftest,
+}
+
+func init() {
+	selftestCmd.Flags().StringVar(&selftestOpts.snapshot, "snapshot", "", "Test a schema snapshot instead of the database")
+	selftestCmd.Flags().StringVar(&selftestOpts.keep, "keep", "", "Generate into this directory and keep it (default: a temporary directory, removed afterwards)")
+
+	rootCmd.AddCommand(selftestCmd)
+}
+
+// selftestFailure is a table, or an extra package, that fails the self-test.
+type selftestFailure struct {
+	Name   string
+	Stage  string
+	Errors []string
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var tables []schema.Table
+	if selftestOpts.snapshot != "" {
+		tables, err = snapshot.Load(selftestOpts.snapshot)
+		tables = introspect.Select(tables, cfg.Include, cfg.Exclude)
+	} else {
+		if cfg.Connection == "" {
+			return fmt.Errorf("%w, or test a --snapshot", errNoConnection)
+		}
+		tables, err = readSchema(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	dir := selftestOpts.keep
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "tables-selftest-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	failures, err := selftest(cfg, tables, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range failures {
+		fmt.Printf("FAIL %s (%s)\n", f.Name, f.Stage)
+		for _, e := range f.Errors {
+			fmt.Printf("    %s\n", e)
+		}
+	}
+	fmt.Printf("%d tables, %d failed\n", len(tables), len(failures))
+
+	if len(failures) > 0 {
+		return withCode(exitCompile, fmt.Errorf("%d tables or packages produce broken code", len(failures)))
+	}
+
+	return nil
+}
+
+// selftest generates the code of tables into a module in dir and compiles
+// it, returning the tables and packages that fail.
+func selftest(cfg *config.Config, tables []schema.Table, dir string) ([]selftestFailure, error) {
+	test := *cfg
+	test.Output.Dir = dir
+	test.Output.Module = selftestModule
+	test.Output.ModulePath = selftestModule
+
+	// The profiles converted from are generated next to the tables, so the
+	// converters have packages to import
+	test.Counterparts = nil
+	for _, other := range cfg.Counterparts {
+		counterpart := *other
+		counterpart.Output.Dir = filepath.Join(dir, "counterparts", other.Name)
+		counterpart.Output.ModulePath = selftestModule + "/counterparts/" + other.Name
+		test.Counterparts = append(test.Counterparts, &counterpart)
+	}
+	opts := buildOptions(&test)
+
+	slog.Info("Generating", "tables", len(tables), "dir", dir)
+
+	names := make(map[string]string)
+	c, failures, err := selftestBuild(tables, opts, "", "", names)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range test.Counterparts {
+		built, more, err := selftestBuild(tables, buildOptions(other), "counterparts/"+other.Name+"/", " in profile "+other.Name, names)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate profile %s: %w", other.Name, err)
+		}
+		maps.Copy(c, built)
+		failures = append(failures, more...)
+	}
+
+	if _, err := writer.Write(dir, c, writer.Options{Workers: workers, FileSuffix: test.Output.FileSuffix}); err != nil {
+		return nil, withCode(exitWrite, fmt.Errorf("failed to write files: %w", err))
+	}
+	if err := writer.WriteModuleGo(dir, selftestModule, test.Output.GoVersion, c); err != nil {
+		return nil, withCode(exitWrite, fmt.Errorf("failed to write module files: %w", err))
+	}
+
+	// Packages importing one that failed to generate cannot load
+	missing := make(map[string]string)
+	for _, f := range failures {
+		for pkg, name := range names {
+			if _, ok := c[pkg]; !ok && name == f.Name {
+				missing[pkg] = name
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for pkg, content := range c {
+			for other, name := range missing {
+				if _, ok := missing[pkg]; !ok && strings.Contains(content, `"`+selftestModule+"/"+other+`"`) {
+					slog.Warn("Not compiling a package importing one that failed", "package", pkg, "imports", name)
+					missing[pkg], changed = name, true
+				}
+			}
+		}
+	}
+	var patterns []string
+	for pkg := range c {
+		if _, ok := missing[pkg]; !ok {
+			patterns = append(patterns, "./"+pkg)
+		}
+	}
+	if len(patterns) == 0 {
+		return failures, nil
+	}
+	sort.Strings(patterns)
+
+	slog.Info("Compiling", "packages", len(patterns))
+
+	broken, err := compileModule(dir, patterns, selftestModule, nil)
+	if err != nil {
+		return nil, err
+	}
+	for pkg, errs := range broken {
+		name, ok := names[pkg]
+		if !ok {
+			name = pkg
+		}
+		failures = append(failures, selftestFailure{Name: name, Stage: "compile", Errors: errs})
+	}
+
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Name < failures[j].Name })
+
+	return failures, nil
+}
+
+// selftestBuild generates the packages of tables with opts, under prefix,
+// and lists what fails to generate, with suffix after the name. names gets
+// the table of every package. Build stops at the first error, so on one
+// every table and the extra packages are built on their own; an error
+// without any of them is the config's and returned.
+func selftestBuild(tables []schema.Table, opts gen.Options, prefix, suffix string, names map[string]string) (map[string]string, []selftestFailure, error) {
+	for _, t := range tables {
+		names[prefix+gen.PackagePath(t, opts)] = t.Schema + "." + t.Name + suffix
+	}
+
+	c, err := gen.Build(tables, opts)
+	if err == nil {
+		return prefixed(c, prefix), nil, nil
+	}
+
+	none := func(schema.Table) bool { return false }
+	check := opts
+	check.Only, check.NoExtraPackages = none, true
+	if _, err := gen.Build(tables, check); err != nil {
+		return nil, nil, err
+	}
+
+	// Extra packages, such as the where package, are built once and kept
+	// for the tables importing them
+	var failures []selftestFailure
+	extras := opts
+	extras.Only = none
+	c, err = gen.Build(tables, extras)
+	if err != nil {
+		c = make(map[string]string)
+		failures = append(failures, selftestFailure{Name: "extra packages" + suffix, Stage: "generate", Errors: []string{err.Error()}})
+	}
+
+	for _, t := range tables {
+		only := opts
+		only.Only = func(o schema.Table) bool { return o.Schema == t.Schema && o.Name == t.Name }
+		only.NoExtraPackages = true
+		built, err := gen.Build(tables, only)
+		if err != nil {
+			failures = append(failures, selftestFailure{Name: t.Schema + "." + t.Name + suffix, Stage: "generate", Errors: []string{err.Error()}})
+			continue
+		}
+		maps.Copy(c, built)
+	}
+
+	return prefixed(c, prefix), failures, nil
+}
+
+// prefixed returns the packages of c with prefix in front of their paths.
+func prefixed(c map[string]string, prefix string) map[string]string {
+	if prefix == "" {
+		return c
+	}
+
+	result := make(map[string]string, len(c))
+	for pkg, content := range c {
+		result[prefix+pkg] = content
+	}
+
+	return result
+}
+
+// compileModule runs go build on the packages matching patterns in dir, whose
+// import paths start with importPath, and returns the compile errors by
+// package path relative to importPath. overlay, when set, maps files to the
+// files the go command reads in their place, "" hiding a file; errors name
+// the replaced files. Failures of the go command itself, such as a module it
+// cannot download, are an error.
+func compileModule(dir string, patterns []string, importPath string, overlay map[string]string) (map[string][]string, error) {
+	args := []string{"build"}
+	if overlay != nil {
+		f, err := os.CreateTemp("", "tables-overlay-*.json")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+
+		err = json.NewEncoder(f).Encode(struct{ Replace map[string]string }{overlay})
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-overlay", f.Name())
+	}
+
+	cmd := exec.Command("go", append(args, patterns...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("failed to run go build: %w", err)
+	}
+
+	// Errors name the files read in place of the replaced ones
+	output := out.String()
+	for path, replacement := range overlay {
+		if replacement == "" {
+			continue
+		}
+		from, err1 := filepath.Rel(dir, replacement)
+		to, err2 := filepath.Rel(dir, path)
+		if err1 == nil && err2 == nil {
+			output = strings.ReplaceAll(output, from+":", to+":")
+		}
+	}
+
+	// Errors follow a "# import/path" line of their package
+	broken := make(map[string][]string)
+	var pkg string
+	var other []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# "):
+			pkg = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "# "), importPath), "/")
+		case pkg != "":
+			broken[pkg] = append(broken[pkg], strings.TrimSpace(line))
+		default:
+			other = append(other, line)
+		}
+	}
+
+	if exitErr != nil && len(broken) == 0 {
+		return nil, fmt.Errorf("go build failed: %s", strings.Join(other, "\n"))
+	}
+
+	return broken, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/pgtest"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// testConfig returns the api profile of testdata/selftest.yaml.
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg, err := config.Load("testdata/selftest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = cfg.Profile("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+// testSelftest generates and compiles tables with the config, failing t
+// with every table that breaks.
+func testSelftest(t *testing.T, cfg *config.Config, tables []schema.Table) {
+	t.Helper()
+
+	failures, err := selftest(cfg, tables, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range failures {
+		t.Errorf("%s fails to %s:", f.Name, f.Stage)
+		for _, e := range f.Errors {
+			t.Errorf("    %s", e)
+		}
+	}
+}
+
+// skipCompile skips t when the generated code cannot be compiled.
+func skipCompile(t *testing.T) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+}
+
+// TestSelftestSnapshot compiles the code generated for the snapshot of
+// testdata/selftest.sql.
+func TestSelftestSnapshot(t *testing.T) {
+	skipCompile(t)
+
+	tables, err := snapshot.Load("testdata/selftest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSelftest(t, testConfig(t), tables)
+}
+
+// TestSelftestFailures checks that a table failing to generate is reported
+// by name, in its profile and the one converted from, next to extra
+// packages the tables import, even when it is the only table.
+func TestSelftestFailures(t *testing.T) {
+	skipCompile(t)
+
+	cfg := testConfig(t)
+	cfg.Output.FixturesPackage = "fixtures"
+	cfg.Tables["broken"] = config.Table{Aggregates: []string{"count(*)"}}
+	broken := schema.Table{Schema: "public", Name: "broken", Columns: []schema.Column{
+		{Name: "id", Type: "integer", PrimaryKey: 1},
+		{Name: "count_rows", Type: "bigint"},
+	}}
+
+	for _, tables := range [][]schema.Table{{broken}, {broken, {Schema: "public", Name: "fine", Columns: broken.Columns[:1]}}} {
+		failures, err := selftest(cfg, tables, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range failures {
+			got = append(got, f.Name+" ("+f.Stage+")")
+		}
+		want := []string{"public.broken (generate)", "public.broken in profile models (generate)"}
+		if !slices.Equal(got, want) {
+			t.Errorf("%d tables: got failures %q, want %q", len(tables), got, want)
+		}
+	}
+}
+
+// TestSelftestIdentifiers compiles the code generated for tables and
+// columns whose names are no Go identifiers.
+func TestSelftestIdentifiers(t *testing.T) {
+	skipCompile(t)
+
+	id := schema.Column{Name: "id", Type: "integer", PrimaryKey: 1}
+	testSelftest(t, testConfig(t), []schema.Table{
+		{Schema: "public", Name: "2fa", Columns: []schema.Column{id, {Name: "my col", Type: "text"}, {Name: "1st", Type: "text"}, {Name: "_", Type: "text"}}},
+		{Schema: "public", Name: "my table", Columns: []schema.Column{id}},
+		{Schema: "public", Name: "type", Columns: []schema.Column{id, {Name: "kind", Type: "2fa_kind", Enum: []string{"sms", "totp"}}}},
+	})
+}
+
+// TestSelftestDatabase runs introspection, generation and compilation
+// against a server with the schema of testdata/selftest.sql, and checks
+// that testdata/selftest.json is its snapshot. It needs PostgreSQL, see
+// pgtest.Start, and is skipped without it, which is the default: install
+// PostgreSQL or set TABLES_TEST_DATABASE_URL to run it.
+func TestSelftestDatabase(t *testing.T) {
+	skipCompile(t)
+	dsn := pgtest.Start(t)
+
+	ddl, err := os.ReadFile("testdata/selftest.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(ddl)); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig(t)
+	cfg.Connection = dsn
+	tables, err := readSchema(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := snapshot.Load("testdata/selftest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := columnTypes(tables), columnTypes(want); !slices.Equal(got, want) {
+		t.Errorf("testdata/selftest.json is out of date:\ngot  %q\nwant %q", got, want)
+	}
+
+	testSelftest(t, cfg, tables)
+}
+
+// columnTypes lists the tables of a schema with their columns and types.
+func columnTypes(tables []schema.Table) []string {
+	var list []string
+	for _, t := range tables {
+		list = append(list, t.Schema+"."+t.Name)
+		for _, c := range t.Columns {
+			list = append(list, "  "+c.Name+" "+c.Type)
+		}
+	}
+
+	return list
+}
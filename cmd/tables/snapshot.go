@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/mymyka/tables/internal/history"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotOutput  string
+	snapshotHistory string
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the introspected schema to a JSON file",
+	Long: `Introspect the database and save the schema as JSON. Snapshots can be
+committed and used by check in place of a live database.
+
+With --history, the snapshot is added to a history directory instead, named
+after the current UTC time, unless the schema is the same as in the latest
+one; tables history reads it back.`,
+	RunE: runSnapshot,
+}
+
+func init() {
+	snapshotCmd.Flags().StringVar(&snapshotOutput, "file", "schema.json", "Snapshot file to write")
+	snapshotCmd.Flags().StringVar(&snapshotHistory, "history", "", "Add the snapshot to this history directory instead of writing --file")
+
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Connection == "" {
+		return errNoConnection
+	}
+
+	tables, err := readSchema(cfg)
+	if err != nil {
+		return err
+	}
+
+	if snapshotHistory != "" {
+		v, added, err := history.Append(snapshotHistory, tables, time.Now())
+		if err != nil {
+			return withCode(exitWrite, err)
+		}
+		if !added {
+			slog.Info("Schema unchanged since the latest snapshot", "file", v.Path)
+			return nil
+		}
+
+		slog.Info("Saved snapshot", "tables", len(tables), "file", v.Path)
+		return nil
+	}
+
+	if err := snapshot.Save(snapshotOutput, tables); err != nil {
+		return withCode(exitWrite, err)
+	}
+
+	slog.Info("Saved snapshot", "tables", len(tables), "file", snapshotOutput)
+
+	return nil
+}
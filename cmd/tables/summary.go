@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/writer"
+)
+
+// runSummary is the machine-readable report of a generate run.
+type runSummary struct {
+	Tables     int            `json:"tables"`
+	Written    []string       `json:"written"`
+	Skipped    []string       `json:"skipped"`
+	Pruned     []string       `json:"pruned"`
+	Unmapped   []gen.Unmapped `json:"unmapped_types"`
+	Warnings   []gen.Warning  `json:"warnings"`
+	Failures   []runFailure   `json:"failures"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// runFailure is a table or file skipped by --keep-going.
+type runFailure struct {
+	Stage string `json:"stage"`
+	Table string `json:"table,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error"`
+}
+
+// warnSkipped is the kind of the warning for a table or file --keep-going
+// skipped.
+const warnSkipped = "skipped"
+
+// failures collects what --keep-going skipped during the run, or during
+// the current cycle of --watch.
+var failures []runFailure
+
+// runWarnings lists warnings and every failure skipped so far, as warnings
+// of kind warnSkipped.
+func runWarnings(warnings []gen.Warning) []gen.Warning {
+	all := slices.Clone(warnings)
+	for _, f := range failures {
+		msg := f.Error
+		if f.Path != "" {
+			msg = f.Path + ": " + msg
+		}
+		all = append(all, gen.Warning{Kind: warnSkipped, Table: f.Table, Message: f.Stage + " failed, " + msg})
+	}
+
+	return all
+}
+
+func newRunSummary(tables int, unmapped []gen.Unmapped, warnings []gen.Warning, result writer.Result, duration time.Duration) runSummary {
+	summary := runSummary{
+		Tables:     tables,
+		Written:    nonNil(result.Written),
+		Skipped:    nonNil(result.Skipped),
+		Pruned:     nonNil(result.Pruned),
+		Unmapped:   unmapped,
+		Warnings:   runWarnings(warnings),
+		Failures:   failures,
+		DurationMS: duration.Milliseconds(),
+	}
+
+	if summary.Unmapped == nil {
+		summary.Unmapped = []gen.Unmapped{}
+	}
+	if summary.Warnings == nil {
+		summary.Warnings = []gen.Warning{}
+	}
+	if summary.Failures == nil {
+		summary.Failures = []runFailure{}
+	}
+
+	return summary
+}
+
+// writeSummary writes the summary as JSON to path, or stdout for "-".
+func writeSummary(path string, summary runSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// nonNil keeps empty lists as [] rather than null in the JSON output.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mymyka/tables/internal/telemetry"
+	"github.com/mymyka/tables/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// flushTimeout bounds the export of spans when the command ends.
+const flushTimeout = 5 * time.Second
+
+// otelEndpoint is the OTLP collector spans are exported to.
+var otelEndpoint string
+
+// commandSpan spans the whole command while tracing.
+var commandSpan *telemetry.Span
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&otelEndpoint, "otel-endpoint", "", "Export traces of the run to this OTLP/HTTP collector, e.g. http://localhost:4318 (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+}
+
+// startTelemetry starts tracing cmd when an OTLP endpoint is configured.
+func startTelemetry(cmd *cobra.Command) error {
+	ok, err := telemetry.Enable(telemetry.Config{Endpoint: otelEndpoint, Service: "tables", Version: version.String()})
+	if err != nil || !ok {
+		return err
+	}
+
+	commandSpan = telemetry.Start(cmd.CommandPath(), "tables.command", cmd.Name())
+
+	return nil
+}
+
+// stopTelemetry ends the command span with the outcome of the command and
+// exports the spans. It runs however the command ended, so failures are
+// only logged.
+func stopTelemetry(err error) {
+	if commandSpan == nil {
+		return
+	}
+
+	commandSpan.Set("tables.exit_code", exitCode(err))
+	commandSpan.End(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := telemetry.Flush(ctx); err != nil {
+		slog.Warn("Failed to export traces", "error", err)
+	}
+}
@@ -0,0 +1,30 @@
+-- Tables that have broken generated code: columns named after identifiers
+-- the packages declare, a table without columns and geometry columns
+-- converted between profiles. selftest.json is their snapshot.
+
+CREATE TABLE things (
+    id integer PRIMARY KEY,
+    meta text,
+    "column" text,
+    schema text,
+    channel text,
+    "where" text,
+    filter text,
+    "row" text,
+    c text,
+    col_id text,
+    querier text,
+    "table" text,
+    merge text
+);
+
+CREATE TABLE empty ();
+
+CREATE TABLE shapes (
+    id integer PRIMARY KEY,
+    pt point NOT NULL,
+    b box,
+    c circle NOT NULL,
+    cn circle,
+    path path
+);
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/pkg/writer"
+)
+
+// verifyModule names the scratch module --verify-build compiles output
+// without a module path in.
+const verifyModule = "tables.verify/gen"
+
+// verifyBuild compiles the packages of c, keyed by package path, as they
+// would be once written to the output directory of cfg, and fails when any
+// does not compile. Output in a module of the user is compiled in place
+// through a go build overlay, together with the hand-written files of that
+// module and with the files generate would prune hidden, so nothing is
+// written there. A standalone output module, or output outside any module, is
+// written to a scratch module instead.
+func verifyBuild(cfg *config.Config, c map[string]string, moves map[string]string) error {
+	slog.Info("Verifying that the generated code compiles", "packages", len(c))
+
+	dir, err := filepath.Abs(cfg.Output.Dir)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "tables-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	var broken map[string][]string
+	root, module := enclosingModule(dir)
+	if cfg.Output.Module != "" || root == "" {
+		module := outputModulePath(cfg)
+		if module == "" {
+			module = verifyModule
+		}
+		if _, err := writer.Write(tmp, c, writer.Options{FileSuffix: cfg.Output.FileSuffix}); err != nil {
+			return err
+		}
+		if err := writer.WriteModuleGo(tmp, module, cfg.Output.GoVersion, c); err != nil {
+			return err
+		}
+		if broken, err = compileModule(tmp, []string{"./..."}, module, nil); err != nil {
+			return err
+		}
+	} else {
+		overlay, err := buildOverlay(cfg, dir, tmp, c, moves)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+		pattern := "./" + filepath.ToSlash(rel) + "/..."
+		if rel == "." {
+			pattern = "./..."
+		}
+		if broken, err = compileModule(root, []string{pattern}, path.Join(module, filepath.ToSlash(rel)), overlay); err != nil {
+			return err
+		}
+	}
+
+	if len(broken) == 0 {
+		return nil
+	}
+
+	pkgs := make([]string, 0, len(broken))
+	for pkg := range broken {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		slog.Error("Generated package does not compile", "package", pkg, "errors", strings.Join(broken[pkg], "\n"))
+	}
+
+	return withCode(exitCompile, fmt.Errorf("%d generated packages do not compile, nothing was written", len(broken)))
+}
+
+// buildOverlay writes the packages of c to files in tmp and returns the go
+// build overlay reading them in place of their files under dir. Generated
+// files a write would prune or move away are hidden.
+func buildOverlay(cfg *config.Config, dir, tmp string, c map[string]string, moves map[string]string) (map[string]string, error) {
+	overlay := make(map[string]string)
+	for i, pkg := range writer.Packages(c) {
+		file := filepath.Join(tmp, fmt.Sprintf("%d.go", i))
+		if err := os.WriteFile(file, []byte(c[pkg]), 0644); err != nil {
+			return nil, err
+		}
+		overlay[writer.FilePath(dir, pkg, cfg.Output.FileSuffix)] = file
+	}
+
+	changes, err := writer.DiffMoves(dir, c, moves, cfg.Output.FileSuffix)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range changes {
+		switch ch.Kind {
+		case "removed":
+			overlay[ch.Path] = ""
+		case "renamed":
+			overlay[ch.From] = ""
+		}
+	}
+
+	return overlay, nil
+}
+
+// enclosingModule returns the directory and path of the module dir is in,
+// or empty strings when it is in none.
+func enclosingModule(dir string) (string, string) {
+	for root := dir; ; root = filepath.Dir(root) {
+		if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			return root, goModulePath(data)
+		}
+		if filepath.Dir(root) == root {
+			return "", ""
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/mymyka/tables/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version and build information",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("tables %s\n", version.String())
+		fmt.Printf("commit:   %s\n", valueOr(version.Commit, "unknown"))
+		fmt.Printf("built:    %s\n", valueOr(version.Date, "unknown"))
+		fmt.Printf("go:       %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		fmt.Printf("dialects: %s\n", strings.Join(version.Dialects, ", "))
+	},
+}
+
+func init() {
+	rootCmd.Version = version.String()
+	rootCmd.AddCommand(versionCmd)
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mymyka/tables/internal/config"
+	"github.com/mymyka/tables/internal/diff"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// watchTypes regenerates whenever the introspected schema changes, until
+// interrupted. The schema is polled every watchInterval; with watchChannel
+// set, a NOTIFY on that channel triggers an immediate check as well. Each
+// regeneration is a generate run of its own, checks included.
+func watchTypes(cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if watchChannel != "" && (cfg.Pool.Single || cfg.Pool.MaxOpen == 1) {
+		return withCode(exitUsage, fmt.Errorf("--watch-channel listens on a session of its own, which a single connection does not allow"))
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := checkPrivileges(db, cfg); err != nil {
+		return err
+	}
+
+	var notify <-chan struct{}
+	if watchChannel != "" {
+		if notify, err = listen(ctx, db, watchChannel); err != nil {
+			return withCode(exitConnection, fmt.Errorf("failed to listen on channel %s: %w", watchChannel, err))
+		}
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	var current []schema.Table
+	checked := false
+
+	for {
+		// Each cycle is a run of its own, reporting only what it skipped
+		start := time.Now()
+		failures = nil
+		tables, err := readTables(db, cfg)
+
+		switch {
+		case err != nil:
+			slog.Warn("Failed to read schema, retrying", "error", err)
+		case !checked || !diff.Compare(current, tables).Empty():
+			if checked {
+				slog.Info("Schema changed, regenerating")
+			}
+
+			// A run failing its checks fails the same way until the
+			// schema changes, so it is not retried before
+			current = tables
+			checked = true
+
+			err := generateTargets([]target{{cfg: cfg, tables: tables}}, start)
+			switch {
+			case exitCode(err) == exitPartialFailure:
+				slog.Warn("Regenerated with tables or files skipped, watching for changes", "error", err)
+			case err != nil:
+				slog.Error("Failed to regenerate, watching for changes", "error", err)
+			default:
+				slog.Info("Watching for changes", "tables", len(tables))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopped watching")
+			return nil
+		case <-ticker.C:
+		case <-notify:
+		}
+	}
+}
+
+// listenInterval is how often the listening session is pinged: the driver
+// only reads the notifications the server sent while it runs a statement.
+const listenInterval = 250 * time.Millisecond
+
+// listen runs LISTEN channel on a connection of its own from db, which has
+// the role and pool limits of cfg like every other, and returns a channel
+// receiving a value when a notification arrives. A lost connection is
+// replaced, until ctx ends.
+func listen(ctx context.Context, db *sql.DB, channel string) (<-chan struct{}, error) {
+	notify := make(chan struct{}, 1)
+	send := func() {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+
+	conn, err := listenConn(ctx, db, channel, send)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(listenInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				if conn != nil {
+					conn.Close()
+				}
+				return
+			case <-ticker.C:
+			}
+
+			if conn != nil {
+				err := conn.PingContext(ctx)
+				if err == nil || ctx.Err() != nil {
+					continue
+				}
+				slog.Warn("Lost the session listening on channel, reconnecting", "channel", channel, "error", err)
+				conn.Close()
+				conn = nil
+			}
+
+			// Notifications sent while reconnecting are lost, so the
+			// schema is checked once listening again
+			if c, err := listenConn(ctx, db, channel, send); err == nil {
+				conn = c
+				send()
+			}
+		}
+	}()
+
+	return notify, nil
+}
+
+// listenConn takes a connection from db that calls notify for every
+// notification on channel.
+func listenConn(ctx context.Context, db *sql.DB, channel string, notify func()) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Raw(func(c any) error {
+		pq.SetNotificationHandler(c.(driver.Conn), func(*pq.Notification) { notify() })
+		return nil
+	})
+	if err == nil {
+		_, err = conn.ExecContext(ctx, "LISTEN "+pq.QuoteIdentifier(channel))
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,241 @@
+// Package analyze reports columns that are candidates for schema cleanup.
+package analyze
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Checks a report can run.
+const (
+	CheckIndex      = "index"      // the column is in no index
+	CheckForeignKey = "fk"         // the column is on neither side of a foreign key
+	CheckNull       = "null"       // every sampled row is NULL
+	CheckDeprecated = "deprecated" // the name matches a deprecated pattern
+)
+
+// DefaultDeprecated are the deprecated name patterns used when
+// Options.Deprecated is empty.
+var DefaultDeprecated = []string{"*_old", "old_*", "*_bak", "*_backup", "*_deprecated", "deprecated_*", "legacy_*", "*_legacy", "tmp_*", "*_tmp", "unused_*"}
+
+// Options configures a report.
+type Options struct {
+	// Checks to run. Defaults to index, fk and deprecated, plus null when
+	// Sample is set.
+	Checks []string
+
+	// Sample is the number of rows per table the null check reads.
+	Sample int
+
+	// Deprecated are path.Match patterns against column names. Defaults to
+	// DefaultDeprecated.
+	Deprecated []string
+}
+
+// Finding lists what a report found about a column.
+type Finding struct {
+	Schema string   `json:"schema"`
+	Table  string   `json:"table"`
+	Column string   `json:"column"`
+	Checks []string `json:"checks"`
+}
+
+// Columns runs the checks on every column of tables and returns the columns
+// failing at least one, in table and column order.
+func Columns(db *sql.DB, tables []schema.Table, opts Options) ([]Finding, error) {
+	checks := opts.Checks
+	if len(checks) == 0 {
+		checks = []string{CheckIndex, CheckForeignKey, CheckDeprecated}
+		if opts.Sample > 0 {
+			checks = append(checks, CheckNull)
+		}
+	}
+	enabled := make(map[string]bool)
+	for _, c := range checks {
+		switch c {
+		case CheckIndex, CheckForeignKey, CheckDeprecated:
+		case CheckNull:
+			if opts.Sample <= 0 {
+				return nil, fmt.Errorf("check %s needs a sample size", c)
+			}
+		default:
+			return nil, fmt.Errorf("unknown check %q, expected one of %s", c, strings.Join([]string{CheckIndex, CheckForeignKey, CheckNull, CheckDeprecated}, ", "))
+		}
+		enabled[c] = true
+	}
+
+	deprecated := opts.Deprecated
+	if len(deprecated) == 0 {
+		deprecated = DefaultDeprecated
+	}
+
+	var indexed map[string]bool
+	if enabled[CheckIndex] {
+		var err error
+		if indexed, err = indexedColumns(db, tables); err != nil {
+			return nil, err
+		}
+	}
+	referenced := foreignKeyColumns(tables)
+
+	var findings []Finding
+	for _, t := range tables {
+		var null map[string]bool
+		if enabled[CheckNull] {
+			var err error
+			if null, err = nullColumns(db, t, opts.Sample); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, c := range t.Columns {
+			key := t.Schema + "." + t.Name + "." + c.Name
+			f := Finding{Schema: t.Schema, Table: t.Name, Column: c.Name}
+
+			if enabled[CheckIndex] && !indexed[key] {
+				f.Checks = append(f.Checks, CheckIndex)
+			}
+			if enabled[CheckForeignKey] && !referenced[key] {
+				f.Checks = append(f.Checks, CheckForeignKey)
+			}
+			if null[c.Name] {
+				f.Checks = append(f.Checks, CheckNull)
+			}
+			if enabled[CheckDeprecated] && matchAny(deprecated, c.Name) {
+				f.Checks = append(f.Checks, CheckDeprecated)
+			}
+
+			if len(f.Checks) > 0 {
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// foreignKeyColumns returns the schema.table.column of every column on
+// either side of a foreign key.
+func foreignKeyColumns(tables []schema.Table) map[string]bool {
+	columns := make(map[string]bool)
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			for _, c := range fk.Columns {
+				columns[t.Schema+"."+t.Name+"."+c] = true
+			}
+			for _, c := range fk.RefColumns {
+				columns[fk.RefSchema+"."+fk.RefTable+"."+c] = true
+			}
+		}
+	}
+
+	return columns
+}
+
+// indexedColumns returns the schema.table.column of every column of an
+// index of tables. Key columns are listed in pg_index; the columns used by
+// index expressions and predicates only show up as dependencies.
+func indexedColumns(db *sql.DB, tables []schema.Table) (map[string]bool, error) {
+	query := `
+		SELECT n.nspname, c.relname, a.attname
+		FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_class c ON c.oid = i.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0
+		WHERE n.nspname = ANY($1) AND (
+			a.attnum = ANY(i.indkey)
+			OR EXISTS (
+				SELECT 1
+				FROM pg_catalog.pg_depend d
+				WHERE d.classid = 'pg_catalog.pg_class'::regclass AND d.objid = i.indexrelid
+					AND d.refclassid = 'pg_catalog.pg_class'::regclass AND d.refobjid = i.indrelid AND d.refobjsubid = a.attnum
+			)
+		)
+	`
+
+	seen := make(map[string]bool)
+	var schemas []string
+	for _, t := range tables {
+		if !seen[t.Schema] {
+			seen[t.Schema] = true
+			schemas = append(schemas, t.Schema)
+		}
+	}
+
+	slog.Debug("Querying indexed columns", "sql", query, "schemas", schemas)
+
+	rows, err := db.Query(query, pq.Array(schemas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var schemaName, tableName, column string
+		if err := rows.Scan(&schemaName, &tableName, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		columns[schemaName+"."+tableName+"."+column] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// nullColumns returns the nullable columns of t that are NULL in every one
+// of the first sample rows, or none when the table is empty.
+func nullColumns(db *sql.DB, t schema.Table, sample int) (map[string]bool, error) {
+	var nullable []string
+	counts := []string{"count(*)"}
+	for _, c := range t.Columns {
+		if c.Nullable {
+			nullable = append(nullable, c.Name)
+			counts = append(counts, "count("+pq.QuoteIdentifier(c.Name)+")")
+		}
+	}
+	if len(nullable) == 0 {
+		return nil, nil
+	}
+
+	query := "SELECT " + strings.Join(counts, ", ") + " FROM (SELECT * FROM " +
+		pq.QuoteIdentifier(t.Schema) + "." + pq.QuoteIdentifier(t.Name) + " LIMIT $1) s"
+
+	slog.Debug("Sampling table", "sql", query, "rows", sample)
+
+	values := make([]int64, len(counts))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := db.QueryRow(query, sample).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to sample table %s.%s: %w", t.Schema, t.Name, err)
+	}
+
+	null := make(map[string]bool)
+	if values[0] == 0 {
+		return null, nil
+	}
+	for i, name := range nullable {
+		if values[i+1] == 0 {
+			null[name] = true
+		}
+	}
+
+	return null, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
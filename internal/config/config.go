@@ -0,0 +1,760 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames lists the config file names looked up when --config is not given,
+// in order of preference.
+var FileNames = []string{"tables.yaml", "tables.yml", "tables.toml"}
+
+type Config struct {
+	// Name is the profile this config was resolved from, empty for the base config.
+	Name string `yaml:"-" toml:"-"`
+
+	// Connection is the PostgreSQL connection string. ${VAR} references are
+	// expanded from the environment.
+	Connection string `yaml:"connection" toml:"connection"`
+
+	// PasswordFile names a file holding the database password, set into the
+	// connection string in place of any password it has. Trailing newlines
+	// are ignored.
+	PasswordFile string `yaml:"password_file" toml:"password_file"`
+
+	// PasswordCommand is a shell command printing the database password,
+	// such as a password manager CLI.
+	PasswordCommand string `yaml:"password_command" toml:"password_command"`
+
+	// Vault reads the database password from a HashiCorp Vault secret.
+	Vault *Vault `yaml:"vault" toml:"vault"`
+
+	// AWSSecret reads the database password from AWS Secrets Manager.
+	AWSSecret *AWSSecret `yaml:"aws_secret" toml:"aws_secret"`
+
+	// Role is switched to with SET ROLE on every connection, so a personal
+	// login reads the schema with the privileges of a limited role.
+	Role string `yaml:"role" toml:"role"`
+
+	// Pool limits the connections opened to the database.
+	Pool Pool `yaml:"pool" toml:"pool"`
+
+	// Connections are named connection strings (dev, staging, prod) selected
+	// with --env instead of Connection.
+	Connections map[string]string `yaml:"connections" toml:"connections"`
+
+	// Schemas to introspect. Defaults to public.
+	Schemas []string `yaml:"schemas" toml:"schemas"`
+
+	// Include and Exclude filter tables by name or schema.name; both accept
+	// path.Match patterns such as audit_*.
+	Include []string `yaml:"include" toml:"include"`
+	Exclude []string `yaml:"exclude" toml:"exclude"`
+
+	// Types overrides the Go type of a PostgreSQL type ("citext") or of a
+	// single column ("users.metadata"). Values name the Go type with its
+	// import path, e.g. "github.com/google/uuid.UUID" or "string".
+	Types map[string]string `yaml:"types" toml:"types"`
+
+	// JSONMaps lists the json and jsonb columns holding objects
+	// ("users.metadata") or a type ("jsonb") to generate with map accessors.
+	JSONMaps []string `yaml:"json_maps" toml:"json_maps"`
+
+	// ColumnTags groups columns under semantic tags such as pii or audit,
+	// each listing table.column patterns ("users.email", "*.created_at").
+	ColumnTags map[string][]string `yaml:"column_tags" toml:"column_tags"`
+
+	// Masking, when set, generates a Mask method on every Row anonymizing
+	// the selected columns.
+	Masking *Masking `yaml:"masking" toml:"masking"`
+
+	// Renames maps the old name of a renamed table to its new one, both as
+	// name or schema.name. diff and migrate plan report the pair as a
+	// rename, and generate moves the old package to the new one.
+	Renames map[string]string `yaml:"renames" toml:"renames"`
+
+	// Lint configures lint-schema.
+	Lint Lint `yaml:"lint" toml:"lint"`
+
+	// Money maps money columns to "decimal" (default), a type embedding
+	// decimal.Decimal, to "cents", an int64 of hundredths, or to "string".
+	Money string `yaml:"money" toml:"money"`
+
+	Naming Naming `yaml:"naming" toml:"naming"`
+	Output Output `yaml:"output" toml:"output"`
+
+	// Tables holds per-table settings keyed by table name or schema.name.
+	Tables map[string]Table `yaml:"tables" toml:"tables"`
+
+	// Enums documents the labels of enum types keyed by type name.
+	Enums map[string]Enum `yaml:"enums" toml:"enums"`
+
+	// Plugins are external generators run after the Go types are written.
+	Plugins []Plugin `yaml:"plugins" toml:"plugins"`
+
+	// Profiles are named overlays applied on top of the base config with
+	// --profile. Several profiles can be generated from a single run.
+	Profiles map[string]Config `yaml:"profiles" toml:"profiles"`
+
+	// DefaultProfiles are generated when no --profile is given.
+	DefaultProfiles []string `yaml:"default_profiles" toml:"default_profiles"`
+
+	// Convert names the profiles whose table packages a profile converts
+	// from and to, generating From<Profile> and To<Profile> per table.
+	Convert []string `yaml:"convert" toml:"convert"`
+
+	// Counterparts are the configs of the Convert profiles, resolved by
+	// Profile.
+	Counterparts []*Config `yaml:"-" toml:"-"`
+
+	// Root stops Discover from looking for config files in parent
+	// directories.
+	Root bool `yaml:"root" toml:"root"`
+}
+
+type Naming struct {
+	// Initialisms are upper-cased as a whole in Go names, e.g. ID turns
+	// user_id into UserID.
+	Initialisms []string `yaml:"initialisms" toml:"initialisms"`
+
+	// Rename maps a column ("email") or table column ("users.email") to the
+	// Go name to use for it.
+	Rename map[string]string `yaml:"rename" toml:"rename"`
+}
+
+type Output struct {
+	// Dir is the output directory path.
+	Dir string `yaml:"dir" toml:"dir"`
+
+	// Layout is "flat" (<dir>/<table>) or "schema" (<dir>/<schema>/<table>).
+	Layout string `yaml:"layout" toml:"layout"`
+
+	// GoVersion is the oldest Go version the generated code must build
+	// with, e.g. 1.18, and the go directive of a bootstrapped module.
+	GoVersion string `yaml:"go_version" toml:"go_version"`
+
+	// FieldOrder orders the fields of generated structs: "ordinal"
+	// (default), "alphabetical" or "primary_key".
+	FieldOrder string `yaml:"field_order" toml:"field_order"`
+
+	// SearchPath leaves table names in generated SQL and name constants
+	// unqualified, resolving them through the search_path.
+	SearchPath bool `yaml:"search_path" toml:"search_path"`
+
+	// Nolint lists the linters, or "all", turned off in generated files by
+	// a //nolint directive.
+	Nolint []string `yaml:"nolint" toml:"nolint"`
+
+	// NoStutter keeps generated type names from repeating their package
+	// name, e.g. users.Role instead of users.UsersRole.
+	NoStutter bool `yaml:"no_stutter" toml:"no_stutter"`
+
+	// MaxLineLength, when set, breaks generated lines longer than this.
+	MaxLineLength int `yaml:"max_line_length" toml:"max_line_length"`
+
+	// BuildTags is a build constraint, e.g. "!codeanalysis", put on every
+	// generated Go file.
+	BuildTags string `yaml:"build_tags" toml:"build_tags"`
+
+	// FileSuffix is appended to the name of the generated file of every
+	// package, e.g. "_gen" for <dir>/users/users_gen.go.
+	FileSuffix string `yaml:"file_suffix" toml:"file_suffix"`
+
+	// PackagePrefix is prepended to every generated package name.
+	PackagePrefix string `yaml:"package_prefix" toml:"package_prefix"`
+
+	// Module, when set, writes go.mod/go.sum declaring it into Dir.
+	Module string `yaml:"module" toml:"module"`
+
+	// ModulePath is the import path of Dir, used to import one table's
+	// package from another. Defaults to Module, or to the path of Dir in
+	// the module of the nearest go.mod above it.
+	ModulePath string `yaml:"module_path" toml:"module_path"`
+
+	// OrderPackage, when set, generates a package at this path under Dir
+	// listing the tables in foreign key order.
+	OrderPackage string `yaml:"order_package" toml:"order_package"`
+
+	// ResetPackage, when set, generates a package at this path under Dir
+	// with ResetAll for integration tests. ResetCascade truncates with
+	// CASCADE.
+	ResetPackage string `yaml:"reset_package" toml:"reset_package"`
+	ResetCascade bool   `yaml:"reset_cascade" toml:"reset_cascade"`
+
+	// FixturesPackage, when set, generates a package at this path under Dir
+	// with Load, which inserts YAML or JSON fixture files in a transaction.
+	FixturesPackage string `yaml:"fixtures_package" toml:"fixtures_package"`
+
+	// StmtCachePackage, when set, generates a package at this path under
+	// Dir with a prepared statement cache for hand-written queries.
+	StmtCachePackage string `yaml:"stmt_cache_package" toml:"stmt_cache_package"`
+
+	// ReplicaPackage, when set, generates a package at this path under Dir
+	// routing reads to replicas and writes to the primary.
+	ReplicaPackage string `yaml:"replica_package" toml:"replica_package"`
+
+	// RetryPackage, when set, generates a package at this path under Dir
+	// with timeout and retry policies for queries and transactions.
+	RetryPackage string `yaml:"retry_package" toml:"retry_package"`
+
+	// Placeholder is the placeholder style of the generated SQL: dollar
+	// ($1, default), question (?) or named (:p1), or a database or driver
+	// such as mysql or sqlite selecting its style.
+	Placeholder string `yaml:"placeholder" toml:"placeholder"`
+
+	// WherePackage, when set, generates a package at this path under Dir
+	// building WHERE clauses from the typed columns of the table packages.
+	WherePackage string `yaml:"where_package" toml:"where_package"`
+
+	// TenantPackage, when set, generates the packages of structurally
+	// identical schemas once and a package at this path under Dir mapping
+	// tenants to their schema.
+	TenantPackage string `yaml:"tenant_package" toml:"tenant_package"`
+
+	// Tags adds struct tags named after the columns to Row fields, e.g.
+	// [json, db].
+	Tags []string `yaml:"tags" toml:"tags"`
+
+	// Templates is a directory of templates overriding the built-in ones.
+	Templates string `yaml:"templates" toml:"templates"`
+
+	// Features lists the artifacts generated for every table: types,
+	// columns, meta, row, pgx, dto, sort, filter and keys (see
+	// gen.FeatureTypes and the other constants). Defaults to
+	// gen.DefaultFeatures: types, columns, meta and row.
+	Features []string `yaml:"features" toml:"features"`
+}
+
+type Masking struct {
+	// Tags selects the columns of these column tags. Defaults to [pii].
+	Tags []string `yaml:"tags" toml:"tags"`
+
+	// Strategy masks the tagged columns: null, redact (default), hash or
+	// fake.
+	Strategy string `yaml:"strategy" toml:"strategy"`
+
+	// Columns sets the strategy of the columns matching table.column
+	// patterns, tagged or not.
+	Columns map[string]string `yaml:"columns" toml:"columns"`
+}
+
+type Pool struct {
+	// MaxOpen caps the open connections, unlimited when zero.
+	MaxOpen int `yaml:"max_open" toml:"max_open"`
+
+	// MaxIdle caps the idle connections kept open, two when zero.
+	MaxIdle int `yaml:"max_idle" toml:"max_idle"`
+
+	// Single runs everything on one session, for poolers such as pgbouncer
+	// in transaction mode.
+	Single bool `yaml:"single" toml:"single"`
+}
+
+type Lint struct {
+	// Rules lists the rules checked: snake-case, pk-id, fk-name and
+	// reserved. Defaults to all of them.
+	Rules []string `yaml:"rules" toml:"rules"`
+
+	// Ignore skips the tables and columns matching table, schema.table,
+	// table.column or schema.table.column patterns.
+	Ignore []string `yaml:"ignore" toml:"ignore"`
+}
+
+type Table struct {
+	// Features replaces Output.Features for the table, or adjusts them
+	// when every item is prefixed with + or -, e.g. [-row].
+	Features []string `yaml:"features" toml:"features"`
+
+	// Notify is the channel a trigger on the table sends its rows on as
+	// JSON, generating typed subscription helpers.
+	Notify string `yaml:"notify" toml:"notify"`
+
+	// History is the table keeping the past versions of the table's rows,
+	// generating AsOf, or "-" to not pair it with <table>_history.
+	History string `yaml:"history" toml:"history"`
+
+	// DTO lists the columns of the DTO of the dto feature, or the columns
+	// left out of it when every item is prefixed with -, e.g.
+	// [-password_hash]. Defaults to every column.
+	DTO []string `yaml:"dto" toml:"dto"`
+
+	// Sort lists the columns OrderBy of the sort feature accepts, each
+	// optionally followed by asc or desc to allow only that direction, e.g.
+	// [created_at, id asc]. Defaults to every column with an ordering.
+	Sort []string `yaml:"sort" toml:"sort"`
+
+	// Filter lists the columns Filter of the filter feature filters by.
+	// Defaults to the key columns of the table's indexes.
+	Filter []string `yaml:"filter" toml:"filter"`
+
+	// Aggregates lists the aggregates the table's package gets a function
+	// for, e.g. ["sum(amount) by status", "max(created_at)"].
+	Aggregates []string `yaml:"aggregates" toml:"aggregates"`
+}
+
+type Enum struct {
+	// Labels maps labels to the doc comments of their constants, replacing
+	// the comments of the labels in the database.
+	Labels map[string]string `yaml:"labels" toml:"labels"`
+
+	// Deprecated lists the labels whose constants are marked deprecated.
+	Deprecated []string `yaml:"deprecated" toml:"deprecated"`
+}
+
+// Vault is a HashiCorp Vault KV secret holding the database password.
+type Vault struct {
+	// Address is the Vault server, VAULT_ADDR when empty.
+	Address string `yaml:"address" toml:"address"`
+
+	// Path is the API path of the secret, e.g. secret/data/db.
+	Path string `yaml:"path" toml:"path"`
+
+	// Field is the key of the password in the secret, "password" when
+	// empty.
+	Field string `yaml:"field" toml:"field"`
+}
+
+// AWSSecret is an AWS Secrets Manager secret holding the database password.
+type AWSSecret struct {
+	// SecretID is the name or ARN of the secret.
+	SecretID string `yaml:"secret_id" toml:"secret_id"`
+
+	// Region defaults to the one of the AWS CLI.
+	Region string `yaml:"region" toml:"region"`
+
+	// Field is the key of the password in a JSON secret, "password" when
+	// empty.
+	Field string `yaml:"field" toml:"field"`
+}
+
+type Plugin struct {
+	// Name selects the tables-gen-<name> executable on PATH.
+	Name string `yaml:"name" toml:"name"`
+
+	// Out is the directory the plugin's files are written to.
+	Out string `yaml:"out" toml:"out"`
+
+	// Options are passed to the plugin as is.
+	Options map[string]string `yaml:"options" toml:"options"`
+}
+
+// Load reads the config file at path. The format is picked by extension.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var c Config
+	switch filepath.Ext(path) {
+	case ".toml":
+		err = toml.Unmarshal(data, &c)
+	default:
+		err = yaml.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	c.rebase(filepath.Dir(path))
+
+	return &c, nil
+}
+
+// Discover finds the config files that apply in dir and returns them merged,
+// along with the path of the nearest one. It walks up from dir and overlays
+// every config file found on the ones further up, stopping at a config with
+// root: true, at the repository root (a directory containing .git) or at the
+// filesystem root. Without any config file it returns nil and an empty path.
+func Discover(dir string) (*Config, string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var chain []*Config
+	var nearest string
+	for {
+		if path := Find(dir); path != "" {
+			c, err := Load(path)
+			if err != nil {
+				return nil, "", err
+			}
+
+			chain = append(chain, c)
+			if nearest == "" {
+				nearest = path
+			}
+			if c.Root {
+				break
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(abs, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			break
+		}
+		abs, dir = parent, filepath.Join(dir, "..")
+	}
+
+	if len(chain) == 0 {
+		return nil, "", nil
+	}
+
+	// Apply from the outermost file inwards
+	merged := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		merged.overlay(*chain[i])
+	}
+
+	return merged, nearest, nil
+}
+
+// overlay applies the config file o on top of c, as Merge does for a
+// profile, and also merges the profiles of both.
+func (c *Config) overlay(o Config) {
+	c.Merge(o)
+
+	if len(o.Profiles) > 0 {
+		profiles := make(map[string]Config, len(c.Profiles)+len(o.Profiles))
+		for name, p := range c.Profiles {
+			profiles[name] = p
+		}
+		for name, p := range o.Profiles {
+			profiles[name] = p
+		}
+		c.Profiles = profiles
+	}
+	if len(o.DefaultProfiles) > 0 {
+		c.DefaultProfiles = o.DefaultProfiles
+	}
+}
+
+// rebase makes the relative paths of c, including those of its profiles,
+// relative to dir, the directory of the config file, instead.
+func (c *Config) rebase(dir string) {
+	join := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	c.PasswordFile = join(c.PasswordFile)
+	c.Output.Dir = join(c.Output.Dir)
+	c.Output.Templates = join(c.Output.Templates)
+	for i := range c.Plugins {
+		c.Plugins[i].Out = join(c.Plugins[i].Out)
+	}
+
+	for name, p := range c.Profiles {
+		p.rebase(dir)
+		c.Profiles[name] = p
+	}
+}
+
+// Find returns the first config file from FileNames present in dir, or an
+// empty string if there is none.
+func Find(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// Profile returns the config with the named profile applied. An empty name
+// returns the base config unchanged.
+func (c *Config) Profile(name string) (*Config, error) {
+	if name == "" {
+		return c, nil
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in config", name)
+	}
+
+	merged := *c
+	merged.Merge(p)
+	merged.Name = name
+
+	for _, other := range merged.Convert {
+		o, ok := c.Profiles[other]
+		if !ok || other == name {
+			return nil, fmt.Errorf("profile %s converts from profile %q, which is not another profile in config", name, other)
+		}
+
+		counterpart := *c
+		counterpart.Merge(o)
+		counterpart.Name = other
+		merged.Counterparts = append(merged.Counterparts, &counterpart)
+	}
+
+	return &merged, nil
+}
+
+// Resolve returns the config of every named profile, falling back to
+// DefaultProfiles and then to the base config when names is empty.
+func (c *Config) Resolve(names []string) ([]*Config, error) {
+	if len(names) == 0 {
+		names = c.DefaultProfiles
+	}
+	if len(names) == 0 {
+		return []*Config{c}, nil
+	}
+
+	var configs []*Config
+	for _, name := range names {
+		p, err := c.Profile(name)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, p)
+	}
+
+	return configs, nil
+}
+
+// Env returns the connection string of the named environment, or Connection
+// when name is empty, with environment variables expanded.
+func (c *Config) Env(name string) (string, error) {
+	conn := c.Connection
+	if name != "" {
+		var ok bool
+		if conn, ok = c.Connections[name]; !ok {
+			return "", fmt.Errorf("connection %q not found in config", name)
+		}
+	}
+
+	return Expand(conn)
+}
+
+// Expand replaces ${VAR} and $VAR in s with the value of the environment
+// variable. Unset variables are an error rather than an empty string, which
+// would otherwise surface as a confusing authentication failure.
+func Expand(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// Merge overrides c with every field set in o. Maps are merged key by key.
+func (c *Config) Merge(o Config) {
+	if o.Connection != "" {
+		c.Connection = o.Connection
+	}
+	if len(o.Convert) > 0 {
+		c.Convert = o.Convert
+	}
+	if o.PasswordFile != "" {
+		c.PasswordFile = o.PasswordFile
+	}
+	if o.Role != "" {
+		c.Role = o.Role
+	}
+	if o.Pool.MaxOpen != 0 {
+		c.Pool.MaxOpen = o.Pool.MaxOpen
+	}
+	if o.Pool.MaxIdle != 0 {
+		c.Pool.MaxIdle = o.Pool.MaxIdle
+	}
+	if o.Pool.Single {
+		c.Pool.Single = true
+	}
+	if o.PasswordCommand != "" {
+		c.PasswordCommand = o.PasswordCommand
+	}
+	if o.Vault != nil {
+		c.Vault = o.Vault
+	}
+	if o.AWSSecret != nil {
+		c.AWSSecret = o.AWSSecret
+	}
+	c.Connections = mergeMap(c.Connections, o.Connections)
+	if len(o.Schemas) > 0 {
+		c.Schemas = o.Schemas
+	}
+	if len(o.Include) > 0 {
+		c.Include = o.Include
+	}
+	if len(o.Exclude) > 0 {
+		c.Exclude = o.Exclude
+	}
+	c.Types = mergeMap(c.Types, o.Types)
+	if len(o.JSONMaps) > 0 {
+		c.JSONMaps = o.JSONMaps
+	}
+	if o.Money != "" {
+		c.Money = o.Money
+	}
+	if o.Masking != nil {
+		c.Masking = o.Masking
+	}
+	if len(o.Lint.Rules) > 0 {
+		c.Lint.Rules = o.Lint.Rules
+	}
+	if len(o.Lint.Ignore) > 0 {
+		c.Lint.Ignore = o.Lint.Ignore
+	}
+	if len(o.ColumnTags) > 0 {
+		tags := make(map[string][]string, len(c.ColumnTags)+len(o.ColumnTags))
+		for name, patterns := range c.ColumnTags {
+			tags[name] = patterns
+		}
+		for name, patterns := range o.ColumnTags {
+			tags[name] = patterns
+		}
+		c.ColumnTags = tags
+	}
+
+	c.Renames = mergeMap(c.Renames, o.Renames)
+
+	if len(o.Naming.Initialisms) > 0 {
+		c.Naming.Initialisms = o.Naming.Initialisms
+	}
+	c.Naming.Rename = mergeMap(c.Naming.Rename, o.Naming.Rename)
+
+	if o.Output.Dir != "" {
+		c.Output.Dir = o.Output.Dir
+	}
+	if o.Output.Layout != "" {
+		c.Output.Layout = o.Output.Layout
+	}
+	if o.Output.GoVersion != "" {
+		c.Output.GoVersion = o.Output.GoVersion
+	}
+	if o.Output.FieldOrder != "" {
+		c.Output.FieldOrder = o.Output.FieldOrder
+	}
+	if o.Output.SearchPath {
+		c.Output.SearchPath = true
+	}
+	if len(o.Output.Nolint) > 0 {
+		c.Output.Nolint = o.Output.Nolint
+	}
+	if o.Output.NoStutter {
+		c.Output.NoStutter = true
+	}
+	if o.Output.MaxLineLength != 0 {
+		c.Output.MaxLineLength = o.Output.MaxLineLength
+	}
+	if o.Output.BuildTags != "" {
+		c.Output.BuildTags = o.Output.BuildTags
+	}
+	if o.Output.FileSuffix != "" {
+		c.Output.FileSuffix = o.Output.FileSuffix
+	}
+	if o.Output.PackagePrefix != "" {
+		c.Output.PackagePrefix = o.Output.PackagePrefix
+	}
+	if o.Output.Module != "" {
+		c.Output.Module = o.Output.Module
+	}
+	if o.Output.ModulePath != "" {
+		c.Output.ModulePath = o.Output.ModulePath
+	}
+	if o.Output.OrderPackage != "" {
+		c.Output.OrderPackage = o.Output.OrderPackage
+	}
+	if o.Output.ResetPackage != "" {
+		c.Output.ResetPackage = o.Output.ResetPackage
+	}
+	if o.Output.ResetCascade {
+		c.Output.ResetCascade = true
+	}
+	if o.Output.FixturesPackage != "" {
+		c.Output.FixturesPackage = o.Output.FixturesPackage
+	}
+	if o.Output.StmtCachePackage != "" {
+		c.Output.StmtCachePackage = o.Output.StmtCachePackage
+	}
+	if o.Output.ReplicaPackage != "" {
+		c.Output.ReplicaPackage = o.Output.ReplicaPackage
+	}
+	if o.Output.RetryPackage != "" {
+		c.Output.RetryPackage = o.Output.RetryPackage
+	}
+	if o.Output.Placeholder != "" {
+		c.Output.Placeholder = o.Output.Placeholder
+	}
+	if o.Output.WherePackage != "" {
+		c.Output.WherePackage = o.Output.WherePackage
+	}
+	if o.Output.TenantPackage != "" {
+		c.Output.TenantPackage = o.Output.TenantPackage
+	}
+	if len(o.Output.Tags) > 0 {
+		c.Output.Tags = o.Output.Tags
+	}
+	if o.Output.Templates != "" {
+		c.Output.Templates = o.Output.Templates
+	}
+	if len(o.Output.Features) > 0 {
+		c.Output.Features = o.Output.Features
+	}
+
+	if len(o.Tables) > 0 {
+		tables := make(map[string]Table, len(c.Tables)+len(o.Tables))
+		for name, t := range c.Tables {
+			tables[name] = t
+		}
+		for name, t := range o.Tables {
+			tables[name] = t
+		}
+		c.Tables = tables
+	}
+
+	if len(o.Enums) > 0 {
+		enums := make(map[string]Enum, len(c.Enums)+len(o.Enums))
+		for name, e := range c.Enums {
+			enums[name] = e
+		}
+		for name, e := range o.Enums {
+			enums[name] = e
+		}
+		c.Enums = enums
+	}
+
+	if len(o.Plugins) > 0 {
+		c.Plugins = o.Plugins
+	}
+}
+
+func mergeMap(base, over map[string]string) map[string]string {
+	if len(over) == 0 {
+		return base
+	}
+
+	merged := make(map[string]string, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		merged[k] = v
+	}
+	return merged
+}
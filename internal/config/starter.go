@@ -0,0 +1,95 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DetectConnection looks for a connection string in the environment:
+// DB_CONNECTION_STRING, DATABASE_URL, then the libpq PG* variables. The
+// password is stripped so it never lands in a config file; lib/pq reads it
+// from PGPASSWORD instead. The second result names the source.
+func DetectConnection() (string, string) {
+	if dsn := os.Getenv("DB_CONNECTION_STRING"); dsn != "" {
+		return stripPassword(dsn), "DB_CONNECTION_STRING"
+	}
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		return stripPassword(dsn), "DATABASE_URL"
+	}
+
+	var parts []string
+	for _, v := range []struct{ env, key string }{
+		{"PGHOST", "host"},
+		{"PGPORT", "port"},
+		{"PGUSER", "user"},
+		{"PGDATABASE", "dbname"},
+		{"PGSSLMODE", "sslmode"},
+	} {
+		if value := os.Getenv(v.env); value != "" {
+			parts = append(parts, v.key+"="+value)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " "), "PG* variables"
+	}
+
+	return "", ""
+}
+
+// stripPassword removes the password from a URL or key=value connection string.
+func stripPassword(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" && u.User != nil {
+		u.User = url.User(u.User.Username())
+		return u.String()
+	}
+
+	var kept []string
+	for _, field := range strings.Fields(dsn) {
+		if !strings.HasPrefix(field, "password=") {
+			kept = append(kept, field)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// Starter renders a commented tables.yaml for tables init.
+func Starter(connection string, schemas []string, output string) string {
+	var b strings.Builder
+
+	b.WriteString("# Configuration for tables; flags passed to `tables generate` override it.\n\n")
+
+	b.WriteString("# PostgreSQL connection string. The password is best left to PGPASSWORD.\n")
+	if connection == "" {
+		b.WriteString("# connection: \"host=localhost port=5432 user=postgres dbname=mydb sslmode=disable\"\n\n")
+	} else {
+		b.WriteString("connection: " + quote(connection) + "\n\n")
+	}
+
+	b.WriteString("schemas:\n")
+	for _, s := range schemas {
+		b.WriteString("  - " + quote(s) + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("# Tables to include or exclude by name or schema.name; patterns like audit_* work.\n")
+	b.WriteString("include: []\n")
+	b.WriteString("exclude: []\n\n")
+
+	b.WriteString("# Go type overrides by PostgreSQL type or table.column.\n")
+	b.WriteString("types: {}\n\n")
+
+	b.WriteString("naming:\n")
+	b.WriteString("  initialisms: []\n")
+	b.WriteString("  rename: {}\n\n")
+
+	b.WriteString("output:\n")
+	b.WriteString("  dir: " + quote(output) + "\n")
+	b.WriteString("  layout: flat\n")
+
+	return b.String()
+}
+
+func quote(s string) string {
+	return "\"" + strings.ReplaceAll(strings.ReplaceAll(s, "\\", "\\\\"), "\"", "\\\"") + "\""
+}
@@ -0,0 +1,172 @@
+package diff
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Result lists the differences between two schema states.
+type Result struct {
+	AddedTables   []schema.Table `json:"added_tables,omitempty"`
+	RemovedTables []schema.Table `json:"removed_tables,omitempty"`
+	RenamedTables []TableRename  `json:"renamed_tables,omitempty"`
+	ChangedTables []TableChange  `json:"changed_tables,omitempty"`
+}
+
+// TableRename is a table renamed from FromSchema.FromName, with the column
+// differences between its old and new definition.
+type TableRename struct {
+	FromSchema string `json:"from_schema"`
+	FromName   string `json:"from_name"`
+	TableChange
+}
+
+// TableChange lists the column differences of a table present in both states.
+type TableChange struct {
+	Schema         string          `json:"schema"`
+	Name           string          `json:"name"`
+	AddedColumns   []schema.Column `json:"added_columns,omitempty"`
+	RemovedColumns []schema.Column `json:"removed_columns,omitempty"`
+	ChangedColumns []ColumnChange  `json:"changed_columns,omitempty"`
+}
+
+// ColumnChange holds both definitions of a column whose type or nullability changed.
+type ColumnChange struct {
+	Name string        `json:"name"`
+	From schema.Column `json:"from"`
+	To   schema.Column `json:"to"`
+}
+
+// Empty reports whether the two states are identical.
+func (r Result) Empty() bool {
+	return len(r.AddedTables) == 0 && len(r.RemovedTables) == 0 && len(r.RenamedTables) == 0 && len(r.ChangedTables) == 0
+}
+
+// Compare computes the changes that turn from into to. Tables are matched by
+// schema and name, columns by name.
+func Compare(from, to []schema.Table) Result {
+	return CompareRenamed(from, to, nil)
+}
+
+// CompareRenamed is Compare with renames, which maps the old name of a
+// table to its new one, both as name or schema.name; an unqualified new
+// name keeps the schema. A removed table and an added table matching a
+// rename are reported as renamed instead.
+func CompareRenamed(from, to []schema.Table, renames map[string]string) Result {
+	var result Result
+
+	fromTables := indexTables(from)
+	toTables := indexTables(to)
+
+	for _, key := range sortedKeys(toTables) {
+		t := toTables[key]
+
+		old, exists := fromTables[key]
+		if !exists {
+			result.AddedTables = append(result.AddedTables, t)
+			continue
+		}
+
+		if change, changed := compareTable(old, t); changed {
+			result.ChangedTables = append(result.ChangedTables, change)
+		}
+	}
+
+	for _, key := range sortedKeys(fromTables) {
+		if _, exists := toTables[key]; !exists {
+			result.RemovedTables = append(result.RemovedTables, fromTables[key])
+		}
+	}
+
+	if len(renames) > 0 {
+		result = pairRenames(result, renames)
+	}
+
+	return result
+}
+
+// pairRenames moves the removed and added tables matching renames to
+// RenamedTables.
+func pairRenames(r Result, renames map[string]string) Result {
+	var removed []schema.Table
+	for _, old := range r.RemovedTables {
+		i := slices.IndexFunc(r.AddedTables, func(t schema.Table) bool { return Renamed(renames, old, t) })
+		if i < 0 {
+			removed = append(removed, old)
+			continue
+		}
+
+		t := r.AddedTables[i]
+		r.AddedTables = slices.Delete(r.AddedTables, i, i+1)
+		change, _ := compareTable(old, t)
+		r.RenamedTables = append(r.RenamedTables, TableRename{FromSchema: old.Schema, FromName: old.Name, TableChange: change})
+	}
+	r.RemovedTables = removed
+
+	return r
+}
+
+// Renamed reports whether renames maps the table from to the table to.
+func Renamed(renames map[string]string, from, to schema.Table) bool {
+	name, ok := renames[from.Schema+"."+from.Name]
+	if !ok {
+		name, ok = renames[from.Name]
+	}
+
+	return ok && (name == to.Schema+"."+to.Name || name == to.Name && to.Schema == from.Schema)
+}
+
+func compareTable(from, to schema.Table) (TableChange, bool) {
+	change := TableChange{Schema: to.Schema, Name: to.Name}
+
+	fromColumns := make(map[string]schema.Column)
+	for _, c := range from.Columns {
+		fromColumns[c.Name] = c
+	}
+	toColumns := make(map[string]bool)
+
+	// Columns keep their table order
+	for _, c := range to.Columns {
+		toColumns[c.Name] = true
+
+		old, exists := fromColumns[c.Name]
+		if !exists {
+			change.AddedColumns = append(change.AddedColumns, c)
+			continue
+		}
+
+		if !old.Equal(c) {
+			change.ChangedColumns = append(change.ChangedColumns, ColumnChange{Name: c.Name, From: old, To: c})
+		}
+	}
+
+	for _, c := range from.Columns {
+		if !toColumns[c.Name] {
+			change.RemovedColumns = append(change.RemovedColumns, c)
+		}
+	}
+
+	changed := len(change.AddedColumns) > 0 || len(change.RemovedColumns) > 0 || len(change.ChangedColumns) > 0
+	return change, changed
+}
+
+func indexTables(tables []schema.Table) map[string]schema.Table {
+	index := make(map[string]schema.Table, len(tables))
+	for _, t := range tables {
+		index[t.Schema+"."+t.Name] = t
+	}
+
+	return index
+}
+
+func sortedKeys(m map[string]schema.Table) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,92 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// CSV renders a data dictionary as spreadsheet files keyed by name:
+// tables.csv with a row per table, columns.csv with a row per column and
+// fks.csv with a row per foreign key. Files start with a UTF-8 byte order
+// mark and end lines with CRLF, so Excel opens them as they are. Lists such
+// as the columns of a key are joined with ", ".
+func CSV(tables []schema.Table) (map[string][]byte, error) {
+	tablesFile := [][]string{{"schema", "table", "columns", "primary_key", "partitioning", "rows", "table_bytes", "index_bytes"}}
+	columnsFile := [][]string{{"schema", "table", "position", "column", "type", "nullable", "default", "generated", "primary_key", "enum"}}
+	fksFile := [][]string{{"schema", "table", "constraint", "columns", "ref_schema", "ref_table", "ref_columns"}}
+
+	for _, t := range tables {
+		var partitioning, rows, tableBytes, indexBytes string
+		if t.Partitioning != nil {
+			partitioning = t.Partitioning.Strategy
+		}
+		if s := t.Stats; s != nil {
+			rows = strconv.FormatInt(s.Rows, 10)
+			tableBytes = strconv.FormatInt(s.TableBytes, 10)
+			indexBytes = strconv.FormatInt(s.IndexBytes, 10)
+		}
+		tablesFile = append(tablesFile, []string{
+			t.Schema, t.Name, strconv.Itoa(len(t.Columns)), strings.Join(primaryKey(t), ", "), partitioning, rows, tableBytes, indexBytes,
+		})
+
+		for i, c := range t.Columns {
+			var pk string
+			if c.PrimaryKey > 0 {
+				pk = strconv.Itoa(c.PrimaryKey)
+			}
+			columnsFile = append(columnsFile, []string{
+				t.Schema, t.Name, strconv.Itoa(i + 1), c.Name, c.Type, yesNo(c.Nullable), c.Default, yesNo(c.Generated), pk, strings.Join(c.Enum, ", "),
+			})
+		}
+
+		for _, fk := range t.ForeignKeys {
+			fksFile = append(fksFile, []string{
+				t.Schema, t.Name, fk.Name, strings.Join(fk.Columns, ", "), fk.RefSchema, fk.RefTable, strings.Join(fk.RefColumns, ", "),
+			})
+		}
+	}
+
+	files := make(map[string][]byte, 3)
+	for name, records := range map[string][][]string{"tables.csv": tablesFile, "columns.csv": columnsFile, "fks.csv": fksFile} {
+		var b bytes.Buffer
+		b.WriteString("\ufeff")
+		w := csv.NewWriter(&b)
+		w.UseCRLF = true
+		if err := w.WriteAll(records); err != nil {
+			return nil, err
+		}
+		files[name] = b.Bytes()
+	}
+
+	return files, nil
+}
+
+// primaryKey returns the primary key columns of t in key order.
+func primaryKey(t schema.Table) []string {
+	var key []schema.Column
+	for _, c := range t.Columns {
+		if c.PrimaryKey > 0 {
+			key = append(key, c)
+		}
+	}
+	slices.SortFunc(key, func(a, b schema.Column) int { return a.PrimaryKey - b.PrimaryKey })
+
+	names := make([]string, len(key))
+	for i, c := range key {
+		names[i] = c.Name
+	}
+
+	return names
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
@@ -0,0 +1,167 @@
+package docs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// plainDBMLType matches the types DBML takes unquoted.
+var plainDBMLType = regexp.MustCompile(`^[A-Za-z0-9_]+(\([0-9, ]*\))?$`)
+
+// numericDefault matches the defaults DBML takes as numbers.
+var numericDefault = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// DBML renders tables in the Database Markup Language of dbdiagram.io and
+// dbdocs.io: an Enum per enum type with its labels, a Table per table with
+// its columns, primary key, defaults and comments as notes, and a Ref per
+// foreign key to tables among tables.
+func DBML(tables []schema.Table) string {
+	var b strings.Builder
+
+	// Enums are known by the columns using them, and only by name
+	enums := make(map[string][]string)
+	var enumNames []string
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			name := strings.TrimRight(c.Type, "[]")
+			if len(c.Enum) == 0 || enums[name] != nil {
+				continue
+			}
+			enums[name] = c.Enum
+			enumNames = append(enumNames, name)
+		}
+	}
+	for _, name := range enumNames {
+		b.WriteString("Enum " + dbmlName(name) + " {\n")
+		for _, label := range enums[name] {
+			b.WriteString("  " + dbmlName(label) + "\n")
+		}
+		b.WriteString("}\n\n")
+	}
+
+	known := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		known[qualifiedName(t)] = true
+	}
+
+	var refs []string
+	for _, t := range tables {
+		key := primaryKey(t)
+
+		b.WriteString("Table " + dbmlTable(t.Schema, t.Name) + " {\n")
+		for _, c := range t.Columns {
+			var settings []string
+			if len(key) == 1 && c.PrimaryKey == 1 {
+				settings = append(settings, "pk")
+			}
+			if c.Generated && c.PrimaryKey > 0 {
+				settings = append(settings, "increment")
+			}
+			if !c.Nullable {
+				settings = append(settings, "not null")
+			}
+			if d := dbmlDefault(c.Default); d != "" {
+				settings = append(settings, "default: "+d)
+			}
+			if c.Comment != "" {
+				settings = append(settings, "note: "+dbmlString(c.Comment))
+			}
+
+			line := "  " + dbmlName(c.Name) + " " + dbmlType(c.Type)
+			if len(settings) > 0 {
+				line += " [" + strings.Join(settings, ", ") + "]"
+			}
+			b.WriteString(line + "\n")
+		}
+
+		if len(key) > 1 {
+			quoted := make([]string, len(key))
+			for i, k := range key {
+				quoted[i] = dbmlName(k)
+			}
+			b.WriteString("\n  indexes {\n    (" + strings.Join(quoted, ", ") + ") [pk]\n  }\n")
+		}
+
+		if t.Comment != "" {
+			b.WriteString("\n  Note: " + dbmlString(t.Comment) + "\n")
+		}
+		b.WriteString("}\n\n")
+
+		for _, fk := range t.ForeignKeys {
+			if !known[qualifiedName(schema.Table{Schema: fk.RefSchema, Name: fk.RefTable})] {
+				continue
+			}
+			refs = append(refs, "Ref "+dbmlName(fk.Name)+": "+dbmlColumns(t.Schema, t.Name, fk.Columns)+" > "+dbmlColumns(fk.RefSchema, fk.RefTable, fk.RefColumns))
+		}
+	}
+
+	for _, r := range refs {
+		b.WriteString(r + "\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// dbmlName quotes an identifier.
+func dbmlName(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+}
+
+func dbmlTable(schemaName, tableName string) string {
+	if schemaName == "" {
+		return dbmlName(tableName)
+	}
+
+	return dbmlName(schemaName) + "." + dbmlName(tableName)
+}
+
+// dbmlColumns refers to columns of a table, in parentheses when there are
+// several.
+func dbmlColumns(schemaName, tableName string, columns []string) string {
+	if len(columns) == 1 {
+		return dbmlTable(schemaName, tableName) + "." + dbmlName(columns[0])
+	}
+
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = dbmlName(c)
+	}
+
+	return dbmlTable(schemaName, tableName) + ".(" + strings.Join(quoted, ", ") + ")"
+}
+
+// dbmlType quotes the types, such as timestamp with time zone or int4[],
+// DBML does not take as they are.
+func dbmlType(t string) string {
+	if plainDBMLType.MatchString(t) {
+		return t
+	}
+
+	return dbmlName(t)
+}
+
+// dbmlDefault renders a default expression as a number, a boolean or an
+// expression in backticks, or "" for one DBML cannot hold.
+func dbmlDefault(d string) string {
+	switch {
+	case d == "":
+		return ""
+	case numericDefault.MatchString(d), d == "true", d == "false":
+		return d
+	case strings.Contains(d, "`"):
+		return ""
+	}
+
+	return "`" + d + "`"
+}
+
+// dbmlString quotes a note, in triple quotes when it spans several lines.
+func dbmlString(s string) string {
+	if strings.Contains(s, "\n") {
+		return "'''\n" + strings.ReplaceAll(s, "'''", `\'''`) + "\n'''"
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,162 @@
+package docs
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Diagram layout, in pixels. Text is measured with charWidth per character
+// of the monospace font.
+const (
+	charWidth  = 7.2
+	rowHeight  = 18
+	boxPadding = 8
+	cellGap    = 60
+)
+
+// box is a table drawn in the diagram.
+type box struct {
+	table schema.Table
+	rows  []string
+	x, y  float64
+	w, h  float64
+}
+
+// SVG renders an entity-relationship diagram of tables: a box per table
+// with its primary and foreign key columns, laid out on a grid, and an arrow
+// per foreign key to the referenced table. Boxes link to the page of their
+// table through href, when set.
+func SVG(tables []schema.Table, href func(schema.Table) string) string {
+	boxes := make([]*box, len(tables))
+	index := make(map[string]*box, len(tables))
+	for i, t := range tables {
+		b := &box{table: t}
+		others := 0
+		keys := make(map[string]bool)
+		for _, fk := range t.ForeignKeys {
+			for _, c := range fk.Columns {
+				keys[c] = true
+			}
+		}
+		for _, c := range t.Columns {
+			switch {
+			case c.PrimaryKey > 0:
+				b.rows = append(b.rows, "PK "+c.Name)
+			case keys[c.Name]:
+				b.rows = append(b.rows, "FK "+c.Name)
+			default:
+				others++
+			}
+		}
+		if others > 0 {
+			b.rows = append(b.rows, fmt.Sprintf("+%d columns", others))
+		}
+
+		width := len(qualifiedName(t))
+		for _, r := range b.rows {
+			width = max(width, len(r))
+		}
+		b.w = float64(width)*charWidth + 2*boxPadding
+		b.h = float64(len(b.rows)+1)*rowHeight + boxPadding
+
+		boxes[i] = b
+		index[qualifiedName(t)] = b
+	}
+
+	// A grid about as wide as high, each column as wide as its widest box
+	// and each row as high as its highest one
+	cols := max(int(math.Ceil(math.Sqrt(float64(len(boxes))))), 1)
+	widths := make([]float64, cols)
+	heights := make([]float64, (len(boxes)+cols-1)/cols)
+	for i, b := range boxes {
+		widths[i%cols] = max(widths[i%cols], b.w)
+		heights[i/cols] = max(heights[i/cols], b.h)
+	}
+	for i, b := range boxes {
+		b.x = cellGap / 2
+		for _, w := range widths[:i%cols] {
+			b.x += w + cellGap
+		}
+		b.y = cellGap / 2
+		for _, h := range heights[:i/cols] {
+			b.y += h + cellGap
+		}
+	}
+	width, height := float64(cellGap)/2, float64(cellGap)/2
+	for _, w := range widths {
+		width += w + cellGap
+	}
+	for _, h := range heights {
+		height += h + cellGap
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, `<svg xmlns="http://www.w3.org/2000/svg" class="er" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`+"\n", width, height, width, height)
+	s.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z"/></marker></defs>` + "\n")
+
+	for _, b := range boxes {
+		for _, fk := range b.table.ForeignKeys {
+			ref, ok := index[qualifiedName(schema.Table{Schema: fk.RefSchema, Name: fk.RefTable})]
+			if !ok {
+				continue
+			}
+			title := html.EscapeString(fk.Name + ": " + strings.Join(fk.Columns, ", ") + " → " + qualifiedName(ref.table))
+			if ref == b {
+				// A loop on the right side
+				x, y := b.x+b.w, b.y+rowHeight/2
+				fmt.Fprintf(&s, `<path class="fk" d="M %.1f %.1f c 30 0 30 %d 0 %d" marker-end="url(#arrow)"><title>%s</title></path>`+"\n", x, y, rowHeight, rowHeight, title)
+				continue
+			}
+			x1, y1 := b.border(ref.cx(), ref.cy())
+			x2, y2 := ref.border(b.cx(), b.cy())
+			fmt.Fprintf(&s, `<line class="fk" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" marker-end="url(#arrow)"><title>%s</title></line>`+"\n", x1, y1, x2, y2, title)
+		}
+	}
+
+	for _, b := range boxes {
+		name := html.EscapeString(qualifiedName(b.table))
+		if href != nil {
+			fmt.Fprintf(&s, `<a href="%s">`, html.EscapeString(href(b.table)))
+		}
+		fmt.Fprintf(&s, `<g class="table" id="er-%s"><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f"/>`, name, b.x, b.y, b.w, b.h)
+		fmt.Fprintf(&s, `<rect class="head" x="%.1f" y="%.1f" width="%.1f" height="%d"/>`, b.x, b.y, b.w, rowHeight+boxPadding/2)
+		fmt.Fprintf(&s, `<text class="name" x="%.1f" y="%.1f">%s</text>`, b.x+boxPadding, b.y+rowHeight-2, name)
+		for i, r := range b.rows {
+			fmt.Fprintf(&s, `<text x="%.1f" y="%.1f">%s</text>`, b.x+boxPadding, b.y+float64(i+2)*rowHeight, html.EscapeString(r))
+		}
+		s.WriteString("</g>")
+		if href != nil {
+			s.WriteString("</a>")
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString("</svg>\n")
+
+	return s.String()
+}
+
+func (b *box) cx() float64 { return b.x + b.w/2 }
+func (b *box) cy() float64 { return b.y + b.h/2 }
+
+// border returns where the line from the center of b to x, y leaves b.
+func (b *box) border(x, y float64) (float64, float64) {
+	dx, dy := x-b.cx(), y-b.cy()
+	if dx == 0 && dy == 0 {
+		return b.cx(), b.cy()
+	}
+
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = b.w / 2 / math.Abs(dx)
+	}
+	if dy != 0 {
+		scale = min(scale, b.h/2/math.Abs(dy))
+	}
+
+	return b.cx() + dx*scale, b.cy() + dy*scale
+}
@@ -0,0 +1,250 @@
+package docs
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// unsafeFileChars matches the characters of a table name kept out of page
+// file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// htmlTable is a table with what its page shows about its neighbours.
+type htmlTable struct {
+	schema.Table
+	Name     string
+	Page     string
+	Search   string
+	Key      []string
+	Outgoing []htmlLink
+	Incoming []htmlLink
+}
+
+// htmlLink is a foreign key from or to another table, with the page of
+// that table, empty when it is not documented.
+type htmlLink struct {
+	schema.ForeignKey
+	Table string
+	Page  string
+}
+
+// HTML renders a static site browsing tables, keyed by file path:
+// index.html lists the tables with a search box filtering them by table or
+// column name, diagram.html shows the entity-relationship diagram of SVG,
+// and tables/<schema>.<name>.html details the columns, foreign keys in both
+// directions, partitions and statistics of a table. The site has no
+// external assets, so it works from any static file host.
+func HTML(tables []schema.Table) (map[string][]byte, error) {
+	page := func(t schema.Table) string {
+		return "tables/" + unsafeFileChars.ReplaceAllString(qualifiedName(t), "_") + ".html"
+	}
+	pages := make(map[string]string, len(tables))
+	for _, t := range tables {
+		pages[qualifiedName(t)] = page(t)
+	}
+
+	incoming := make(map[string][]htmlLink)
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			ref := qualifiedName(schema.Table{Schema: fk.RefSchema, Name: fk.RefTable})
+			incoming[ref] = append(incoming[ref], htmlLink{ForeignKey: fk, Table: qualifiedName(t), Page: pages[qualifiedName(t)]})
+		}
+	}
+
+	views := make([]htmlTable, len(tables))
+	for i, t := range tables {
+		name := qualifiedName(t)
+		search := []string{name}
+		for _, c := range t.Columns {
+			search = append(search, c.Name)
+		}
+
+		v := htmlTable{Table: t, Name: name, Page: pages[name], Search: strings.ToLower(strings.Join(search, " ")), Key: primaryKey(t), Incoming: incoming[name]}
+		for _, fk := range t.ForeignKeys {
+			ref := qualifiedName(schema.Table{Schema: fk.RefSchema, Name: fk.RefTable})
+			v.Outgoing = append(v.Outgoing, htmlLink{ForeignKey: fk, Table: ref, Page: pages[ref]})
+		}
+		views[i] = v
+	}
+
+	files := make(map[string][]byte, len(tables)+3)
+	files["style.css"] = []byte(htmlStyle)
+
+	render := func(path, name string, data any) error {
+		var b bytes.Buffer
+		if err := htmlTemplates.ExecuteTemplate(&b, name, data); err != nil {
+			return err
+		}
+		files[path] = b.Bytes()
+		return nil
+	}
+
+	if err := render("index.html", "index", map[string]any{"Root": "", "Tables": views}); err != nil {
+		return nil, err
+	}
+
+	svg := SVG(tables, func(t schema.Table) string { return page(t) })
+	if err := render("diagram.html", "diagram", map[string]any{"Root": "", "SVG": template.HTML(svg)}); err != nil {
+		return nil, err
+	}
+
+	for _, v := range views {
+		if err := render(v.Page, "table", map[string]any{"Root": "../", "Table": v}); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+var htmlTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"join":  strings.Join,
+	"bytes": formatBytes,
+	"add1":  func(i int) int { return i + 1 },
+}).Parse(`
+{{- define "nav" -}}
+<nav><a href="{{.Root}}index.html">Tables</a> <a href="{{.Root}}diagram.html">Diagram</a></nav>
+{{- end}}
+
+{{- define "index" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Data Dictionary</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+{{template "nav" .}}
+<h1>Data Dictionary</h1>
+<input id="search" type="search" placeholder="Filter by table or column name" autofocus>
+<table id="tables">
+<thead><tr><th>Table</th><th>Columns</th><th>Primary key</th><th>References</th></tr></thead>
+<tbody>
+{{- range .Tables}}
+<tr data-search="{{.Search}}"><td><a href="{{.Page}}">{{.Name}}</a></td><td>{{len .Columns}}</td><td>{{join .Key ", "}}</td><td>{{range $i, $l := .Outgoing}}{{if $i}}, {{end}}{{$l.Table}}{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.getElementById("search").addEventListener("input", function () {
+  var words = this.value.toLowerCase().split(/\s+/).filter(Boolean);
+  document.querySelectorAll("#tables tbody tr").forEach(function (row) {
+    var text = row.dataset.search;
+    row.hidden = !words.every(function (w) { return text.indexOf(w) >= 0; });
+  });
+});
+</script>
+</body>
+</html>
+{{end}}
+
+{{- define "diagram" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Diagram</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+{{template "nav" .}}
+<h1>Diagram</h1>
+<p>Primary keys (PK) and foreign keys (FK) of every table. Arrows point to the referenced table; click a table for its details.</p>
+<div class="diagram">
+{{.SVG}}
+</div>
+</body>
+</html>
+{{end}}
+
+{{- define "table" -}}
+{{- $root := .Root -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Table.Name}}</title>
+<link rel="stylesheet" href="../style.css">
+</head>
+<body>
+{{template "nav" .}}
+{{- with .Table}}
+<h1>{{.Name}}</h1>
+{{- with .Comment}}
+<p>{{.}}</p>
+{{- end}}
+{{- with .Stats}}
+<p>About {{.Rows}} rows, {{bytes .TableBytes}} on disk and {{bytes .IndexBytes}} of indexes.</p>
+{{- end}}
+<h2>Columns</h2>
+<table>
+<thead><tr><th>#</th><th>Column</th><th>Type</th><th>Nullable</th><th>Default</th><th>Key</th></tr></thead>
+<tbody>
+{{- range $i, $c := .Columns}}
+<tr id="{{$c.Name}}"><td>{{add1 $i}}</td><td><code>{{$c.Name}}</code>{{with $c.Comment}}<br><small>{{.}}</small>{{end}}</td><td>{{$c.Type}}{{with $c.Enum}}<br><small>{{join . ", "}}</small>{{end}}</td><td>{{if $c.Nullable}}YES{{else}}NO{{end}}</td><td>{{if $c.Generated}}<em>generated</em>{{else if $c.Default}}<code>{{$c.Default}}</code>{{end}}</td><td>{{if $c.PrimaryKey}}PK {{$c.PrimaryKey}}{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- if .Outgoing}}
+<h2>References</h2>
+<table>
+<thead><tr><th>Constraint</th><th>Columns</th><th>Table</th><th>Referenced columns</th></tr></thead>
+<tbody>
+{{- range .Outgoing}}
+<tr><td>{{.Name}}</td><td>{{join .Columns ", "}}</td><td>{{if .Page}}<a href="{{$root}}{{.Page}}">{{.Table}}</a>{{else}}{{.Table}}{{end}}</td><td>{{join .RefColumns ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .Incoming}}
+<h2>Referenced by</h2>
+<table>
+<thead><tr><th>Table</th><th>Constraint</th><th>Columns</th><th>Referenced columns</th></tr></thead>
+<tbody>
+{{- range .Incoming}}
+<tr><td><a href="{{$root}}{{.Page}}">{{.Table}}</a></td><td>{{.Name}}</td><td>{{join .Columns ", "}}</td><td>{{join .RefColumns ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- with .Partitioning}}
+<h2>Partitions</h2>
+<p>Partitioned by {{.Strategy}}{{with .Key}} on {{join . ", "}}{{end}}.</p>
+<table>
+<thead><tr><th>Partition</th><th>Bound</th></tr></thead>
+<tbody>
+{{- range .Partitions}}
+<tr><td>{{.Schema}}.{{.Name}}</td><td><code>{{.Bound}}</code></td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{end}}
+`))
+
+const htmlStyle = `body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+nav a { margin-right: 1rem; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #d0d7de; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+#search { width: 100%; max-width: 30rem; padding: 0.4rem; margin-bottom: 1rem; }
+.diagram { overflow: auto; }
+.er { font: 12px monospace; }
+.er .table rect { fill: #fff; stroke: #57606a; }
+.er .table rect.head { fill: #ddf4ff; }
+.er .table .name { font-weight: bold; }
+.er a:hover rect { stroke: #0969da; stroke-width: 2; }
+.er .fk { stroke: #57606a; fill: none; }
+.er marker path { fill: #57606a; }
+`
@@ -0,0 +1,103 @@
+package docs
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Markdown renders a data dictionary with a section per table. Tables with
+// Stats also get a capacity overview, largest first.
+func Markdown(tables []schema.Table) string {
+	var b strings.Builder
+
+	b.WriteString("# Data Dictionary\n\n")
+
+	// Table of contents
+	for _, t := range tables {
+		name := qualifiedName(t)
+		b.WriteString("- [" + name + "](#" + anchor(name) + ")\n")
+	}
+
+	writeCapacity(&b, tables)
+
+	for _, t := range tables {
+		b.WriteString("\n## " + qualifiedName(t) + "\n\n")
+		if s := t.Stats; s != nil {
+			fmt.Fprintf(&b, "About %d rows, %s on disk and %s of indexes.\n\n", s.Rows, formatBytes(s.TableBytes), formatBytes(s.IndexBytes))
+		}
+		b.WriteString("| Column | Type | Nullable |\n")
+		b.WriteString("|--------|------|----------|\n")
+
+		for _, c := range t.Columns {
+			nullable := "NO"
+			if c.Nullable {
+				nullable = "YES"
+			}
+			b.WriteString("| `" + c.Name + "` | " + c.Type + " | " + nullable + " |\n")
+		}
+	}
+
+	return b.String()
+}
+
+// writeCapacity renders the tables with Stats by total size, if any.
+func writeCapacity(b *strings.Builder, tables []schema.Table) {
+	var sized []schema.Table
+	for _, t := range tables {
+		if t.Stats != nil {
+			sized = append(sized, t)
+		}
+	}
+	if len(sized) == 0 {
+		return
+	}
+
+	total := func(t schema.Table) int64 {
+		return t.Stats.TableBytes + t.Stats.IndexBytes
+	}
+	slices.SortStableFunc(sized, func(x, y schema.Table) int {
+		return cmp.Compare(total(y), total(x))
+	})
+
+	b.WriteString("\n## Capacity\n\n")
+	b.WriteString("| Table | Rows | Size | Indexes |\n")
+	b.WriteString("|-------|-----:|-----:|--------:|\n")
+	for _, t := range sized {
+		name := qualifiedName(t)
+		fmt.Fprintf(b, "| [%s](#%s) | %d | %s | %s |\n", name, anchor(name), t.Stats.Rows, formatBytes(t.Stats.TableBytes), formatBytes(t.Stats.IndexBytes))
+	}
+}
+
+// formatBytes renders a size like pg_size_pretty: in bytes below 10 kB, and
+// otherwise in the largest unit that keeps the value at 10 or more.
+func formatBytes(n int64) string {
+	if n < 10*1024 {
+		return fmt.Sprintf("%d bytes", n)
+	}
+
+	units := []string{"kB", "MB", "GB", "TB"}
+	v := float64(n) / 1024
+	unit := 0
+	for v >= 10*1024 && unit < len(units)-1 {
+		v /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.0f %s", v, units[unit])
+}
+
+func qualifiedName(t schema.Table) string {
+	if t.Schema == "" {
+		return t.Name
+	}
+
+	return t.Schema + "." + t.Name
+}
+
+// anchor mimics the heading anchors GitHub generates.
+func anchor(heading string) string {
+	return strings.NewReplacer(".", "", " ", "-").Replace(strings.ToLower(heading))
+}
@@ -0,0 +1,100 @@
+package docs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// unsafeAliasChars matches the characters of a table name kept out of
+// PlantUML aliases.
+var unsafeAliasChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// PlantUML renders an entity-relationship diagram in PlantUML: an entity
+// per table listing its primary key columns above the others, NOT NULL
+// columns marked with *, and a crow's foot relation per foreign key to
+// tables among tables, optional when a referencing column is nullable.
+func PlantUML(tables []schema.Table) string {
+	alias := func(schemaName, tableName string) string {
+		return unsafeAliasChars.ReplaceAllString(qualifiedName(schema.Table{Schema: schemaName, Name: tableName}), "_")
+	}
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	b.WriteString("hide circle\n")
+	b.WriteString("skinparam linetype ortho\n")
+
+	known := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		known[alias(t.Schema, t.Name)] = true
+	}
+
+	for _, t := range tables {
+		b.WriteString("\nentity \"" + qualifiedName(t) + "\" as " + alias(t.Schema, t.Name) + " {\n")
+
+		key := primaryKey(t)
+		for _, name := range key {
+			for _, c := range t.Columns {
+				if c.Name == name {
+					b.WriteString("  * " + c.Name + " : " + c.Type + " <<PK>>\n")
+				}
+			}
+		}
+		if len(key) > 0 {
+			b.WriteString("  --\n")
+		}
+
+		fkColumns := make(map[string]bool)
+		for _, fk := range t.ForeignKeys {
+			for _, c := range fk.Columns {
+				fkColumns[c] = true
+			}
+		}
+		for _, c := range t.Columns {
+			if c.PrimaryKey > 0 {
+				continue
+			}
+			line := "  "
+			if !c.Nullable {
+				line += "* "
+			}
+			line += c.Name + " : " + c.Type
+			if fkColumns[c.Name] {
+				line += " <<FK>>"
+			}
+			b.WriteString(line + "\n")
+		}
+
+		b.WriteString("}\n")
+	}
+
+	var relations []string
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			ref := alias(fk.RefSchema, fk.RefTable)
+			if !known[ref] {
+				continue
+			}
+
+			// Many referencing rows to one referenced row, or to none when
+			// the key may be NULL
+			arrow := "}o--||"
+			for _, name := range fk.Columns {
+				for _, c := range t.Columns {
+					if c.Name == name && c.Nullable {
+						arrow = "}o--o|"
+					}
+				}
+			}
+			relations = append(relations, alias(t.Schema, t.Name)+" "+arrow+" "+ref+" : "+fk.Name)
+		}
+	}
+	if len(relations) > 0 {
+		b.WriteString("\n" + strings.Join(relations, "\n") + "\n")
+	}
+
+	b.WriteString("@enduml\n")
+
+	return b.String()
+}
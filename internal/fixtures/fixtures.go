@@ -0,0 +1,120 @@
+// Package fixtures reads rows from the database and writes them as
+// testfixtures-style YAML: a file per table holding a list of records.
+package fixtures
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mymyka/tables/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Read returns the first limit rows of t, ordered by primary key when it
+// has one, with a value per column in column order.
+func Read(db *sql.DB, t schema.Table, limit int) ([][]any, error) {
+	columns := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		columns[i] = pq.QuoteIdentifier(c.Name)
+	}
+
+	var key []schema.Column
+	for _, c := range t.Columns {
+		if c.PrimaryKey > 0 {
+			key = append(key, c)
+		}
+	}
+	slices.SortFunc(key, func(a, b schema.Column) int { return a.PrimaryKey - b.PrimaryKey })
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + pq.QuoteIdentifier(t.Schema) + "." + pq.QuoteIdentifier(t.Name)
+	if len(key) > 0 {
+		order := make([]string, len(key))
+		for i, c := range key {
+			order[i] = pq.QuoteIdentifier(c.Name)
+		}
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	query += " LIMIT $1"
+
+	slog.Debug("Reading rows", "sql", query, "limit", limit)
+
+	rows, err := db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s.%s: %w", t.Schema, t.Name, err)
+	}
+	defer rows.Close()
+
+	var result [][]any
+	for rows.Next() {
+		values := make([]any, len(t.Columns))
+		dest := make([]any, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, values)
+	}
+
+	return result, rows.Err()
+}
+
+// YAML encodes rows of t as a list of records keyed by column name, in
+// column order. Values are written as PostgreSQL accepts them back:
+// timestamps in RFC 3339, bytea as \x hex and other types in their text
+// form. NULL columns are written as null; nil values of NOT NULL columns,
+// which masking leaves for a zero value, are left out.
+func YAML(t schema.Table, rows [][]any) ([]byte, error) {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range rows {
+		record := &yaml.Node{Kind: yaml.MappingNode}
+		for i, c := range t.Columns {
+			if i >= len(row) || row[i] == nil && !c.Nullable {
+				continue
+			}
+			record.Content = append(record.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: c.Name},
+				value(c, row[i]),
+			)
+		}
+		list.Content = append(list.Content, record)
+	}
+
+	data, err := yaml.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixtures of %s.%s: %w", t.Schema, t.Name, err)
+	}
+
+	return data, nil
+}
+
+// value returns the YAML node of a scanned value.
+func value(c schema.Column, v any) *yaml.Node {
+	switch v := v.(type) {
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v, 10)}
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	case time.Time:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Format(time.RFC3339Nano)}
+	case []byte:
+		if c.Type == "bytea" {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: `\x` + hex.EncodeToString(v)}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(v)}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(v)}
+	}
+}
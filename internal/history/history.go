@@ -0,0 +1,163 @@
+// Package history keeps a directory of schema snapshots, one per version, and
+// tells when each table and column appeared or changed across them.
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mymyka/tables/internal/diff"
+	"github.com/mymyka/tables/internal/snapshot"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// VersionFormat names the snapshots of a history after the UTC time they
+// were taken at, so they sort in order.
+const VersionFormat = "20060102150405"
+
+// Version is a snapshot of a history.
+type Version struct {
+	Name string // file name without .json, e.g. 20250102150405
+	Path string
+}
+
+// Event is something that happened to a table or, when Column is set, to
+// one of its columns in a version.
+type Event struct {
+	Version string `json:"version"`
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Column  string `json:"column,omitempty"`
+	// Kind is "added", "removed", "changed" or "renamed".
+	Kind   string `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// List returns the snapshots in dir ordered from oldest to newest. A missing
+// directory has none.
+func List(dir string) ([]Version, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var versions []Version
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			versions = append(versions, Version{Name: name, Path: filepath.Join(dir, e.Name())})
+		}
+	}
+
+	return versions, nil
+}
+
+// Append saves tables into dir as a new version taken at now, unless they
+// hash the same as the latest version. It returns the version written, or
+// the latest one and false when the schema did not change.
+func Append(dir string, tables []schema.Table, now time.Time) (Version, bool, error) {
+	versions, err := List(dir)
+	if err != nil {
+		return Version{}, false, err
+	}
+
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		previous, err := snapshot.Load(latest.Path)
+		if err != nil {
+			return Version{}, false, err
+		}
+		if schema.Hash(previous) == schema.Hash(tables) {
+			return latest, false, nil
+		}
+	}
+
+	name := now.UTC().Format(VersionFormat)
+	if len(versions) > 0 && name <= versions[len(versions)-1].Name {
+		return Version{}, false, fmt.Errorf("history already has version %s, newer than %s", versions[len(versions)-1].Name, name)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Version{}, false, fmt.Errorf("failed to create history: %w", err)
+	}
+
+	v := Version{Name: name, Path: filepath.Join(dir, name+".json")}
+	if err := snapshot.Save(v.Path, tables); err != nil {
+		return Version{}, false, err
+	}
+
+	return v, true, nil
+}
+
+// Events replays the versions in order and returns what changed in each,
+// starting with every table of the first version as added. renames are
+// the table renames of diff.CompareRenamed.
+func Events(versions []Version, renames map[string]string) ([]Event, error) {
+	var events []Event
+	var previous []schema.Table
+
+	for _, v := range versions {
+		tables, err := snapshot.Load(v.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		r := diff.CompareRenamed(previous, tables, renames)
+		for _, t := range r.AddedTables {
+			events = append(events, Event{Version: v.Name, Schema: t.Schema, Table: t.Name, Kind: "added", Detail: fmt.Sprintf("%d columns", len(t.Columns))})
+		}
+		for _, t := range r.RemovedTables {
+			events = append(events, Event{Version: v.Name, Schema: t.Schema, Table: t.Name, Kind: "removed"})
+		}
+		for _, t := range r.RenamedTables {
+			events = append(events, Event{Version: v.Name, Schema: t.Schema, Table: t.Name, Kind: "renamed", Detail: "from " + t.FromSchema + "." + t.FromName})
+			events = append(events, columnEvents(v.Name, t.TableChange)...)
+		}
+		for _, t := range r.ChangedTables {
+			events = append(events, columnEvents(v.Name, t)...)
+		}
+
+		previous = tables
+	}
+
+	// Group by table, keeping the order of versions within each
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return strings.Compare(a.Schema+"."+a.Table, b.Schema+"."+b.Table)
+	})
+
+	return events, nil
+}
+
+func columnEvents(version string, t diff.TableChange) []Event {
+	var events []Event
+	for _, c := range t.AddedColumns {
+		events = append(events, Event{Version: version, Schema: t.Schema, Table: t.Name, Column: c.Name, Kind: "added", Detail: describe(c)})
+	}
+	for _, c := range t.RemovedColumns {
+		events = append(events, Event{Version: version, Schema: t.Schema, Table: t.Name, Column: c.Name, Kind: "removed"})
+	}
+	for _, c := range t.ChangedColumns {
+		events = append(events, Event{Version: version, Schema: t.Schema, Table: t.Name, Column: c.Name, Kind: "changed", Detail: describe(c.From) + " -> " + describe(c.To)})
+	}
+
+	return events
+}
+
+// describe summarizes a column definition like diff does.
+func describe(c schema.Column) string {
+	desc := c.Type + " NOT NULL"
+	if c.Nullable {
+		desc = c.Type + " NULL"
+	}
+	if c.Default != "" {
+		desc += " DEFAULT " + c.Default
+	}
+
+	return desc
+}
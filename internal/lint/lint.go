@@ -0,0 +1,180 @@
+// Package lint checks a schema against naming conventions.
+package lint
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Rules of a schema lint.
+const (
+	// RuleSnakeCase wants table and column names in lower snake_case.
+	RuleSnakeCase = "snake-case"
+
+	// RulePrimaryKey wants every table to have a primary key of a single
+	// column named id.
+	RulePrimaryKey = "pk-id"
+
+	// RuleForeignKey wants a single-column foreign key to be named after the
+	// table it references, <table>_id, with the table name singular or as is.
+	RuleForeignKey = "fk-name"
+
+	// RuleReserved rejects table and column names that are reserved words
+	// in PostgreSQL, which every query has to quote.
+	RuleReserved = "reserved"
+)
+
+// AllRules are the rules checked when Options.Rules is empty.
+var AllRules = []string{RuleSnakeCase, RulePrimaryKey, RuleForeignKey, RuleReserved}
+
+// Options configures a lint.
+type Options struct {
+	// Rules to check. Defaults to AllRules.
+	Rules []string
+
+	// Ignore skips the tables and columns matching path.Match patterns
+	// against table, schema.table, table.column or schema.table.column.
+	Ignore []string
+}
+
+// Violation is a name breaking a rule. Column is empty for a table.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+var snakeCaseRe = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// reserved lists the key words PostgreSQL reserves, including those it
+// only allows as function or type names.
+var reserved = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`all analyse analyze and any array as asc asymmetric authorization
+		binary both case cast check collate collation column concurrently constraint create cross
+		current_catalog current_date current_role current_schema current_time current_timestamp
+		current_user default deferrable desc distinct do else end except false fetch for foreign
+		freeze from full grant group having ilike in initially inner intersect into is isnull join
+		lateral leading left like limit localtime localtimestamp natural not notnull null offset on
+		only or order outer overlaps placing primary references returning right select session_user
+		similar some symmetric system_user table tablesample then to trailing true union unique user
+		using variadic verbose when where window with`) {
+		reserved[w] = true
+	}
+}
+
+// Check returns the violations of the rules in tables, in table order.
+func Check(tables []schema.Table, opts Options) ([]Violation, error) {
+	rules := opts.Rules
+	if len(rules) == 0 {
+		rules = AllRules
+	}
+	enabled := make(map[string]bool)
+	for _, r := range rules {
+		if !slices.Contains(AllRules, r) {
+			return nil, fmt.Errorf("unknown lint rule %q, expected one of %s", r, strings.Join(AllRules, ", "))
+		}
+		enabled[r] = true
+	}
+
+	var violations []Violation
+	for _, t := range tables {
+		if ignored(opts.Ignore, t.Schema, t.Name) {
+			continue
+		}
+		add := func(rule, column, format string, args ...any) {
+			violations = append(violations, Violation{Rule: rule, Schema: t.Schema, Table: t.Name, Column: column, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if enabled[RuleSnakeCase] && !snakeCaseRe.MatchString(t.Name) {
+			add(RuleSnakeCase, "", "table name %q is not snake_case", t.Name)
+		}
+		if enabled[RuleReserved] && reserved[strings.ToLower(t.Name)] {
+			add(RuleReserved, "", "table name %q is a reserved word", t.Name)
+		}
+		if enabled[RulePrimaryKey] {
+			var pk []string
+			for _, c := range t.Columns {
+				if c.PrimaryKey > 0 {
+					pk = append(pk, c.Name)
+				}
+			}
+			switch {
+			case len(pk) == 0:
+				add(RulePrimaryKey, "", "table has no primary key")
+			case len(pk) > 1:
+				add(RulePrimaryKey, "", "primary key (%s) is not a single id column", strings.Join(pk, ", "))
+			case pk[0] != "id":
+				add(RulePrimaryKey, pk[0], "primary key column %q is not named id", pk[0])
+			}
+		}
+
+		for _, c := range t.Columns {
+			if ignored(opts.Ignore, t.Schema, t.Name+"."+c.Name) {
+				continue
+			}
+			if enabled[RuleSnakeCase] && !snakeCaseRe.MatchString(c.Name) {
+				add(RuleSnakeCase, c.Name, "column name %q is not snake_case", c.Name)
+			}
+			if enabled[RuleReserved] && reserved[strings.ToLower(c.Name)] {
+				add(RuleReserved, c.Name, "column name %q is a reserved word", c.Name)
+			}
+		}
+
+		if enabled[RuleForeignKey] {
+			for _, fk := range t.ForeignKeys {
+				if len(fk.Columns) != 1 || ignored(opts.Ignore, t.Schema, t.Name+"."+fk.Columns[0]) {
+					continue
+				}
+				if names := foreignKeyNames(fk.RefTable); !slices.Contains(names, fk.Columns[0]) {
+					add(RuleForeignKey, fk.Columns[0], "column %q references %s.%s, expected %s", fk.Columns[0], fk.RefSchema, fk.RefTable, strings.Join(names, " or "))
+				}
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// foreignKeyNames returns the accepted names of a column referencing table:
+// <table>_id, and <singular>_id for a plural table name.
+func foreignKeyNames(table string) []string {
+	names := []string{table + "_id"}
+
+	var singular string
+	switch {
+	case strings.HasSuffix(table, "ies"):
+		singular = strings.TrimSuffix(table, "ies") + "y"
+	case strings.HasSuffix(table, "sses"), strings.HasSuffix(table, "xes"), strings.HasSuffix(table, "ches"), strings.HasSuffix(table, "shes"):
+		singular = strings.TrimSuffix(table, "es")
+	case strings.HasSuffix(table, "s") && !strings.HasSuffix(table, "ss"):
+		singular = strings.TrimSuffix(table, "s")
+	}
+	if singular != "" {
+		names = append([]string{singular + "_id"}, names...)
+	}
+
+	return names
+}
+
+// ignored reports whether a table, or a column given as table.column,
+// matches one of the Ignore patterns.
+func ignored(patterns []string, schemaName, name string) bool {
+	for _, p := range patterns {
+		for _, candidate := range []string{name, schemaName + "." + name} {
+			if ok, _ := path.Match(p, candidate); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
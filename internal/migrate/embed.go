@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"go/format"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/mymyka/tables/pkg/gen"
+)
+
+// EmbedFile is the name of the file written by EmbedSource.
+const EmbedFile = "embed.go"
+
+// embedTemplate is the source of EmbedFile. The driver gets a single
+// connection rather than the *sql.DB, since closing a driver created with
+// postgres.WithInstance closes the database as well.
+const embedTemplate = `package PACKAGE
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// FS holds the migrations of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Migrate applies the migrations not yet applied to db. It does not close db.
+func Migrate(db *sql.DB) error {
+	return MigrateContext(context.Background(), db)
+}
+
+// MigrateContext is Migrate with a context for acquiring the connection.
+func MigrateContext(ctx context.Context, db *sql.DB) error {
+	source, err := iofs.New(FS, ".")
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		source.Close()
+		return err
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		source.Close()
+		conn.Close()
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		source.Close()
+		driver.Close()
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+`
+
+// EmbedSource returns the source of a Go file for a migration directory
+// that embeds its SQL files and applies them with golang-migrate. The
+// package is named after dir, the directory's base name.
+func EmbedSource(dir string) (string, error) {
+	src := gen.Header() + "\n" + strings.Replace(embedTemplate, "PACKAGE", packageName(dir), 1)
+
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", err
+	}
+
+	return string(formatted), nil
+}
+
+// packageName turns a directory name into a Go package name.
+func packageName(dir string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dir) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case b.Len() > 0:
+			b.WriteRune('_')
+		}
+	}
+
+	name := strings.Trim(b.String(), "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) || token.IsKeyword(name) {
+		name = "migrations" + name
+	}
+
+	return name
+}
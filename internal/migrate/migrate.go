@@ -0,0 +1,155 @@
+package migrate
+
+import (
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mymyka/tables/internal/diff"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Disclaimer heads every generated plan.
+const Disclaimer = `-- Generated by tables migrate plan. REVIEW BEFORE APPLYING.
+-- Only tables, columns, types, nullability and defaults are compared;
+-- constraints and indexes are not. Renames not listed under renames in the
+-- config appear as a drop plus an add, which loses data. Type changes may
+-- need a hand-written USING clause.
+`
+
+// Statements returns the SQL statements that apply a diff, in the order
+// renames, creates, alters, drops.
+func Statements(r diff.Result) []string {
+	var stmts []string
+
+	for _, t := range r.RenamedTables {
+		table := qualify(t.FromSchema, t.FromName)
+		if t.Schema != t.FromSchema {
+			stmts = append(stmts, "ALTER TABLE "+table+" SET SCHEMA "+pq.QuoteIdentifier(t.Schema)+";")
+			table = qualify(t.Schema, t.FromName)
+		}
+		if t.Name != t.FromName {
+			stmts = append(stmts, "ALTER TABLE "+table+" RENAME TO "+pq.QuoteIdentifier(t.Name)+";")
+		}
+	}
+
+	for _, t := range r.AddedTables {
+		stmts = append(stmts, createTable(t))
+	}
+
+	for _, t := range r.RenamedTables {
+		stmts = append(stmts, alterTable(t.TableChange)...)
+	}
+	for _, t := range r.ChangedTables {
+		stmts = append(stmts, alterTable(t)...)
+	}
+
+	for _, t := range r.RemovedTables {
+		stmts = append(stmts, "DROP TABLE "+qualify(t.Schema, t.Name)+";")
+	}
+
+	return stmts
+}
+
+// alterTable returns the statements applying the column changes of a table.
+func alterTable(t diff.TableChange) []string {
+	var stmts []string
+	table := qualify(t.Schema, t.Name)
+
+	for _, c := range t.AddedColumns {
+		stmts = append(stmts, "ALTER TABLE "+table+" ADD COLUMN "+columnDefinition(c)+";")
+	}
+
+	for _, c := range t.ChangedColumns {
+		name := pq.QuoteIdentifier(c.Name)
+
+		if c.From.Type != c.To.Type {
+			stmts = append(stmts, typeComment(c.To)+"ALTER TABLE "+table+" ALTER COLUMN "+name+" TYPE "+c.To.Type+" USING "+name+"::"+c.To.Type+";")
+		}
+
+		if c.From.Default != c.To.Default {
+			if c.To.Default == "" {
+				stmts = append(stmts, "ALTER TABLE "+table+" ALTER COLUMN "+name+" DROP DEFAULT;")
+			} else {
+				stmts = append(stmts, "ALTER TABLE "+table+" ALTER COLUMN "+name+" SET DEFAULT "+c.To.Default+";")
+			}
+		}
+
+		if c.From.Nullable && !c.To.Nullable {
+			stmts = append(stmts, "ALTER TABLE "+table+" ALTER COLUMN "+name+" SET NOT NULL;")
+		} else if !c.From.Nullable && c.To.Nullable {
+			stmts = append(stmts, "ALTER TABLE "+table+" ALTER COLUMN "+name+" DROP NOT NULL;")
+		}
+	}
+
+	for _, c := range t.RemovedColumns {
+		stmts = append(stmts, "ALTER TABLE "+table+" DROP COLUMN "+pq.QuoteIdentifier(c.Name)+";")
+	}
+
+	return stmts
+}
+
+// Render joins statements into a SQL script headed by the disclaimer.
+func Render(stmts []string) string {
+	var b strings.Builder
+
+	b.WriteString(Disclaimer + "\n")
+	if len(stmts) == 0 {
+		b.WriteString("-- No changes.\n")
+	}
+	for _, stmt := range stmts {
+		b.WriteString(stmt + "\n\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func createTable(t schema.Table) string {
+	var b strings.Builder
+
+	for _, c := range t.Columns {
+		b.WriteString(typeComment(c))
+	}
+
+	b.WriteString("CREATE TABLE " + qualify(t.Schema, t.Name) + " (\n")
+	for i, c := range t.Columns {
+		b.WriteString("    " + columnDefinition(c))
+		if i < len(t.Columns)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(");")
+
+	return b.String()
+}
+
+func columnDefinition(c schema.Column) string {
+	def := pq.QuoteIdentifier(c.Name) + " " + c.Type
+	if c.Default != "" {
+		def += " DEFAULT " + c.Default
+	}
+	if !c.Nullable {
+		def += " NOT NULL"
+	}
+
+	return def
+}
+
+// typeComment flags types information_schema reports only generically, which
+// need the real type filled in by hand.
+func typeComment(c schema.Column) string {
+	switch c.Type {
+	case "ARRAY", "USER-DEFINED":
+		return "-- TODO: column " + c.Name + " has type " + c.Type + "; replace it with the actual type.\n"
+	}
+
+	return ""
+}
+
+func qualify(schemaName, name string) string {
+	if schemaName == "" {
+		return pq.QuoteIdentifier(name)
+	}
+
+	return pq.QuoteIdentifier(schemaName) + "." + pq.QuoteIdentifier(name)
+}
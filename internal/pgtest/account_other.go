@@ -0,0 +1,25 @@
+//go:build !unix
+
+package pgtest
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// account is a user the server runs as, instead of the one running the
+// tests, and dir the directory of its own its commands run in.
+type account struct {
+	uid, gid int
+	dir      string
+}
+
+// serverAccount reports that the server cannot run as another user here.
+func serverAccount() (*account, error) {
+	return nil, errors.New("running as another user is not supported on this system")
+}
+
+// command returns a command running as the current user.
+func command(owner *account, name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package pgtest
+
+import (
+	"errors"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// account is a user the server runs as, instead of the one running the
+// tests, and dir the directory of its own its commands run in.
+type account struct {
+	uid, gid int
+	dir      string
+}
+
+// serverAccount returns the postgres user, or nobody when there is none.
+func serverAccount() (*account, error) {
+	for _, name := range []string{"postgres", "nobody"} {
+		u, err := user.Lookup(name)
+		if err != nil {
+			continue
+		}
+		uid, err1 := strconv.Atoi(u.Uid)
+		gid, err2 := strconv.Atoi(u.Gid)
+		if err1 == nil && err2 == nil {
+			return &account{uid: uid, gid: gid}, nil
+		}
+	}
+
+	return nil, errors.New("no postgres or nobody user")
+}
+
+// command returns a command running as owner, or as the current user when
+// owner is nil.
+func command(owner *account, name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if owner != nil {
+		cmd.Dir = owner.dir
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: uint32(owner.uid), Gid: uint32(owner.gid)}}
+	}
+
+	return cmd
+}
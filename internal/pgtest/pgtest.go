@@ -0,0 +1,91 @@
+// Package pgtest runs a throwaway PostgreSQL server for integration tests,
+// from the initdb and pg_ctl binaries installed on the machine.
+package pgtest
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Start returns the URL of a database for tb to create its schema in. It
+// uses the database at TABLES_TEST_DATABASE_URL when that is set, and
+// otherwise initializes a server in a temporary directory, listening on a
+// Unix socket only, that is stopped and removed when tb ends. The binaries
+// are looked up in TABLES_TEST_PGBIN, on the PATH and in the usual install
+// directories; tb is skipped when there are none. PostgreSQL refuses to run
+// as root, so as root the server runs as the postgres or nobody user.
+func Start(tb testing.TB) string {
+	tb.Helper()
+
+	if dsn := os.Getenv("TABLES_TEST_DATABASE_URL"); dsn != "" {
+		return dsn
+	}
+
+	bin, ok := binDir()
+	if !ok {
+		tb.Skip("PostgreSQL not found: install initdb and pg_ctl, set TABLES_TEST_PGBIN or TABLES_TEST_DATABASE_URL")
+	}
+
+	// Socket paths are limited to about 100 bytes, which test directories
+	// can exceed, so the server gets a short directory of its own
+	dir, err := os.MkdirTemp("", "pgtest-")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+
+	var owner *account
+	if os.Geteuid() == 0 {
+		if owner, err = serverAccount(); err != nil {
+			tb.Skipf("running as root without a user to run PostgreSQL as: %v", err)
+		}
+		owner.dir = dir
+		if err := os.Chown(dir, owner.uid, owner.gid); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	data := filepath.Join(dir, "data")
+	run(tb, owner, filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	run(tb, owner, filepath.Join(bin, "pg_ctl"), "start", "-D", data, "-w", "-l", filepath.Join(dir, "server.log"),
+		"-o", fmt.Sprintf("-c listen_addresses='' -k %s -F", dir))
+	tb.Cleanup(func() {
+		command(owner, filepath.Join(bin, "pg_ctl"), "stop", "-D", data, "-m", "immediate", "-w").Run()
+	})
+
+	return "postgres:///postgres?" + url.Values{"host": {dir}, "user": {"postgres"}, "sslmode": {"disable"}}.Encode()
+}
+
+// binDir returns the directory holding initdb and pg_ctl.
+func binDir() (string, bool) {
+	if dir := os.Getenv("TABLES_TEST_PGBIN"); dir != "" {
+		return dir, true
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path), true
+	}
+
+	// Debian and Ubuntu keep them out of the PATH, one directory per
+	// version
+	dirs, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "initdb")); err == nil {
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// run runs a command as owner, failing tb with its output when it fails.
+func run(tb testing.TB, owner *account, name string, args ...string) {
+	tb.Helper()
+
+	if out, err := command(owner, name, args...).CombinedOutput(); err != nil {
+		tb.Fatalf("%s: %v\n%s", filepath.Base(name), err, out)
+	}
+}
@@ -0,0 +1,160 @@
+// Package redact keeps database passwords out of logs and error messages.
+package redact
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted password.
+const Mask = "xxxxx"
+
+// minSecret is the length below which registered secrets are not masked on
+// their own, as they would mangle unrelated text; they are still masked in
+// connection strings.
+const minSecret = 4
+
+var (
+	// urlPasswordRe matches the password of a URL connection string.
+	urlPasswordRe = regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]*@`)
+
+	// keywordPasswordRe matches the password of a key=value connection
+	// string, quoted or not.
+	keywordPasswordRe = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s'"]+)`)
+)
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Register adds secrets, such as a password read from a file, that String
+// removes wherever they appear.
+func Register(s ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, secret := range s {
+		if len(secret) >= minSecret {
+			secrets = append(secrets, secret)
+		}
+	}
+}
+
+// String masks the passwords of the connection strings in s and every
+// registered secret.
+func String(s string) string {
+	s = urlPasswordRe.ReplaceAllString(s, "${1}"+Mask+"@")
+	s = keywordPasswordRe.ReplaceAllString(s, "${1}"+Mask)
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+
+	return s
+}
+
+// Password returns the password of a URL or key=value connection string,
+// or "" when it has none.
+func Password(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if u.User == nil {
+			return ""
+		}
+		password, _ := u.User.Password()
+		return password
+	}
+
+	m := keywordPasswordRe.FindStringSubmatch(dsn)
+	if m == nil {
+		return ""
+	}
+	value := m[2]
+	if strings.HasPrefix(value, "'") {
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "'"), "'")
+		value = strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(value)
+	}
+
+	return value
+}
+
+// WithPassword returns the connection string dsn with its password set to
+// password, replacing any it has.
+func WithPassword(dsn, password string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		username := ""
+		if u.User != nil {
+			username = u.User.Username()
+		}
+		u.User = url.UserPassword(username, password)
+		return u.String()
+	}
+
+	quoted := "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password) + "'"
+	if keywordPasswordRe.MatchString(dsn) {
+		return keywordPasswordRe.ReplaceAllLiteralString(dsn, "password="+quoted)
+	}
+
+	return strings.TrimSpace(dsn + " password=" + quoted)
+}
+
+// Handler wraps a slog.Handler, applying String to the message and to the
+// string and error values of every attribute.
+func Handler(h slog.Handler) slog.Handler {
+	return handler{h}
+}
+
+type handler struct {
+	slog.Handler
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, String(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(attr(a))
+		return true
+	})
+
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = attr(a)
+	}
+
+	return handler{h.Handler.WithAttrs(redacted)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{h.Handler.WithGroup(name)}
+}
+
+// attr redacts the value of a, and of the attributes of a group.
+func attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, String(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, g := range group {
+			redacted[i] = attr(g)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, String(err.Error()))
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,203 @@
+// Package secrets reads the database password from a secrets backend: an
+// external command, HashiCorp Vault or AWS Secrets Manager.
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultField is the key of the password in secrets holding several
+// values.
+const DefaultField = "password"
+
+// timeout bounds every request to a backend.
+const timeout = 30 * time.Second
+
+// Command runs command with the shell and returns its output without
+// trailing newlines, e.g. for `op read op://prod/db/password`.
+func Command(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	}
+
+	out, err := run(cmd)
+	if err != nil {
+		return "", fmt.Errorf("password command failed: %w", err)
+	}
+
+	return strings.TrimRight(out, "\r\n"), nil
+}
+
+// Vault is a HashiCorp Vault KV secret.
+type Vault struct {
+	// Address is the Vault server, VAULT_ADDR when empty.
+	Address string
+
+	// Path is the API path of the secret under /v1, such as
+	// secret/data/db for version 2 of the KV engine.
+	Path string
+
+	// Field is the key of the password in the secret, DefaultField when
+	// empty.
+	Field string
+}
+
+// Read reads the password from Vault, authenticating with VAULT_TOKEN or
+// the token the vault CLI saved in ~/.vault-token, within the namespace of
+// VAULT_NAMESPACE if set.
+func (v Vault) Read() (string, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", fmt.Errorf("vault: no address, set vault.address or VAULT_ADDR")
+	}
+	if v.Path == "" {
+		return "", fmt.Errorf("vault: no secret path")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			data, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token == "" {
+		return "", fmt.Errorf("vault: no token, set VAULT_TOKEN or log in with the vault CLI")
+	}
+
+	u, err := url.JoinPath(address, "v1", v.Path)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []string                   `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("vault: invalid response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: reading %s: %s %s", v.Path, resp.Status, strings.Join(body.Errors, "; "))
+	}
+
+	// Version 2 of the KV engine nests the values under data.data
+	data := body.Data
+	if nested, ok := data["data"]; ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			if err := json.Unmarshal(nested, &data); err != nil {
+				return "", fmt.Errorf("vault: invalid secret %s: %w", v.Path, err)
+			}
+		}
+	}
+
+	field := v.Field
+	if field == "" {
+		field = DefaultField
+	}
+	var password string
+	if err := json.Unmarshal(data[field], &password); err != nil {
+		return "", fmt.Errorf("vault: secret %s has no string field %q", v.Path, field)
+	}
+
+	return password, nil
+}
+
+// AWS is a secret of AWS Secrets Manager.
+type AWS struct {
+	// SecretID is the name or ARN of the secret.
+	SecretID string
+
+	// Region is the region of the secret, the default of the AWS CLI when
+	// empty.
+	Region string
+
+	// Field is the key of the password when the secret is a JSON object,
+	// as for RDS credentials; DefaultField when empty. A secret that is not
+	// a JSON object is the password itself.
+	Field string
+}
+
+// Read reads the password with the AWS CLI, so every way it finds
+// credentials works: environment, profiles, SSO and instance roles.
+func (a AWS) Read() (string, error) {
+	if a.SecretID == "" {
+		return "", fmt.Errorf("aws secrets manager: no secret id")
+	}
+
+	args := []string{"secretsmanager", "get-secret-value", "--secret-id", a.SecretID, "--query", "SecretString", "--output", "text"}
+	if a.Region != "" {
+		args = append(args, "--region", a.Region)
+	}
+
+	out, err := run(exec.Command("aws", args...))
+	if err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	secret := strings.TrimRight(out, "\r\n")
+
+	var values map[string]any
+	if json.Unmarshal([]byte(secret), &values) != nil {
+		if a.Field != "" {
+			return "", fmt.Errorf("aws secrets manager: secret %s is not a JSON object with field %q", a.SecretID, a.Field)
+		}
+		return secret, nil
+	}
+
+	field := a.Field
+	if field == "" {
+		field = DefaultField
+	}
+	password, ok := values[field].(string)
+	if !ok {
+		return "", fmt.Errorf("aws secrets manager: secret %s has no string field %q", a.SecretID, field)
+	}
+
+	return password, nil
+}
+
+// run runs cmd and returns its output, or an error with what it wrote to
+// stderr.
+func run(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,43 @@
+// Package snapshot saves and loads introspected schemas as
+// schema.Document files, usable in place of a live database by check and
+// diff.
+package snapshot
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+func Save(path string, tables []schema.Table) error {
+	data, err := Marshal(tables)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Marshal encodes tables in the snapshot format.
+func Marshal(tables []schema.Table) ([]byte, error) {
+	return schema.Marshal(tables)
+}
+
+func Load(path string) ([]schema.Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	tables, err := schema.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	return tables, nil
+}
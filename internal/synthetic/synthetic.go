@@ -0,0 +1,32 @@
+// Package synthetic builds schemas of any size without a database, for
+// measuring generation speed.
+package synthetic
+
+import (
+	"fmt"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// types are cycled through by the columns of a schema, so every kind of
+// mapping and import is exercised.
+var types = []string{"integer", "text", "uuid", "timestamp with time zone", "numeric", "jsonb", "boolean", "bigint", "bytea", "date"}
+
+// Tables returns a deterministic schema of n tables with columns columns
+// each.
+func Tables(n, columns int) []schema.Table {
+	tables := make([]schema.Table, n)
+	for i := range tables {
+		t := schema.Table{Schema: "public", Name: fmt.Sprintf("table_%05d", i)}
+		for j := range columns {
+			t.Columns = append(t.Columns, schema.Column{
+				Name:     fmt.Sprintf("column_%02d", j),
+				Type:     types[(i+j)%len(types)],
+				Nullable: j%3 == 2,
+			})
+		}
+		tables[i] = t
+	}
+
+	return tables
+}
@@ -0,0 +1,298 @@
+// Package telemetry traces the phases of a run, such as introspection and
+// generation, and exports them to an OpenTelemetry collector with OTLP over
+// HTTP in its JSON encoding. Until Enable is called every span is a no-op.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mymyka/tables/internal/redact"
+)
+
+// scope names the instrumentation in exported spans.
+const scope = "github.com/mymyka/tables"
+
+// OTLP span kind and status codes.
+const (
+	kindInternal = 1
+	statusOK     = 1
+	statusError  = 2
+)
+
+// Span is a timed phase of a run. Spans nest: a span started while another
+// is open is its child. A nil Span, as Start returns while tracing is off,
+// does nothing.
+type Span struct {
+	name     string
+	spanID   string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    []any
+	err      error
+	parent   *Span
+}
+
+var (
+	mu       sync.Mutex
+	enabled  bool
+	endpoint string
+	headers  map[string]string
+	resource []any
+	traceID  string
+	parentID string
+	current  *Span
+	ended    []*Span
+)
+
+// Config configures the export.
+type Config struct {
+	// Endpoint is the base URL of the collector, e.g.
+	// http://localhost:4318, to which /v1/traces is appended. When empty,
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is used as it is, or
+	// OTEL_EXPORTER_OTLP_ENDPOINT with /v1/traces appended.
+	Endpoint string
+
+	// Service and Version describe the traced program in the resource of
+	// its spans. OTEL_SERVICE_NAME overrides Service.
+	Service string
+	Version string
+}
+
+// Enable starts tracing and reports whether an endpoint is configured;
+// without one nothing is traced. OTEL_EXPORTER_OTLP_HEADERS adds headers
+// to every export, such as an API key, and OTEL_RESOURCE_ATTRIBUTES
+// attributes to the resource, such as the repository generating. A W3C
+// TRACEPARENT in the environment, as CI tools set, makes the run part of
+// that trace.
+func Enable(cfg Config) (bool, error) {
+	target := cfg.Endpoint
+	switch {
+	case target != "":
+		target = strings.TrimSuffix(target, "/") + "/v1/traces"
+	case os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "":
+		target = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	case os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "":
+		target = strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/") + "/v1/traces"
+	default:
+		return false, nil
+	}
+	if u, err := url.Parse(target); err != nil || u.Scheme == "" || u.Host == "" {
+		return false, fmt.Errorf("invalid OTLP endpoint %q, expected a URL such as http://localhost:4318", target)
+	}
+
+	service := cfg.Service
+	if s := os.Getenv("OTEL_SERVICE_NAME"); s != "" {
+		service = s
+	}
+	attrs := []any{"service.name", service, "service.version", cfg.Version}
+	for k, v := range pairs(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")) {
+		attrs = append(attrs, k, v)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	enabled = true
+	endpoint = target
+	headers = pairs(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	resource = attrs
+	traceID = randomID(16)
+	if parts := strings.Split(os.Getenv("TRACEPARENT"), "-"); len(parts) == 4 && len(parts[1]) == 32 && len(parts[2]) == 16 {
+		traceID, parentID = parts[1], parts[2]
+	}
+
+	return true, nil
+}
+
+// Start opens a span named name, a child of the span open, with attributes
+// given as alternating keys and values like slog.
+func Start(name string, attrs ...any) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !enabled {
+		return nil
+	}
+
+	s := &Span{name: name, spanID: randomID(8), parentID: parentID, start: time.Now(), attrs: attrs, parent: current}
+	if current != nil {
+		s.parentID = current.spanID
+	}
+	current = s
+
+	return s
+}
+
+// Set adds attributes to the span.
+func (s *Span) Set(attrs ...any) {
+	if s == nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End closes the span, failed when err is not nil, and makes its parent
+// the open span again. Ending a span twice keeps the first end.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+	ended = append(ended, s)
+	if current == s {
+		current = s.parent
+	}
+}
+
+// Flush exports the ended spans.
+func Flush(ctx context.Context) error {
+	mu.Lock()
+	spans := ended
+	ended = nil
+	target, hdrs, res := endpoint, headers, resource
+	mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(export(spans, res))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hdrs {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export spans: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// export builds the OTLP ExportTraceServiceRequest of spans.
+func export(spans []*Span, res []any) map[string]any {
+	encoded := make([]map[string]any, len(spans))
+	for i, s := range spans {
+		span := map[string]any{
+			"traceId":           traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              kindInternal,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        attributes(s.attrs),
+			"status":            map[string]any{"code": statusOK},
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			span["status"] = map[string]any{"code": statusError, "message": redact.String(s.err.Error())}
+		}
+		encoded[i] = span
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": attributes(res)},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": scope},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+// attributes encodes alternating keys and values as OTLP attributes.
+func attributes(kv []any) []map[string]any {
+	var attrs []map[string]any
+	for i := 0; i+1 < len(kv); i += 2 {
+		var value map[string]any
+		switch v := kv[i+1].(type) {
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		case []string:
+			values := make([]any, len(v))
+			for j, s := range v {
+				values[j] = map[string]any{"stringValue": s}
+			}
+			value = map[string]any{"arrayValue": map[string]any{"values": values}}
+		default:
+			value = map[string]any{"stringValue": redact.String(fmt.Sprint(v))}
+		}
+		attrs = append(attrs, map[string]any{"key": fmt.Sprint(kv[i]), "value": value})
+	}
+
+	return attrs
+}
+
+// pairs parses the comma-separated, URL-encoded key=value pairs of the
+// OTEL_ environment variables.
+func pairs(s string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		m[strings.TrimSpace(k)] = v
+	}
+
+	return m
+}
+
+// randomID returns n random bytes in hex, the form of trace and span IDs.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,55 @@
+package version
+
+import (
+	"runtime/debug"
+)
+
+// Build metadata, set at link time:
+//
+//	go build -ldflags "-X github.com/mymyka/tables/internal/version.Version=v1.2.3 \
+//	  -X github.com/mymyka/tables/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/mymyka/tables/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Binaries built by `go install ...@version` fall back to the module version
+// and VCS information embedded by the go command.
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// Dialects lists the databases the generator can introspect.
+var Dialects = []string{"postgres"}
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	if Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		Version = info.Main.Version
+	}
+
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if Commit == "" {
+				Commit = s.Value
+			}
+		case "vcs.time":
+			if Date == "" {
+				Date = s.Value
+			}
+		}
+	}
+}
+
+// String returns the version, or "dev" for untagged builds.
+func String() string {
+	if Version == "" {
+		return "dev"
+	}
+
+	return Version
+}
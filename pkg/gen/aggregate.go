@@ -0,0 +1,171 @@
+package gen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// aggregatePattern matches an entry of Options.Aggregates, such as
+// "sum(amount) by status, region".
+var aggregatePattern = regexp.MustCompile(`(?i)^\s*(\w+)\s*\(\s*([^()\s]+)\s*\)\s*(?:by\s+(.+?))?\s*$`)
+
+// integerTypes are the PostgreSQL integer types, whose sum is an integer
+// and whose average is not.
+var integerTypes = map[string]bool{
+	"smallint": true, "int2": true,
+	"integer": true, "int": true, "int4": true,
+	"bigint": true, "int8": true,
+	"smallserial": true, "serial2": true,
+	"serial": true, "serial4": true,
+	"bigserial": true, "serial8": true,
+}
+
+// floatTypes are the PostgreSQL floating point types.
+var floatTypes = map[string]bool{
+	"real": true, "float4": true,
+	"double precision": true, "float8": true,
+}
+
+// AggregateData describes the function of an entry of Options.Aggregates.
+type AggregateData struct {
+	// GoName names the function, e.g. SumAmountByStatus, and Name the
+	// field of its value in the rows of grouped aggregates, e.g. SumAmount.
+	GoName string
+	Name   string
+
+	// Expr is the aggregate as the entry writes it, e.g. sum(amount).
+	Expr string
+
+	// Type is the Go type of the value, a pointer unless the function is
+	// count, as the other functions are NULL over no rows.
+	Type string
+
+	// GroupBy lists the columns the rows are grouped by, if any.
+	GroupBy []ColumnData
+
+	// Query selects the columns of GroupBy and the value, ordered by
+	// GroupBy.
+	Query string
+}
+
+// aggregates returns the aggregates of the entry of Options.Aggregates for
+// t: "function(column)", or "count(*)", optionally followed by "by" and
+// the columns to group by separated by commas. The functions are count,
+// sum, avg, min and max. Entries naming other columns are ignored, as
+// entries keyed by name apply to the tables of that name in every schema.
+func aggregates(t schema.Table, columns []ColumnData, opts Options) ([]AggregateData, error) {
+	entry, ok := opts.Aggregates[t.Schema+"."+t.Name]
+	if !ok {
+		entry = opts.Aggregates[t.Name]
+	}
+
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+
+	byName := make(map[string]ColumnData, len(columns))
+	for _, c := range columns {
+		byName[c.Name] = c
+	}
+
+	var result []AggregateData
+	seen := make(map[string]bool)
+entries:
+	for _, item := range entry {
+		m := aggregatePattern.FindStringSubmatch(item)
+		if m == nil {
+			return nil, fmt.Errorf("invalid aggregate %q, expected e.g. sum(amount) or count(*) by status", item)
+		}
+		fn, arg := strings.ToLower(m[1]), m[2]
+
+		var a AggregateData
+		var groups []string
+		if m[3] != "" {
+			for _, name := range strings.Split(m[3], ",") {
+				c, ok := byName[strings.TrimSpace(name)]
+				if !ok {
+					continue entries
+				}
+				a.GroupBy = append(a.GroupBy, c)
+				groups = append(groups, quote(c.Name))
+			}
+		}
+
+		value := fn + "(*)"
+		a.Expr = value
+		if arg == "*" {
+			if fn != "count" {
+				return nil, fmt.Errorf("invalid aggregate %q, only count takes *", item)
+			}
+			a.Name, a.Type = "CountRows", "int64"
+		} else {
+			c, ok := byName[arg]
+			if !ok {
+				continue
+			}
+			value = fn + "(" + quote(c.Name) + ")"
+			a.Expr = fn + "(" + c.Name + ")"
+			a.Name = identifierName(fn) + c.GoName
+
+			var err error
+			if a.Type, err = aggregateType(fn, c); err != nil {
+				return nil, fmt.Errorf("invalid aggregate %q: %w", item, err)
+			}
+		}
+
+		a.GoName = a.Name
+		if len(a.GroupBy) > 0 {
+			var goNames []string
+			for _, c := range a.GroupBy {
+				goNames = append(goNames, c.GoName)
+			}
+			a.GoName += "By" + strings.Join(goNames, "And")
+		}
+		if seen[a.GoName] {
+			continue
+		}
+		seen[a.GoName] = true
+
+		a.Query = "SELECT " + strings.Join(append(groups, value), ", ") + " FROM " + sqlName(t, opts)
+		if len(groups) > 0 {
+			a.Query += " GROUP BY " + strings.Join(groups, ", ") + " ORDER BY " + strings.Join(groups, ", ")
+		}
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+// aggregateType returns the Go type of the value of fn over c: the type of
+// the column for min and max, and for the sum and average of other numeric
+// types than integers and floats, whose sums are int64 and whose averages
+// are float64.
+func aggregateType(fn string, c ColumnData) (string, error) {
+	pgType := normalizeType(c.Type)
+	numeric := !strings.HasSuffix(pgType, "[]") && (integerTypes[pgType] || floatTypes[pgType] ||
+		pgType == "numeric" || pgType == "decimal" || pgType == "money" || pgType == "interval")
+
+	switch fn {
+	case "count":
+		return "int64", nil
+	case "min", "max":
+		return "*" + c.ValueType, nil
+	case "sum", "avg":
+		if !numeric {
+			return "", fmt.Errorf("column %s of type %s is not numeric", c.Name, c.Type)
+		}
+	default:
+		return "", fmt.Errorf("unknown function %s, expected count, sum, avg, min or max", fn)
+	}
+
+	switch {
+	case fn == "sum" && integerTypes[pgType]:
+		return "*int64", nil
+	case integerTypes[pgType] || floatTypes[pgType]:
+		return "*float64", nil
+	}
+	return "*" + c.ValueType, nil
+}
@@ -0,0 +1,27 @@
+package gen_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mymyka/tables/internal/synthetic"
+	"github.com/mymyka/tables/pkg/gen"
+)
+
+// BenchmarkBuild measures Build on synthetic schemas, reporting tables per
+// second. The wide schema catches work growing faster than the number of
+// columns of a table.
+func BenchmarkBuild(b *testing.B) {
+	for _, size := range []struct{ tables, columns int }{{100, 20}, {10, 200}} {
+		b.Run(fmt.Sprintf("tables=%d/columns=%d", size.tables, size.columns), func(b *testing.B) {
+			tables := synthetic.Tables(size.tables, size.columns)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := gen.Build(tables, gen.Options{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(size.tables*b.N)/b.Elapsed().Seconds(), "tables/s")
+		})
+	}
+}
@@ -0,0 +1,539 @@
+// Package gen generates a Go package per introspected table.
+package gen
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+
+	"github.com/mymyka/tables/internal/version"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Header marks a file as owned by the generator and records the tables
+// version that produced it. Pruning only removes files with it, and never
+// a <table>_ext.go extension; the file at the path of a generated one is
+// overwritten whether it has it or not.
+func Header() string {
+	return "// Code generated by tables " + version.String() + ". DO NOT EDIT.\n"
+}
+
+// Build renders the package of every table with the templates, keyed by
+// package path. Rendered code is parsed and printed with go/format, so the
+// result is valid, gofmt-formatted Go with exactly the imports it uses.
+func Build(tables []schema.Table, opts Options) (map[string]string, error) {
+	tmpl, err := loadTemplates(opts.Templates)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFieldOrder(opts.FieldOrder); err != nil {
+		return nil, err
+	}
+	if err := checkGoVersion(opts.GoVersion); err != nil {
+		return nil, err
+	}
+	if err := checkBuildTags(opts.BuildTags); err != nil {
+		return nil, err
+	}
+	if err := checkNolint(opts.Nolint); err != nil {
+		return nil, err
+	}
+	if _, err := placeholderStyle(opts.Placeholder); err != nil {
+		return nil, err
+	}
+	if opts.WherePackage != "" && opts.ModulePath == "" {
+		return nil, fmt.Errorf("table packages import the where package, set the module path of the output directory")
+	}
+
+	result := make(map[string]string)
+	tables = applyHooks(tables, opts)
+
+	// Tenant schemas sharing another's packages are left out of everything
+	var shares map[string]string
+	if opts.TenantPackage != "" {
+		shares = tenantSchemas(tables)
+		tables = dedupeTenants(tables, shares)
+		opts.shares = shares
+	}
+
+	packages, packageImports := tablePackages(tables, opts)
+	histories, err := historyTables(tables, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tables are independent, so with several workers each builds its own
+	// packages and only the result map is shared
+	var mu sync.Mutex
+	var firstErr error
+	jobs := make(chan schema.Table)
+	var wg sync.WaitGroup
+
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				pkg, block, err := buildPackage(tmpl, t, opts, packages, packageImports, histories[t.Schema+"."+t.Name])
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if pkg != "" {
+					result[pkg] = block
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, t := range tables {
+		if opts.Only == nil || opts.Only(t) {
+			jobs <- t
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if opts.NoExtraPackages {
+		return result, nil
+	}
+
+	if opts.OrderPackage != "" {
+		src, err := buildOrder(tmpl, tables, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.OrderPackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.ResetPackage != "" {
+		src, err := buildReset(tmpl, tables, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.ResetPackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.FixturesPackage != "" {
+		src, err := buildLoader(tmpl, tables, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.FixturesPackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.StmtCachePackage != "" {
+		src, err := buildStmtCache(tmpl, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.StmtCachePackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.ReplicaPackage != "" {
+		src, err := buildReplica(tmpl, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.ReplicaPackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.RetryPackage != "" {
+		src, err := buildRetry(tmpl, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.RetryPackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.WherePackage != "" {
+		src, err := buildWhere(tmpl, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.WherePackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.TenantPackage != "" {
+		src, err := buildTenants(tmpl, shares, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPackage(result, opts.TenantPackage, src); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// addPackage adds a package that is not a table's to the result of Build.
+func addPackage(result map[string]string, pkg, src string) error {
+	if _, ok := result[pkg]; ok {
+		return fmt.Errorf("package %s collides with another generated package", pkg)
+	}
+
+	result[pkg] = src
+	return nil
+}
+
+// buildPackage returns the package path and source of a table, or an empty
+// path for a table with every feature turned off. packages and
+// packageImports are the other tables' packages from tablePackages, and
+// history is the table's history table from historyTables, if any.
+func buildPackage(tmpl *template.Template, t schema.Table, opts Options, packages map[string]string, packageImports []Import, history *HistoryData) (string, string, error) {
+	features, err := tableFeatures(t, opts)
+	if err != nil || len(features) == 0 {
+		return "", "", err
+	}
+
+	pkg := PackagePath(t, opts)
+	data := tableData(t, path.Base(pkg), features, opts)
+	data.Packages = packages
+	data.History = history
+	if data.ColumnTags, err = buildColumnTags(t, data.Columns, opts); err != nil {
+		return pkg, "", fmt.Errorf("table %s.%s: %w", t.Schema, t.Name, err)
+	}
+	if features[FeatureDTO] {
+		data.DTO = dtoFields(t, data, opts)
+	}
+	if features[FeatureRow] {
+		data.Converters = converters(t, &data, opts)
+	}
+	if features[FeatureSort] {
+		if data.Sort, err = sortColumns(t, data.Columns, opts); err != nil {
+			return pkg, "", fmt.Errorf("table %s.%s: %w", t.Schema, t.Name, err)
+		}
+	}
+	if features[FeatureFilter] {
+		data.Filter = filterFields(t, data.Fields, opts)
+	}
+	if features[FeatureKeys] {
+		data.Keys = keys(t, data.Columns, opts)
+	}
+	if data.Aggregates, err = aggregates(t, data.Columns, opts); err != nil {
+		return pkg, "", fmt.Errorf("table %s.%s: %w", t.Schema, t.Name, err)
+	}
+	if opts.Masking != nil {
+		data.Masking = true
+		if data.Masked, err = maskedColumns(t, data.Columns, opts); err != nil {
+			return pkg, "", fmt.Errorf("table %s.%s: %w", t.Schema, t.Name, err)
+		}
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "file.tmpl", data); err != nil {
+		return pkg, "", fmt.Errorf("failed to render table %s.%s: %w", t.Schema, t.Name, err)
+	}
+
+	// Other tables' packages come after the column types' ones, which
+	// take precedence on a name collision
+	imports := slices.Clone(data.Imports)
+	for _, imp := range packageImports {
+		if imp.Path != data.ImportPath {
+			imports = append(imports, imp)
+		}
+	}
+
+	src, err := emit(path.Base(pkg)+".go", block.String(), imports, opts)
+	if err != nil {
+		return pkg, "", fmt.Errorf("failed to render table %s.%s: %w", t.Schema, t.Name, err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(pkg, src)
+	}
+
+	return pkg, src, nil
+}
+
+// Unmapped describes a column whose PostgreSQL type has no known Go mapping
+// and was generated with the fallback type.
+type Unmapped struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Type   string `json:"type"`
+	GoType string `json:"go_type"`
+}
+
+// FindUnmapped lists the columns that fell through to the fallback type
+// mapping. Columns with a type override are never reported.
+func FindUnmapped(tables []schema.Table, opts Options) []Unmapped {
+	var unmapped []Unmapped
+
+	for _, t := range applyHooks(tables, opts) {
+		for _, c := range t.Columns {
+			if _, ok := typeOverride(t, c, opts); ok {
+				continue
+			}
+			if _, ok := enumType(c); ok {
+				continue
+			}
+
+			if goType, ok := postgresTypeToGoType(c.Type); !ok {
+				unmapped = append(unmapped, Unmapped{
+					Schema: t.Schema,
+					Table:  t.Name,
+					Column: c.Name,
+					Type:   c.Type,
+					GoType: goType,
+				})
+			}
+		}
+	}
+
+	return unmapped
+}
+
+// postgresTypeToGoType maps a PostgreSQL type to a Go type. The second result
+// is false when the type is unknown and fell back to a default mapping.
+func postgresTypeToGoType(pgType string) (string, bool) {
+	// Normalize the type (remove length specifications, etc.)
+	normalizedType := normalizeType(pgType)
+
+	switch normalizedType {
+	// Integer types
+	case "smallint", "int2":
+		return "int16", true
+	case "integer", "int", "int4":
+		return "int32", true
+	case "bigint", "int8":
+		return "int64", true
+	case "serial", "serial4":
+		return "int32", true
+	case "bigserial", "serial8":
+		return "int64", true
+	case "smallserial", "serial2":
+		return "int16", true
+
+	// Floating point types
+	case "real", "float4":
+		return "float32", true
+	case "double precision", "float8":
+		return "float64", true
+
+	// Decimal types
+	case "numeric", "decimal":
+		return "decimal.Decimal", true
+
+	// String types
+	case "character varying", "varchar":
+		return "string", true
+	case "character", "char":
+		return "string", true
+	case "text":
+		return "string", true
+
+	// Boolean type
+	case "boolean", "bool":
+		return "bool", true
+
+	// Date/Time types
+	case "timestamp", "timestamp with time zone", "timestamptz":
+		return "time.Time", true
+	case "timestamp without time zone":
+		return "time.Time", true
+	case "date":
+		return "time.Time", true
+	case "time", "time with time zone", "timetz":
+		return "time.Time", true
+	case "time without time zone":
+		return "time.Time", true
+	case "interval":
+		return "time.Duration", true
+
+	// UUID type
+	case "uuid":
+		return "uuid.UUID", true
+
+	// JSON types
+	case "json":
+		return "json.RawMessage", true
+	case "jsonb":
+		return "json.RawMessage", true
+
+	// Key/value type, generated into the package
+	case "hstore":
+		return "Hstore", true
+
+	// Binary types
+	case "bytea":
+		return "[]byte", true
+
+	// Network types, generated into the package
+	case "inet":
+		return "NetAddr", true
+	case "cidr":
+		return "NetPrefix", true
+	case "macaddr", "macaddr8":
+		return "HardwareAddr", true
+
+	// Geometric types, generated into the package
+	case "point":
+		return "Point", true
+	case "box":
+		return "Box", true
+	case "circle":
+		return "Circle", true
+	case "path":
+		return "Path", true
+	case "line":
+		return "string", true
+	case "lseg":
+		return "string", true
+	case "polygon":
+		return "string", true
+
+	// Range types
+	case "int4range":
+		return "string", true
+	case "int8range":
+		return "string", true
+	case "numrange":
+		return "string", true
+	case "tsrange":
+		return "string", true
+	case "tstzrange":
+		return "string", true
+	case "daterange":
+		return "string", true
+
+	// Multirange types, PostgreSQL 14 and later
+	case "int4multirange", "int8multirange", "nummultirange", "tsmultirange", "tstzmultirange", "datemultirange":
+		return "string", true
+
+	// Array types (basic handling)
+	case "text[]", "varchar[]", "character varying[]":
+		return "[]string", true
+	case "integer[]", "int4[]":
+		return "[]int32", true
+	case "bigint[]", "int8[]":
+		return "[]int64", true
+	case "smallint[]", "int2[]":
+		return "[]int16", true
+	case "boolean[]", "bool[]":
+		return "[]bool", true
+	case "real[]", "float4[]":
+		return "[]float32", true
+	case "double precision[]", "float8[]":
+		return "[]float64", true
+
+	// Money type, generated into the package
+	case "money":
+		return "Money", true
+
+	// Enum types (generic handling)
+	case "enum":
+		return "string", true
+
+	// XML type
+	case "xml":
+		return "string", true
+
+	// Bit string types, generated into the package
+	case "bit", "bit varying", "varbit":
+		return "BitString", true
+
+	// PostgreSQL specific types
+	case "tsvector":
+		return "string", true
+	case "tsquery":
+		return "string", true
+	case "pg_lsn":
+		return "string", true
+	case "pg_snapshot":
+		return "string", true
+	case "txid_snapshot":
+		return "string", true
+
+	// Default fallback
+	default:
+		// Multi-dimensional arrays nest the slice of one dimension less
+		if strings.HasSuffix(normalizedType, "[][]") {
+			goType, ok := postgresTypeToGoType(strings.TrimSuffix(normalizedType, "[]"))
+			return "[]" + goType, ok
+		}
+
+		// Handle array types that weren't caught above
+		if strings.HasSuffix(normalizedType, "[]") {
+			return "[]interface{}", false
+		}
+		// Unknown type, default to string
+		return "string", false
+	}
+}
+
+// buildTags renders the struct tags of a Row field with the given keys.
+func buildTags(c schema.Column, keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+
+	var tags []string
+	for _, key := range keys {
+		tags = append(tags, key+":\""+c.Name+"\"")
+	}
+
+	return " `" + strings.Join(tags, " ") + "`"
+}
+
+// Helper function to capitalize the first letter
+func capitalizeFirst(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+
+	// Convert first character to uppercase, keep rest as is
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// Helper function to convert snake_case to PascalCase
+func toPascalCase(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+
+	// Split into words and capitalize each part
+	parts := nameParts(s)
+	var result strings.Builder
+
+	for _, part := range parts {
+		result.WriteString(capitalizeFirst(part))
+	}
+
+	return result.String()
+}
+
+// nameParts splits a name into its words, separated by underscores and by
+// any other character a Go identifier cannot hold, such as spaces.
+func nameParts(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,49 @@
+package gen
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"strings"
+)
+
+// checkBuildTags reports an Options.BuildTags that is not a valid build
+// constraint expression.
+func checkBuildTags(tags string) error {
+	if tags == "" {
+		return nil
+	}
+	if _, err := constraint.Parse("//go:build " + tags); err != nil {
+		return fmt.Errorf("invalid build tags %q: %w", tags, err)
+	}
+
+	return nil
+}
+
+// buildConstraint returns the constraint lines of Options.BuildTags and the
+// blank line after them, or "" without tags. Go versions before 1.17 only
+// read the // +build form, so it is added for them.
+func buildConstraint(opts Options) (string, error) {
+	if opts.BuildTags == "" {
+		return "", nil
+	}
+
+	expr, err := constraint.Parse("//go:build " + opts.BuildTags)
+	if err != nil {
+		return "", fmt.Errorf("invalid build tags %q: %w", opts.BuildTags, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("//go:build " + expr.String() + "\n")
+	if !goAtLeast(opts, 17) {
+		lines, err := constraint.PlusBuildLines(expr)
+		if err != nil {
+			return "", fmt.Errorf("invalid build tags %q: %w", opts.BuildTags, err)
+		}
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String(), nil
+}
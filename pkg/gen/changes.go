@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// comparableTypes are the column types whose values are equal exactly when
+// == says so, keyed by import path and name for types of other packages.
+var comparableTypes = map[string]bool{
+	"bool":    true,
+	"int16":   true,
+	"int32":   true,
+	"int64":   true,
+	"float32": true,
+	"float64": true,
+	"string":  true,
+
+	"time.Duration":               true,
+	"github.com/google/uuid.UUID": true,
+
+	"BitString":  true,
+	"XML":        true,
+	"MoneyCents": true,
+	"NetAddr":    true,
+	"NetPrefix":  true,
+	"Point":      true,
+	"Box":        true,
+	"Circle":     true,
+}
+
+// differs maps the column types == does not compare correctly to the
+// expression Changes uses instead.
+var differs = map[string]string{
+	"time.Time":                             "!%[1]s.Equal(%[2]s)",
+	"github.com/shopspring/decimal.Decimal": "!%[1]s.Equal(%[2]s)",
+	"Money":                                 "!%[1]s.Decimal.Equal(%[2]s.Decimal)",
+	"JSONMap":                               "!%[1]s.Equal(%[2]s)",
+	"HardwareAddr":                          "!bytes.Equal(%[1]s.HardwareAddr, %[2]s.HardwareAddr)",
+	"[]byte":                                "!bytes.Equal(%[1]s, %[2]s)",
+	"encoding/json.RawMessage":              "!bytes.Equal(%[1]s, %[2]s)",
+}
+
+// differ returns the format of the Go expression reporting whether two
+// non-NULL values of a column differ, %[1]s and %[2]s being the values.
+// goType and importPath are the column's type as columnType returns it.
+// Types it knows nothing about are compared with reflect.DeepEqual, as are
+// slices before Go 1.21 and its slices package.
+func differ(c schema.Column, goType, importPath string, opts Options) string {
+	name := strings.TrimLeft(goType, "[]")
+	dims := (len(goType) - len(name)) / 2
+	if importPath != "" {
+		name = importPath + name[strings.LastIndex(name, "."):]
+	}
+	if goType == "[]byte" {
+		name, dims = goType, 0
+	}
+
+	_, enum := enumType(c)
+	equal := enum && importPath == "" || comparableTypes[name]
+
+	switch {
+	case dims == 0 && equal:
+		return "%[1]s != %[2]s"
+	case dims == 1 && equal && goAtLeast(opts, 21):
+		return "!slices.Equal(%[1]s, %[2]s)"
+	case dims == 0 && differs[name] != "":
+		return differs[name]
+	}
+
+	return "!reflect.DeepEqual(%[1]s, %[2]s)"
+}
@@ -0,0 +1,90 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// ColumnTagData is a column tag as generated for a table.
+type ColumnTagData struct {
+	// Name is the tag as configured, e.g. pii.
+	Name string
+
+	// GoName prefixes the generated slice, e.g. PII for PIIColumns.
+	GoName string
+
+	// Columns are the tagged columns of the table in table order.
+	Columns []ColumnData
+}
+
+// buildColumnTags returns every tag of Options.ColumnTags, sorted by name,
+// with the columns of the table it applies to.
+func buildColumnTags(t schema.Table, columns []ColumnData, opts Options) ([]ColumnTagData, error) {
+	names := make([]string, 0, len(opts.ColumnTags))
+	for name := range opts.ColumnTags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tags []ColumnTagData
+	for _, name := range names {
+		tag := ColumnTagData{Name: name, GoName: tagGoName(name, opts)}
+		switch tag.GoName {
+		case "":
+			return nil, fmt.Errorf("column tag %q has no usable Go name", name)
+		case "All":
+			return nil, fmt.Errorf("column tag %q collides with AllColumns", name)
+		}
+
+		for _, c := range columns {
+			if hasColumnTag(t, c.Column, opts.ColumnTags[name]) {
+				tag.Columns = append(tag.Columns, c)
+			}
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// hasColumnTag reports whether a column matches one of patterns, which are
+// path.Match patterns against table.column or schema.table.column.
+func hasColumnTag(t schema.Table, c schema.Column, patterns []string) bool {
+	for _, p := range patterns {
+		for _, name := range []string{t.Name + "." + c.Name, t.Schema + "." + t.Name + "." + c.Name} {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tagGoName turns a tag into an exported Go name, upper-casing configured
+// initialisms: pii becomes PII when it is one, and Pii otherwise.
+func tagGoName(tag string, opts Options) string {
+	parts := strings.FieldsFunc(tag, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, part := range parts {
+		if isInitialism(part, opts.Initialisms) {
+			b.WriteString(strings.ToUpper(part))
+		} else {
+			b.WriteString(capitalizeFirst(part))
+		}
+	}
+
+	name := b.String()
+	if name != "" && !unicode.IsLetter([]rune(name)[0]) {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,297 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Converter is another build of the same tables, such as the packages of
+// another output profile, whose Rows the Row of every table is converted
+// from and to.
+type Converter struct {
+	// Name names the other build, such as its profile: models generates
+	// FromModels and ToModels.
+	Name string
+
+	// Options are the options of the other build, which resolve the import
+	// path of its packages and the Go types of its columns. They need a
+	// ModulePath.
+	Options Options
+}
+
+// ConverterData describes the converting functions of a table from and to
+// the Row of a Converter.
+type ConverterData struct {
+	// GoName is the Converter name as a Go name, e.g. Models.
+	GoName string
+
+	// Package is the name the file imports the other package by, and
+	// Path its import path.
+	Package string
+	Path    string
+
+	// From and To are the statements converting the other Row r into the
+	// Row d and the Row r into the other Row d.
+	From string
+	To   string
+
+	// Skipped lists the columns without a conversion, with their types on
+	// both sides, e.g. "info (JSONMap, models.JSONMap)".
+	Skipped []string
+}
+
+// nullWrappers maps the types holding a nullable value next to a Valid flag
+// to the field and type of the value.
+var nullWrappers = map[string][2]string{
+	"database/sql.NullBool":    {"Bool", "bool"},
+	"database/sql.NullByte":    {"Byte", "byte"},
+	"database/sql.NullFloat64": {"Float64", "float64"},
+	"database/sql.NullInt16":   {"Int16", "int16"},
+	"database/sql.NullInt32":   {"Int32", "int32"},
+	"database/sql.NullInt64":   {"Int64", "int64"},
+	"database/sql.NullString":  {"String", "string"},
+	"database/sql.NullTime":    {"Time", "time.Time"},
+
+	"github.com/jackc/pgx/v5/pgtype.Bool":        {"Bool", "bool"},
+	"github.com/jackc/pgx/v5/pgtype.Date":        {"Time", "time.Time"},
+	"github.com/jackc/pgx/v5/pgtype.Float4":      {"Float32", "float32"},
+	"github.com/jackc/pgx/v5/pgtype.Float8":      {"Float64", "float64"},
+	"github.com/jackc/pgx/v5/pgtype.Int2":        {"Int16", "int16"},
+	"github.com/jackc/pgx/v5/pgtype.Int4":        {"Int32", "int32"},
+	"github.com/jackc/pgx/v5/pgtype.Int8":        {"Int64", "int64"},
+	"github.com/jackc/pgx/v5/pgtype.Text":        {"String", "string"},
+	"github.com/jackc/pgx/v5/pgtype.Timestamp":   {"Time", "time.Time"},
+	"github.com/jackc/pgx/v5/pgtype.Timestamptz": {"Time", "time.Time"},
+	"github.com/jackc/pgx/v5/pgtype.UUID":        {"Bytes", "[16]byte"},
+}
+
+// underlyingTypes maps the types converting into each other to a shared
+// underlying type: defined types generated into the packages to the type
+// they are defined as, or to themselves for structs, which convert between
+// the packages of two builds too.
+var underlyingTypes = map[string]string{
+	"github.com/google/uuid.UUID": "[16]byte",
+	"[16]byte":                    "[16]byte",
+	"string":                      "string",
+	"int64":                       "int64",
+	"map[string]*string":          "map[string]*string",
+
+	"BitString":    "string",
+	"XML":          "string",
+	"MoneyCents":   "int64",
+	"Hstore":       "map[string]*string",
+	"Money":        "Money",
+	"NetAddr":      "NetAddr",
+	"NetPrefix":    "NetPrefix",
+	"HardwareAddr": "HardwareAddr",
+	"Point":        "Point",
+	"Box":          "Box",
+	"Circle":       "Circle",
+}
+
+// pointFields maps the helper structs holding a Point to the fields of
+// their composite literal, converting every Point field by itself: the
+// structs embed the Point of their own package, so they do not convert as
+// a whole. Path, with its []Point, has no conversion.
+var pointFields = map[string]string{
+	"Box":    "High: %[1]s(%[2]s.High), Low: %[1]s(%[2]s.Low)",
+	"Circle": "Center: %[1]s(%[2]s.Center), Radius: %[2]s.Radius",
+}
+
+// convertSide is the type of a column in the Row of one build.
+type convertSide struct {
+	// goType is the type as the file writes it, without the pointer of
+	// nullable columns.
+	goType string
+
+	// pointer is set for nullable columns, and wrapper for a type of
+	// nullWrappers, with the field and type of its value.
+	pointer bool
+	wrapper *[2]string
+
+	// base is the type of the value, and underlying its key in
+	// underlyingTypes, or empty.
+	base       string
+	underlying string
+}
+
+// converters returns the functions converting the Row of t from and to the
+// Rows of Options.Converters that generate one for t. It adds the imports
+// they need to data.
+func converters(t schema.Table, data *TableData, opts Options) []ConverterData {
+	taken := make(map[string]bool, len(data.Imports))
+	for _, imp := range data.Imports {
+		taken[imp.Name] = true
+	}
+	imported := func(importPath, name string) string {
+		for _, imp := range data.Imports {
+			if imp.Path == importPath {
+				return imp.Name
+			}
+		}
+		if taken[name] {
+			name = aliasName(importPath, taken)
+		}
+		taken[name] = true
+		data.Imports = append(data.Imports, Import{Name: name, Path: importPath})
+		return name
+	}
+
+	var result []ConverterData
+	for _, conv := range opts.Converters {
+		features, err := tableFeatures(t, conv.Options)
+		importPath := ImportPath(t, conv.Options)
+		if err != nil || !features[FeatureRow] || importPath == "" {
+			continue
+		}
+
+		pkg := imported(importPath, identifier(strings.ToLower(conv.Name)))
+		cd := ConverterData{GoName: tagGoName(conv.Name, opts), Package: pkg, Path: importPath}
+
+		otherNames := goNames(t, conv.Options)
+		var from, to strings.Builder
+		for _, c := range data.Columns {
+			other := columnSide(t, c.Column, conv.Options, func(goType, importPath string) string {
+				if importPath != "" {
+					name := imported(importPath, importName(importPath))
+					return strings.Replace(goType, importName(importPath)+".", name+".", 1)
+				}
+				if isLocalType(t, c.Column, goType, importPath, conv.Options) {
+					elem := strings.TrimLeft(goType, "[]")
+					return goType[:len(goType)-len(elem)] + pkg + "." + elem
+				}
+				return goType
+			})
+			own := columnSide(t, c.Column, opts, func(goType, importPath string) string {
+				return qualify(goType, importPath, data.Imports)
+			})
+			otherName := otherNames[c.Name]
+
+			toOwn, ok := convertColumn(other, own, "r."+otherName, "d."+c.GoName, c.Name, "Row{}")
+			toOther, ok2 := convertColumn(own, other, "r."+c.GoName, "d."+otherName, c.Name, pkg+".Row{}")
+			if !ok || !ok2 {
+				cd.Skipped = append(cd.Skipped, fmt.Sprintf("%s (%s, %s)", c.Name, own.goType, other.goType))
+				continue
+			}
+			from.WriteString(toOwn)
+			to.WriteString(toOther)
+		}
+		cd.From, cd.To = from.String(), to.String()
+
+		result = append(result, cd)
+	}
+
+	return result
+}
+
+// columnSide resolves the type of a column in the Row of the build of opts,
+// written by qualify.
+func columnSide(t schema.Table, c schema.Column, opts Options, qualify func(goType, importPath string) string) convertSide {
+	goType, importPath := columnType(t, c, opts)
+	s := convertSide{goType: qualify(goType, importPath), pointer: c.Nullable}
+	s.base = s.goType
+
+	name := path.Ext(goType)
+	if w, ok := nullWrappers[importPath+name]; ok && name != "" && !strings.HasPrefix(goType, "[]") {
+		s.wrapper = &w
+		s.base = w[1]
+		s.underlying = underlyingTypes[w[1]]
+		return s
+	}
+
+	switch {
+	case importPath != "":
+		s.underlying = underlyingTypes[importPath+name]
+	case isLocalType(t, c, goType, importPath, opts):
+		s.underlying = underlyingTypes[goType]
+		if _, ok := enumType(c); ok && !strings.HasPrefix(goType, "[]") {
+			s.underlying = "string"
+		}
+	default:
+		s.underlying = underlyingTypes[goType]
+	}
+
+	return s
+}
+
+// isLocalType reports whether goType, the type of c resolved with opts, is
+// generated into the package of the table: an enum or a helper type.
+func isLocalType(t schema.Table, c schema.Column, goType, importPath string, opts Options) bool {
+	if _, ok := columnHelper(goType, importPath); ok {
+		return true
+	}
+	if _, ok := typeOverride(t, c, opts); ok || importPath != "" {
+		return false
+	}
+	_, ok := enumType(c)
+	return ok
+}
+
+// convertColumn returns the statements assigning the value of src, read
+// from the field from, to the field to of type dst. A NULL becomes a NULL
+// when dst can hold one and an error naming column otherwise, returning
+// zero. It reports false when the types do not convert.
+func convertColumn(src, dst convertSide, from, to, column, zero string) (string, bool) {
+	if src.goType == dst.goType && src.pointer == dst.pointer {
+		return to + " = " + from + "\n", true
+	}
+
+	value := func(v string) string { return v }
+	if src.base != dst.base {
+		if src.underlying == "" || src.underlying != dst.underlying || strings.HasPrefix(dst.base, "[]") {
+			return "", false
+		}
+		value = func(v string) string { return dst.base + "(" + v + ")" }
+		if fields, ok := pointFields[src.underlying]; ok {
+			point := strings.TrimSuffix(dst.base, src.underlying) + "Point"
+			value = func(v string) string {
+				return dst.base + "{" + fmt.Sprintf(fields, point, strings.TrimPrefix(v, "*")) + "}"
+			}
+		}
+	}
+
+	// Whether src holds a value, and the value
+	present, absent, v := "", "", from
+	switch {
+	case src.pointer && src.wrapper != nil:
+		present, absent, v = from+" != nil && "+from+".Valid", from+" == nil || !"+from+".Valid", from+"."+src.wrapper[0]
+	case src.pointer:
+		present, absent, v = from+" != nil", from+" == nil", "*"+from
+	case src.wrapper != nil:
+		present, absent, v = from+".Valid", "!"+from+".Valid", from+"."+src.wrapper[0]
+	}
+	v = value(v)
+
+	var assign string
+	switch {
+	case dst.pointer && dst.wrapper != nil:
+		assign = fmt.Sprintf("v := %s{%s: %s, Valid: true}\n%s = &v\n", dst.goType, dst.wrapper[0], v, to)
+	case dst.pointer && !src.pointer && src.wrapper == nil:
+		assign = fmt.Sprintf("v := %s\n%s = &v\n", v, to)
+	case dst.pointer && v == "*"+from && src.base == dst.base:
+		assign = fmt.Sprintf("%s = %s\n", to, from)
+		present = ""
+	case dst.pointer:
+		assign = fmt.Sprintf("v := %s\n%s = &v\n", v, to)
+	case dst.wrapper != nil:
+		assign = fmt.Sprintf("%s = %s{%s: %s, Valid: true}\n", to, dst.goType, dst.wrapper[0], v)
+	default:
+		assign = fmt.Sprintf("%s = %s\n", to, v)
+	}
+
+	switch {
+	case present == "":
+		if strings.Contains(assign, "v := ") {
+			return "{\n" + assign + "}\n", true
+		}
+		return assign, true
+	case dst.pointer || dst.wrapper != nil:
+		return "if " + present + " {\n" + assign + "}\n", true
+	}
+
+	return fmt.Sprintf("if %s {\nreturn %s, errors.New(%s)\n}\n%s", absent, zero, strconv.Quote("column "+column+" is NULL"), assign), true
+}
@@ -0,0 +1,92 @@
+package gen
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// castRe matches the cast PostgreSQL appends to a literal default, as in
+// 'pending'::order_status or '-1'::integer.
+var castRe = regexp.MustCompile(`^::[\w ."\[\]]+$`)
+
+// defaultValue returns the Go constant of a column default that is a plain
+// literal of goType, such as "pending" for 'pending'::order_status, or an
+// empty string for expressions (now(), nextval(...)) and types Go has no
+// constants for. goType is the column's type without the pointer of
+// nullable columns.
+func defaultValue(c schema.Column, goType string) string {
+	literal, quoted, ok := defaultLiteral(c.Default)
+	if !ok {
+		return ""
+	}
+
+	if _, ok := enumType(c); ok && !strings.HasSuffix(c.Type, "[]") {
+		goType = "string"
+	}
+
+	switch goType {
+	case "string":
+		if quoted {
+			return strconv.Quote(literal)
+		}
+	case "bool":
+		if literal == "true" || literal == "false" {
+			return literal
+		}
+	case "int16", "int32", "int64":
+		bits, _ := strconv.Atoi(goType[3:])
+		if n, err := strconv.ParseInt(literal, 10, bits); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+	case "float32", "float64":
+		bits, _ := strconv.Atoi(goType[5:])
+		if f, err := strconv.ParseFloat(literal, bits); err == nil && !strings.ContainsAny(strconv.FormatFloat(f, 'g', -1, bits), "IN") {
+			return strconv.FormatFloat(f, 'g', -1, bits)
+		}
+	}
+
+	return ""
+}
+
+// defaultLiteral returns the literal of a default expression without its
+// quotes, cast and parentheses, and whether it was quoted. The last result
+// is false when the expression is more than a literal.
+func defaultLiteral(expr string) (string, bool, bool) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" || strings.HasPrefix(expr, "NULL") {
+		return "", false, false
+	}
+
+	if !strings.HasPrefix(expr, "'") {
+		expr = strings.TrimSuffix(strings.TrimPrefix(expr, "("), ")")
+		if strings.ContainsAny(expr, " ()'") {
+			return "", false, false
+		}
+		return expr, false, true
+	}
+
+	// Find the closing quote, skipping doubled ones
+	var b strings.Builder
+	for i := 1; i < len(expr); i++ {
+		if expr[i] != '\'' {
+			b.WriteByte(expr[i])
+			continue
+		}
+		if i+1 < len(expr) && expr[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+
+		rest := expr[i+1:]
+		if rest != "" && !castRe.MatchString(rest) {
+			return "", false, false
+		}
+		return b.String(), true, true
+	}
+
+	return "", false, false
+}
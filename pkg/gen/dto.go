@@ -0,0 +1,128 @@
+package gen
+
+import (
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// dbPackages are the import paths of database types a DTO leaves out.
+var dbPackages = []string{
+	"database/sql",
+	"github.com/jackc/pgtype",
+	"github.com/jackc/pgx",
+	"github.com/lib/pq",
+}
+
+// dtoUnwrap maps the helper types a DTO replaces with the plain type they
+// wrap to that type and the formats converting a value to it and back,
+// with %s being the value. The Money type is qualified at generation.
+var dtoUnwrap = map[string][3]string{
+	"Money":        {"%s.Decimal", "%s.Decimal", "Money{Decimal: %s}"},
+	"MoneyCents":   {"int64", "int64(%s)", "MoneyCents(%s)"},
+	"BitString":    {"string", "string(%s)", "BitString(%s)"},
+	"XML":          {"string", "string(%s)", "XML(%s)"},
+	"Hstore":       {"map[string]*string", "map[string]*string(%s)", "Hstore(%s)"},
+	"NetAddr":      {"netip.Addr", "%s.Addr", "NetAddr{Addr: %s}"},
+	"NetPrefix":    {"netip.Prefix", "%s.Prefix", "NetPrefix{Prefix: %s}"},
+	"HardwareAddr": {"net.HardwareAddr", "%s.HardwareAddr", "HardwareAddr{HardwareAddr: %s}"},
+}
+
+// DTOField is a field of the DTO struct of a table.
+type DTOField struct {
+	ColumnData
+
+	// Type is the Go type of the field, a pointer for nullable columns.
+	Type string
+
+	// ToDTO and FromDTO are the formats converting the Row field to the DTO
+	// field and back, %s being the field. They are empty when the field
+	// is assigned as it is.
+	ToDTO   string
+	FromDTO string
+}
+
+// dtoFields returns the fields of the DTO of t, from the columns of
+// Options.DTO or every column, in the order of fields. Columns of database
+// driver types, such as pgtype or sql.Null types, and arrays of helper
+// types are left out.
+func dtoFields(t schema.Table, data TableData, opts Options) []DTOField {
+	selected := dtoColumns(t, opts)
+
+	decimal := data.ImportName("github.com/shopspring/decimal")
+
+	var fields []DTOField
+	for _, c := range data.Fields {
+		if !selected[c.Name] {
+			continue
+		}
+
+		_, importPath := columnType(t, c.Column, opts)
+		if isDBPackage(importPath) {
+			continue
+		}
+
+		f := DTOField{ColumnData: c, Type: c.GoType}
+		if _, ok := columnHelper(c.ValueType, importPath); ok {
+			name := strings.TrimPrefix(c.ValueType, "[]")
+			unwrap, ok := dtoUnwrap[name]
+			if ok && name != c.ValueType {
+				// Arrays would need a loop to convert each element
+				continue
+			}
+			if ok {
+				f.Type, f.ToDTO, f.FromDTO = strings.ReplaceAll(unwrap[0], "%s", decimal), unwrap[1], unwrap[2]
+			}
+			if ok && c.Nullable {
+				// Selectors dereference the pointer, conversions must
+				f.Type = "*" + f.Type
+				if !strings.HasPrefix(f.ToDTO, "%s.") {
+					f.ToDTO = strings.Replace(f.ToDTO, "%s", "*%s", 1)
+				}
+				f.FromDTO = strings.Replace(f.FromDTO, "%s", "*%s", 1)
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// dtoColumns returns the names of the columns of t in its DTO. An entry of
+// Options.DTO made only of -column items leaves those out of every column;
+// any other entry lists the columns. Names of other columns are ignored, as
+// entries keyed by name apply to the tables of that name in every schema.
+func dtoColumns(t schema.Table, opts Options) map[string]bool {
+	entry, ok := opts.DTO[t.Schema+"."+t.Name]
+	if !ok {
+		entry = opts.DTO[t.Name]
+	}
+
+	exclude := len(entry) > 0
+	for _, name := range entry {
+		if !strings.HasPrefix(name, "-") {
+			exclude = false
+		}
+	}
+
+	selected := make(map[string]bool, len(t.Columns))
+	for _, c := range t.Columns {
+		selected[c.Name] = len(entry) == 0 || exclude
+	}
+	for _, name := range entry {
+		selected[strings.TrimPrefix(name, "-")] = !exclude
+	}
+
+	return selected
+}
+
+// isDBPackage reports whether importPath is a package of database types.
+func isDBPackage(importPath string) bool {
+	for _, p := range dbPackages {
+		if importPath == p || strings.HasPrefix(importPath, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,236 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stdImports lets templates use common standard library packages without
+// listing them in imports.tmpl.
+var stdImports = []string{
+	"bytes",
+	"context",
+	"crypto/sha256",
+	"database/sql",
+	"database/sql/driver",
+	"encoding/hex",
+	"encoding/json",
+	"errors",
+	"fmt",
+	"reflect",
+	"slices",
+	"sort",
+	"strconv",
+	"strings",
+	"time",
+}
+
+// emit parses rendered source as Go, replaces its imports with the packages
+// the code actually refers to and prints it gofmt-formatted. Packages are
+// looked up in the file's own imports first, then in imports, then in
+// stdImports. Source that is not valid Go is an error rather than a file
+// that fails to compile later. Code for Go versions before 1.18, see
+// Options.GoVersion, spells any as interface{}, lines are wrapped at
+// Options.MaxLineLength, Options.Nolint goes on the package clause and
+// Options.BuildTags on top of the file.
+func emit(filename, src string, imports []Import, opts Options) (string, error) {
+	if !goAtLeast(opts, 18) {
+		var err error
+		if src, err = replaceAny(src); err != nil {
+			return "", err
+		}
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("generated invalid Go: %w", err)
+	}
+	if name := redeclared(file); name != "" {
+		return "", fmt.Errorf("generated invalid Go: %s is declared twice, rename the column or setting it is named after", name)
+	}
+
+	candidates := make(map[string]Import)
+	var kept []Import
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := importName(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		// Blank and dot imports have no qualifier to look for
+		if name == "_" || name == "." {
+			kept = append(kept, Import{Name: name, Path: p})
+			continue
+		}
+		candidates[name] = Import{Name: name, Path: p}
+	}
+	for _, imp := range imports {
+		if _, ok := candidates[imp.Name]; !ok {
+			candidates[imp.Name] = imp
+		}
+	}
+	for _, p := range stdImports {
+		if _, ok := candidates[importName(p)]; !ok {
+			candidates[importName(p)] = Import{Name: importName(p), Path: p}
+		}
+	}
+
+	used := kept
+	for _, name := range qualifiers(file) {
+		if spec, ok := candidates[name]; ok {
+			used = append(used, spec)
+		}
+	}
+
+	// Splice the new import block in place of the old declarations
+	var out strings.Builder
+	offset := 0
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			out.WriteString(src[offset:fset.Position(d.Pos()).Offset])
+			offset = fset.Position(d.End()).Offset
+		}
+	}
+	out.WriteString(src[offset:])
+
+	spliced := out.String()
+	at := fset.Position(file.Name.End()).Offset
+	spliced = spliced[:at] + "\n\n" + importBlock(used) + spliced[at:]
+
+	formatted, err := format.Source([]byte(spliced))
+	if err != nil {
+		return "", fmt.Errorf("generated invalid Go: %w", err)
+	}
+
+	result := string(formatted)
+	if opts.MaxLineLength > 0 {
+		if result, err = wrapLines(result, opts.MaxLineLength); err != nil {
+			return "", err
+		}
+	}
+	result = addNolint(result, opts.Nolint)
+
+	constraint, err := buildConstraint(opts)
+	if err != nil {
+		return "", err
+	}
+
+	return constraint + result, nil
+}
+
+// redeclared returns the first package-level identifier file declares
+// twice, or "".
+func redeclared(file *ast.File) string {
+	seen := make(map[string]bool)
+	declare := func(id *ast.Ident) bool {
+		if id.Name == "_" || id.Name == "init" {
+			return false
+		}
+		if seen[id.Name] {
+			return true
+		}
+		seen[id.Name] = true
+		return false
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && declare(d.Name) {
+				return d.Name.Name
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if declare(s.Name) {
+						return s.Name.Name
+					}
+				case *ast.ValueSpec:
+					for _, id := range s.Names {
+						if declare(id) {
+							return id.Name
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// qualifiers returns the sorted names used as package qualifiers in file,
+// that is the unresolved identifiers on the left of a selector.
+func qualifiers(file *ast.File) []string {
+	seen := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				seen[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// importBlock renders an import declaration, standard library first and
+// each group sorted by path.
+func importBlock(imports []Import) string {
+	if len(imports) == 0 {
+		return ""
+	}
+
+	var std, external []string
+	for _, imp := range imports {
+		line := strconv.Quote(imp.Path)
+		if imp.Aliased() {
+			line = imp.Name + " " + line
+		}
+
+		if isStdImport(imp.Path) {
+			std = append(std, line)
+		} else {
+			external = append(external, line)
+		}
+	}
+
+	byPath := func(lines []string) {
+		sort.Slice(lines, func(i, j int) bool {
+			return lines[i][strings.IndexByte(lines[i], '"'):] < lines[j][strings.IndexByte(lines[j], '"'):]
+		})
+	}
+	byPath(std)
+	byPath(external)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, line := range std {
+		b.WriteString("\t" + line + "\n")
+	}
+	if len(std) > 0 && len(external) > 0 {
+		b.WriteString("\n")
+	}
+	for _, line := range external {
+		b.WriteString("\t" + line + "\n")
+	}
+	b.WriteString(")\n")
+
+	return b.String()
+}
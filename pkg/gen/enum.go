@@ -0,0 +1,152 @@
+package gen
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// EnumData describes an enum type generated into a table's package.
+type EnumData struct {
+	// Name is the PostgreSQL type name.
+	Name string
+
+	// GoName is the Go type, named after the PostgreSQL type.
+	GoName string
+
+	Values []EnumValue
+}
+
+// EnumValue is a label of an enum and its Go constant.
+type EnumValue struct {
+	GoName string
+	Value  string
+
+	// Doc holds the lines of the doc comment of the constant, empty lines
+	// separating paragraphs.
+	Doc []string
+}
+
+// EnumDoc documents the labels of an enum type.
+type EnumDoc struct {
+	// Labels maps labels to their documentation.
+	Labels map[string]string
+
+	// Deprecated lists the labels not to use anymore. Enum labels cannot
+	// be dropped, so their constants stay, marked deprecated.
+	Deprecated []string
+}
+
+// enumType returns the PostgreSQL enum type of a column, without the []s of
+// an array of enums, and whether the column is an enum at all.
+func enumType(c schema.Column) (string, bool) {
+	if len(c.Enum) == 0 {
+		return "", false
+	}
+
+	return strings.TrimRight(c.Type, "[]"), true
+}
+
+// enumGoName returns the Go type of an enum in the package of t, without
+// the package name in front with Options.NoStutter. A column with the same
+// Go name, like a status column of type status, keeps it and the enum type
+// gets an Enum suffix.
+func enumGoName(t schema.Table, name string, opts Options) string {
+	goType := exported(identifierName(name))
+	if opts.NoStutter {
+		goType = unstutter(goType, PackagePath(t, opts))
+	}
+
+	for _, name := range goNames(t, opts) {
+		if name == goType {
+			return goType + "Enum"
+		}
+	}
+
+	return goType
+}
+
+// buildEnums returns the enum types the columns of t need, in column order.
+func buildEnums(t schema.Table, opts Options) []EnumData {
+	seen := make(map[string]bool)
+	var enums []EnumData
+
+	for _, c := range t.Columns {
+		name, ok := enumType(c)
+		if !ok || seen[name] {
+			continue
+		}
+		if _, ok := typeOverride(t, c, opts); ok {
+			continue
+		}
+		seen[name] = true
+
+		e := EnumData{Name: name, GoName: enumGoName(t, name, opts)}
+		used := make(map[string]bool)
+		for i, label := range c.Enum {
+			constName := e.GoName + identifierName(label)
+			if constName == e.GoName || used[constName] {
+				constName += strconv.Itoa(i + 1)
+			}
+			used[constName] = true
+
+			e.Values = append(e.Values, EnumValue{GoName: constName, Value: label, Doc: labelDoc(c, name, label, opts)})
+		}
+
+		enums = append(enums, e)
+	}
+
+	return enums
+}
+
+// labelDoc returns the doc comment lines of the constant of label, a label
+// of the enum type name of c: its documentation in Options.Enums or its
+// comment in the schema, and a Deprecated paragraph for the deprecated
+// labels.
+func labelDoc(c schema.Column, name, label string, opts Options) []string {
+	doc, ok := opts.Enums[name].Labels[label]
+	if !ok {
+		doc = c.EnumComments[label]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		if line = strings.TrimSpace(line); line != "" || len(lines) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	if slices.Contains(opts.Enums[name].Deprecated, label) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("Deprecated: the %s label is kept for existing rows, do not write it.", label))
+	}
+
+	return lines
+}
+
+// identifierName turns any string into an exported Go identifier part,
+// capitalizing every run of letters and digits: in-progress becomes
+// InProgress.
+func identifierName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
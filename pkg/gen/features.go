@@ -0,0 +1,109 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Features name the artifacts generated for a table, which can be turned on
+// and off per table.
+const (
+	FeatureTypes   = "types"   // a type alias per column
+	FeatureColumns = "columns" // the column names struct, C, Table and Column constants
+	FeatureMeta    = "meta"    // Meta with column lists, the primary key and placeholders
+	FeatureRow     = "row"     // Row and its Validator hook
+	FeaturePgx     = "pgx"     // RowTo and RowToAddr scanning pgx rows into Row, and batch helpers
+	FeatureDTO     = "dto"     // DTO with ToDTO and FromDTO converting from and to Row
+	FeatureSort    = "sort"    // OrderBy checking client sort parameters against SortColumns
+	FeatureFilter  = "filter"  // Filter with its WHERE clause built with the where package
+	FeatureKeys    = "keys"    // CountBy and ExistsBy per primary key and unique index
+)
+
+// featureNeeds lists the features a feature's code refers to.
+var featureNeeds = map[string]string{
+	FeatureMeta: FeatureColumns,
+	FeaturePgx:  FeatureRow,
+	FeatureDTO:  FeatureRow,
+	FeatureSort: FeatureColumns,
+}
+
+// DefaultFeatures are generated when Options.Features is empty.
+var DefaultFeatures = []string{FeatureTypes, FeatureColumns, FeatureMeta, FeatureRow}
+
+// knownFeatures lists every feature in the order they are generated.
+var knownFeatures = []string{FeatureTypes, FeatureColumns, FeatureMeta, FeatureRow, FeaturePgx, FeatureDTO, FeatureSort, FeatureFilter, FeatureKeys}
+
+// tableFeatures returns the set of features generated for t. An entry of
+// Options.TableFeatures made only of +feature and -feature items adjusts
+// the default set; any other entry replaces it.
+func tableFeatures(t schema.Table, opts Options) (map[string]bool, error) {
+	base := opts.Features
+	if len(base) == 0 {
+		base = DefaultFeatures
+	}
+
+	features := make(map[string]bool)
+	for _, f := range base {
+		features[f] = true
+	}
+
+	entry, ok := opts.TableFeatures[t.Schema+"."+t.Name]
+	if !ok {
+		entry, ok = opts.TableFeatures[t.Name]
+	}
+	if ok {
+		relative := len(entry) > 0
+		for _, f := range entry {
+			if !strings.HasPrefix(f, "+") && !strings.HasPrefix(f, "-") {
+				relative = false
+			}
+		}
+		if !relative {
+			features = make(map[string]bool)
+		}
+
+		for _, f := range entry {
+			features[strings.TrimLeft(f, "+-")] = !strings.HasPrefix(f, "-")
+		}
+	}
+
+	for f, on := range features {
+		if !on {
+			delete(features, f)
+			continue
+		}
+		if !isKnownFeature(f) {
+			return nil, fmt.Errorf("table %s.%s: unknown feature %q, expected one of %s", t.Schema, t.Name, f, strings.Join(knownFeatures, ", "))
+		}
+	}
+
+	for f := range features {
+		if need, ok := featureNeeds[f]; ok && !features[need] {
+			return nil, fmt.Errorf("table %s.%s: feature %s needs %s", t.Schema, t.Name, f, need)
+		}
+	}
+
+	// Filter builds its conditions with the Where variable
+	if features[FeatureFilter] && opts.WherePackage == "" {
+		return nil, fmt.Errorf("table %s.%s: feature %s needs the where package", t.Schema, t.Name, FeatureFilter)
+	}
+
+	// pgx v5 itself needs Go 1.21
+	if features[FeaturePgx] && !goAtLeast(opts, 21) {
+		return nil, fmt.Errorf("table %s.%s: feature %s needs Go 1.21 or later", t.Schema, t.Name, FeaturePgx)
+	}
+
+	return features, nil
+}
+
+func isKnownFeature(f string) bool {
+	for _, known := range knownFeatures {
+		if f == known {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package gen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Orders of the fields of generated structs, see Options.FieldOrder.
+const (
+	// FieldOrderOrdinal keeps the columns in table order.
+	FieldOrderOrdinal = "ordinal"
+
+	// FieldOrderAlphabetical sorts fields by Go name.
+	FieldOrderAlphabetical = "alphabetical"
+
+	// FieldOrderPrimaryKey puts the primary key columns first, in key
+	// order, followed by the other columns in table order.
+	FieldOrderPrimaryKey = "primary_key"
+)
+
+// checkFieldOrder reports an unknown Options.FieldOrder.
+func checkFieldOrder(order string) error {
+	switch order {
+	case "", FieldOrderOrdinal, FieldOrderAlphabetical, FieldOrderPrimaryKey:
+		return nil
+	}
+
+	return fmt.Errorf("unknown field order %q, expected one of %s", order, strings.Join([]string{FieldOrderOrdinal, FieldOrderAlphabetical, FieldOrderPrimaryKey}, ", "))
+}
+
+// orderFields returns columns in the order of struct fields. The columns
+// themselves keep table order, which scanning relies on.
+func orderFields(columns []ColumnData, order string) []ColumnData {
+	fields := slices.Clone(columns)
+
+	switch order {
+	case FieldOrderAlphabetical:
+		slices.SortStableFunc(fields, func(a, b ColumnData) int {
+			return strings.Compare(a.GoName, b.GoName)
+		})
+	case FieldOrderPrimaryKey:
+		slices.SortStableFunc(fields, func(a, b ColumnData) int {
+			switch {
+			case a.PrimaryKey == 0 && b.PrimaryKey == 0:
+				return 0
+			case a.PrimaryKey == 0:
+				return 1
+			case b.PrimaryKey == 0:
+				return -1
+			}
+			return a.PrimaryKey - b.PrimaryKey
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,79 @@
+package gen
+
+import (
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// rangeTypes are the PostgreSQL types Filter matches by a range, with From
+// and To fields, rather than by a value.
+var rangeTypes = map[string]bool{
+	"date":                        true,
+	"timestamp":                   true,
+	"timestamp without time zone": true,
+	"timestamptz":                 true,
+	"timestamp with time zone":    true,
+}
+
+// FilterField is a column Filter filters by.
+type FilterField struct {
+	ColumnData
+
+	// Range is set for columns of rangeTypes, filtered by From and To
+	// fields, and In for enum columns, filtered by a list of labels. Other
+	// columns are filtered by a value.
+	Range bool
+	In    bool
+}
+
+// filterFields returns the columns of t that Filter filters by, in the
+// order of fields: those of the entry of Options.Filter, or the key columns
+// of the indexes of t and of its primary key. Arrays and columns of types
+// without equality, such as json, are left out of the indexed ones. Names
+// of other columns are ignored, as entries keyed by name apply to the
+// tables of that name in every schema.
+func filterFields(t schema.Table, fields []ColumnData, opts Options) []FilterField {
+	entry, ok := opts.Filter[t.Schema+"."+t.Name]
+	if !ok {
+		entry, ok = opts.Filter[t.Name]
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range entry {
+		selected[name] = true
+	}
+	if !ok {
+		for _, idx := range t.Indexes {
+			for _, name := range idx.Columns {
+				selected[name] = true
+			}
+		}
+		for _, c := range t.Columns {
+			if c.PrimaryKey > 0 {
+				selected[c.Name] = true
+			}
+		}
+	}
+
+	var filter []FilterField
+	for _, c := range fields {
+		if !selected[c.Name] {
+			continue
+		}
+
+		pgType := normalizeType(c.Type)
+		if !ok && (strings.HasSuffix(pgType, "[]") || unsortableTypes[pgType]) {
+			continue
+		}
+
+		_, enum := enumType(c.Column)
+		filter = append(filter, FilterField{
+			ColumnData: c,
+			Range:      rangeTypes[pgType],
+			In:         enum && !strings.HasSuffix(pgType, "[]"),
+		})
+	}
+
+	return filter
+}
@@ -0,0 +1,172 @@
+package gen
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// FixtureTable is a table with rows read from the database, each holding a
+// value per column in column order as database/sql scans them into an any.
+// A nil value of a NOT NULL column stands for the zero value of its type,
+// which masking with MaskRedact produces.
+type FixtureTable struct {
+	Table schema.Table
+	Rows  [][]any
+}
+
+// fixtureTypes renders a value of a type referred to by import path, given
+// the name the file imports its package by and the value as text.
+var fixtureTypes = map[string]string{
+	"github.com/google/uuid.UUID":           "%s.MustParse(%q)",
+	"github.com/shopspring/decimal.Decimal": "%s.RequireFromString(%q)",
+	"encoding/json.RawMessage":              "%s.RawMessage(%q)",
+}
+
+// BuildFixtures renders a Go file of package pkg declaring a slice of Row
+// literals per table, named after the table, e.g. var Users []users.Row.
+// The table packages are imported from Options.ModulePath, so it must be
+// set. Values of types the file cannot spell are left at their zero value
+// with a TODO comment showing them.
+func BuildFixtures(pkg string, tables []FixtureTable, opts Options) (string, error) {
+	// Nullable values are spelled with a generic helper
+	if !goAtLeast(opts, 18) {
+		return "", fmt.Errorf("dumped fixtures need Go 1.18 or later")
+	}
+
+	var b strings.Builder
+	var imports []Import
+	ptr := false
+
+	b.WriteString("// Fixtures dumped from the database by tables dump-fixtures.\n\n")
+	b.WriteString("package " + pkg + "\n")
+
+	for _, ft := range tables {
+		t := ft.Table
+		features, err := tableFeatures(t, opts)
+		if err != nil {
+			return "", err
+		}
+		if !features[FeatureRow] {
+			return "", fmt.Errorf("table %s.%s has no Row, enable the %s feature for it", t.Schema, t.Name, FeatureRow)
+		}
+
+		importPath := ImportPath(t, opts)
+		if importPath == "" {
+			return "", fmt.Errorf("fixtures import the generated packages, set the module path of the output directory")
+		}
+		name := path.Base(importPath)
+		data := tableData(t, name, features, opts)
+		imports = append(imports, Import{Name: name, Path: importPath})
+		imports = append(imports, data.Imports...)
+
+		fmt.Fprintf(&b, "\n// %s holds %d rows of %s.%s.\n", exported(identifierName(t.Name)), len(ft.Rows), t.Schema, t.Name)
+		fmt.Fprintf(&b, "var %s = []%s.Row{\n", exported(identifierName(t.Name)), name)
+		for _, row := range ft.Rows {
+			b.WriteString("{\n")
+			for i, c := range data.Columns {
+				if i >= len(row) || row[i] == nil {
+					continue
+				}
+
+				literal, ok := fixtureLiteral(c, data, row[i], opts)
+				switch {
+				case !ok:
+					fmt.Fprintf(&b, "// TODO: %s %s: %q\n", c.GoName, c.Type, fixtureText(row[i]))
+				case c.Nullable:
+					fmt.Fprintf(&b, "%s: ptr(%s),\n", c.GoName, literal)
+					ptr = true
+				default:
+					fmt.Fprintf(&b, "%s: %s,\n", c.GoName, literal)
+				}
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	if ptr {
+		b.WriteString("\nfunc ptr[T any](v T) *T { return &v }\n")
+	}
+
+	return emit("fixtures.go", b.String(), imports, opts)
+}
+
+// fixtureLiteral returns the Go expression of a value of column c, or false
+// when the file cannot spell it.
+func fixtureLiteral(c ColumnData, data TableData, v any, opts Options) (string, bool) {
+	valueType := c.ValueType
+	text := fixtureText(v)
+
+	// Generated enums convert from their label
+	_, enum := enumType(c.Column)
+	if _, overridden := typeOverride(data.Table, c.Column, opts); enum && !overridden && !strings.HasPrefix(valueType, "[]") {
+		return fmt.Sprintf("%s.%s(%q)", data.Package, valueType, text), true
+	}
+
+	qualifier, name, qualified := strings.Cut(valueType, ".")
+
+	switch valueType {
+	case "string":
+		return strconv.Quote(text), true
+	case "bool":
+		b, ok := v.(bool)
+		return strconv.FormatBool(b), ok
+	case "int", "int16", "int32", "int64", "uint", "uint16", "uint32", "uint64":
+		_, err := strconv.ParseInt(text, 10, 64)
+		return text, err == nil
+	case "float32", "float64":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	case "[]byte":
+		return fmt.Sprintf("[]byte(%q)", text), true
+	}
+
+	if !qualified || strings.ContainsAny(qualifier, "*[]") {
+		return "", false
+	}
+
+	importPath := ""
+	for _, imp := range data.Imports {
+		if imp.Name == qualifier {
+			importPath = imp.Path
+		}
+	}
+
+	if importPath+"."+name == "time.Time" {
+		t, ok := v.(time.Time)
+		if !ok {
+			return "", false
+		}
+		t = t.UTC()
+		return fmt.Sprintf("%[1]s.Date(%d, %d, %d, %d, %d, %d, %d, %[1]s.UTC)", qualifier, t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()), true
+	}
+
+	if format, ok := fixtureTypes[importPath+"."+name]; ok {
+		return fmt.Sprintf(format, qualifier, text), true
+	}
+
+	return "", false
+}
+
+// fixtureText returns a scanned value as text.
+func fixtureText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,165 @@
+package gen
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/mymyka/tables/pkg/introspect"
+	"github.com/mymyka/tables/pkg/schema"
+	"github.com/mymyka/tables/pkg/writer"
+)
+
+// Generator runs the same pipeline as `tables generate`: it introspects a
+// database, builds a package per table and writes them to a directory.
+//
+//	g := gen.Generator{
+//		DB:         db,
+//		Introspect: introspect.Options{Schemas: []string{"public"}},
+//		Options:    gen.Options{Initialisms: []string{"ID"}},
+//		Dir:        "internal/models",
+//	}
+//	result, err := g.Run()
+type Generator struct {
+	// DB is an open PostgreSQL connection. The generator does not close it.
+	DB *sql.DB
+
+	// Introspect selects the schemas and tables to read.
+	Introspect introspect.Options
+
+	// Options control the generated code.
+	Options Options
+
+	// Dir is the directory packages are written to.
+	Dir string
+
+	// Module, when set, writes go.mod/go.sum declaring it into Dir.
+	Module string
+
+	// Write controls how files are written.
+	Write writer.Options
+}
+
+// Option configures a Generator built with New.
+type Option func(*Generator)
+
+// New returns a Generator writing to dir, configured by opts:
+//
+//	g := gen.New(db, "internal/models",
+//		gen.WithSchemas("public", "billing"),
+//		gen.WithRename(func(t schema.Table, c schema.Column, name string) string {
+//			return strings.TrimSuffix(name, "Col")
+//		}),
+//	)
+func New(db *sql.DB, dir string, opts ...Option) *Generator {
+	g := &Generator{DB: db, Dir: dir}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// WithSchemas sets the schemas to introspect.
+func WithSchemas(schemas ...string) Option {
+	return func(g *Generator) { g.Introspect.Schemas = schemas }
+}
+
+// WithInclude keeps only the tables matching patterns.
+func WithInclude(patterns ...string) Option {
+	return func(g *Generator) { g.Introspect.Include = patterns }
+}
+
+// WithExclude drops the tables matching patterns.
+func WithExclude(patterns ...string) Option {
+	return func(g *Generator) { g.Introspect.Exclude = patterns }
+}
+
+// WithOptions replaces the code generation options. Apply it before the
+// other options that change them.
+func WithOptions(opts Options) Option {
+	return func(g *Generator) { g.Options = opts }
+}
+
+// WithModule writes go.mod/go.sum declaring module into the output directory.
+func WithModule(module string) Option {
+	return func(g *Generator) { g.Module = module }
+}
+
+// WithWorkers sets the number of packages built and written concurrently.
+func WithWorkers(n int) Option {
+	return func(g *Generator) {
+		g.Options.Workers = n
+		g.Write.Workers = n
+	}
+}
+
+// WithOnTable sets Options.OnTable.
+func WithOnTable(fn func(t *schema.Table) bool) Option {
+	return func(g *Generator) { g.Options.OnTable = fn }
+}
+
+// WithOnColumn sets Options.OnColumn.
+func WithOnColumn(fn func(t schema.Table, c *schema.Column) bool) Option {
+	return func(g *Generator) { g.Options.OnColumn = fn }
+}
+
+// WithRename sets Options.RenameFunc.
+func WithRename(fn func(t schema.Table, c schema.Column, name string) string) Option {
+	return func(g *Generator) { g.Options.RenameFunc = fn }
+}
+
+// WithTypeMap sets Options.TypeMapFunc.
+func WithTypeMap(fn func(t schema.Table, c schema.Column) (string, bool)) Option {
+	return func(g *Generator) { g.Options.TypeMapFunc = fn }
+}
+
+// WithPostProcess sets Options.PostProcess.
+func WithPostProcess(fn func(pkg, src string) string) Option {
+	return func(g *Generator) { g.Options.PostProcess = fn }
+}
+
+// Tables introspects the configured schemas.
+func (g *Generator) Tables() ([]schema.Table, error) {
+	if g.DB == nil {
+		return nil, fmt.Errorf("generator has no database")
+	}
+
+	return introspect.NewSchemaParser(g.DB, g.Introspect).GetTables()
+}
+
+// Generate introspects the database and returns the generated source of
+// every package keyed by package path, without writing anything.
+func (g *Generator) Generate() (map[string]string, error) {
+	tables, err := g.Tables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to introspect schema: %w", err)
+	}
+
+	return Build(tables, g.Options)
+}
+
+// Run generates the packages and writes them to Dir, pruning the packages
+// of tables that no longer exist.
+func (g *Generator) Run() (writer.Result, error) {
+	if g.Dir == "" {
+		return writer.Result{}, fmt.Errorf("generator has no output directory")
+	}
+
+	c, err := g.Generate()
+	if err != nil {
+		return writer.Result{}, err
+	}
+
+	result, err := writer.Write(g.Dir, c, g.Write)
+	if err != nil {
+		return result, fmt.Errorf("failed to write files: %w", err)
+	}
+
+	if g.Module != "" {
+		if err := writer.WriteModuleGo(g.Dir, g.Module, g.Options.GoVersion, c); err != nil {
+			return result, fmt.Errorf("failed to write module files: %w", err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// DefaultGoVersion is the Go version generated code targets without
+// Options.GoVersion, the one bootstrapped modules declare.
+const DefaultGoVersion = "1.21"
+
+// MinGoVersion is the oldest Go version generated code can target.
+const MinGoVersion = "1.16"
+
+// goMinor returns the minor version of a Go version such as 1.18, 1.18.3 or
+// go1.18, DefaultGoVersion's when it is empty.
+func goMinor(version string) (int, error) {
+	if version == "" {
+		version = DefaultGoVersion
+	}
+
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(version, "go"), "1.")
+	if !ok {
+		return 0, fmt.Errorf("invalid Go version %q, expected one like %s", version, DefaultGoVersion)
+	}
+	rest, _, _ = strings.Cut(rest, ".")
+	minor, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Go version %q, expected one like %s", version, DefaultGoVersion)
+	}
+
+	return minor, nil
+}
+
+// checkGoVersion reports an invalid Options.GoVersion or one older than
+// MinGoVersion.
+func checkGoVersion(version string) error {
+	minor, err := goMinor(version)
+	if err != nil {
+		return err
+	}
+
+	oldest, _ := goMinor(MinGoVersion)
+	if minor < oldest {
+		return fmt.Errorf("Go version %s is not supported, generated code needs Go %s or later", version, MinGoVersion)
+	}
+
+	return nil
+}
+
+// goMinorOf returns the minor version of the Go version of opts, which
+// Build has checked.
+func goMinorOf(opts Options) int {
+	minor, err := goMinor(opts.GoVersion)
+	if err != nil {
+		minor, _ = goMinor(DefaultGoVersion)
+	}
+	return minor
+}
+
+// goAtLeast reports whether the Go version of opts is minor or later.
+func goAtLeast(opts Options, minor int) bool {
+	return goMinorOf(opts) >= minor
+}
+
+// replaceAny spells the predeclared any as interface{}, which Go versions
+// before 1.18 lack. Identifiers declared in the file keep their name.
+func replaceAny(src string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("generated invalid Go: %w", err)
+	}
+
+	// Selector names and struct keys are identifiers too, but never the
+	// predeclared type
+	skip := make(map[*ast.Ident]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			skip[n.Sel] = true
+		case *ast.KeyValueExpr:
+			if id, ok := n.Key.(*ast.Ident); ok {
+				skip[id] = true
+			}
+		}
+		return true
+	})
+
+	var offsets []int
+	ast.Inspect(file, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == "any" && id.Obj == nil && !skip[id] {
+			offsets = append(offsets, fset.Position(id.Pos()).Offset)
+		}
+		return true
+	})
+
+	for i := len(offsets) - 1; i >= 0; i-- {
+		at := offsets[i]
+		src = src[:at] + "interface{}" + src[at+len("any"):]
+	}
+
+	return src, nil
+}
@@ -0,0 +1,123 @@
+package gen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// HistoryPeriod is the period column temporal_tables versioning uses by
+// convention, looked for when pairing tables automatically.
+const HistoryPeriod = "sys_period"
+
+// HistoryData describes the history table keeping the past versions of a
+// table's rows.
+type HistoryData struct {
+	// Table is the quoted history table, qualified by its schema unless
+	// Options.SearchPath is set.
+	Table string
+
+	// Period is the quoted tstzrange column holding when a version was
+	// current.
+	Period string
+
+	// Query selects every column of the versions current at $1, from the
+	// table and its history table.
+	Query string
+}
+
+// historyTables pairs tables with their history table, by schema.name.
+// Options.History names the history table of a table, "-" turning pairing
+// off; otherwise a table with a sys_period tstzrange column is paired with
+// <table>_history in its schema when that has every column of the table.
+func historyTables(tables []schema.Table, opts Options) (map[string]*HistoryData, error) {
+	byName := make(map[string]schema.Table, len(tables))
+	for _, t := range tables {
+		byName[t.Schema+"."+t.Name] = t
+	}
+
+	histories := make(map[string]*HistoryData)
+	for _, t := range tables {
+		name, configured := opts.History[t.Schema+"."+t.Name]
+		if !configured {
+			name, configured = opts.History[t.Name]
+		}
+		if name == "-" {
+			continue
+		}
+		if !configured {
+			name = t.Name + "_history"
+		}
+		if !strings.Contains(name, ".") {
+			name = t.Schema + "." + name
+		}
+
+		history, ok := byName[name]
+		period := historyPeriod(t, history)
+		switch {
+		case !configured && (!ok || period != HistoryPeriod || !hasColumns(history, t)):
+			continue
+		case !ok:
+			return nil, fmt.Errorf("table %s.%s: history table %s not found", t.Schema, t.Name, name)
+		case period == "":
+			return nil, fmt.Errorf("table %s.%s: no tstzrange period column shared with history table %s", t.Schema, t.Name, name)
+		case !hasColumns(history, t):
+			return nil, fmt.Errorf("table %s.%s: history table %s lacks some of its columns", t.Schema, t.Name, name)
+		}
+
+		histories[t.Schema+"."+t.Name] = historyData(t, history, period, opts)
+	}
+
+	return histories, nil
+}
+
+// historyPeriod returns the tstzrange column of t that history has too,
+// preferring HistoryPeriod when there are several, or "".
+func historyPeriod(t, history schema.Table) string {
+	var periods []string
+	for _, c := range t.Columns {
+		if c.Type == "tstzrange" && slices.ContainsFunc(history.Columns, func(h schema.Column) bool { return h.Name == c.Name }) {
+			periods = append(periods, c.Name)
+		}
+	}
+
+	switch {
+	case slices.Contains(periods, HistoryPeriod):
+		return HistoryPeriod
+	case len(periods) == 1:
+		return periods[0]
+	}
+	return ""
+}
+
+// hasColumns reports whether history has every column of t.
+func hasColumns(history schema.Table, t schema.Table) bool {
+	for _, c := range t.Columns {
+		if !slices.ContainsFunc(history.Columns, func(h schema.Column) bool { return h.Name == c.Name }) {
+			return false
+		}
+	}
+	return true
+}
+
+func historyData(t, history schema.Table, period string, opts Options) *HistoryData {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+
+	var columns []string
+	for _, c := range t.Columns {
+		columns = append(columns, quote(c.Name))
+	}
+	list := strings.Join(columns, ", ")
+	where := " WHERE " + quote(period) + " @> $1::timestamptz"
+
+	return &HistoryData{
+		Table:  sqlName(history, opts),
+		Period: quote(period),
+		Query: "SELECT " + list + " FROM " + sqlName(t, opts) + where +
+			" UNION ALL SELECT " + list + " FROM " + sqlName(history, opts) + where,
+	}
+}
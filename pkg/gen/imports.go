@@ -0,0 +1,215 @@
+package gen
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Import is a package imported by a generated file.
+type Import struct {
+	// Name is the name the code refers to the package by. It differs from
+	// the package's own name when two imports would otherwise collide.
+	Name string
+
+	Path string
+}
+
+// Aliased reports whether the import spells out Name: when it was renamed
+// to avoid a collision, or differs from the last path element, which makes
+// the name explicit for readers.
+func (i Import) Aliased() bool {
+	return i.Name != path.Base(i.Path)
+}
+
+// String returns the import path, so templates can print an Import as is.
+func (i Import) String() string {
+	return i.Path
+}
+
+// buildImports returns the imports of the resolved column types, of the
+// helper types they need and of the package of Options.WherePackage,
+// standard library first and each group sorted by path. Packages with the same name
+// are aliased after the preceding path element, e.g. gofrsuuid.
+func buildImports(t schema.Table, opts Options) []Import {
+	seen := make(map[string]bool)
+	var std, external []string
+
+	add := func(importPath string) {
+		if importPath == "" || seen[importPath] {
+			return
+		}
+		seen[importPath] = true
+
+		if isStdImport(importPath) {
+			std = append(std, importPath)
+		} else {
+			external = append(external, importPath)
+		}
+	}
+
+	// Collect the import path of every resolved column type
+	for _, c := range t.Columns {
+		goType, importPath := columnType(t, c, opts)
+		add(importPath)
+
+		if helper, ok := columnHelper(goType, importPath); ok {
+			for _, p := range helperImports[helper] {
+				add(p)
+			}
+		}
+	}
+
+	add(whereImport(opts))
+
+	sort.Strings(std)
+	sort.Strings(external)
+
+	// Name in path order, so the standard library keeps its names
+	taken := make(map[string]bool)
+	var imports []Import
+	for _, p := range append(std, external...) {
+		name := importName(p)
+		if taken[name] {
+			name = aliasName(p, taken)
+		}
+		taken[name] = true
+
+		imports = append(imports, Import{Name: name, Path: p})
+	}
+
+	return imports
+}
+
+// tablePackages indexes the packages of the generated tables: their import
+// paths by schema.name and by unique table name, and the imports emit adds
+// when a template refers to one by package name. Package names shared by
+// several tables or with a standard library package are left out of the
+// imports, so templates must import those explicitly. Both are empty
+// without Options.ModulePath.
+func tablePackages(tables []schema.Table, opts Options) (map[string]string, []Import) {
+	if opts.ModulePath == "" {
+		return nil, nil
+	}
+
+	paths := make(map[string]string)
+	byName := make(map[string][]string)
+	byPackage := make(map[string][]string)
+	for _, t := range tables {
+		if features, err := tableFeatures(t, opts); err != nil || len(features) == 0 {
+			continue
+		}
+
+		p := ImportPath(t, opts)
+		paths[t.Schema+"."+t.Name] = p
+		byName[t.Name] = append(byName[t.Name], p)
+		byPackage[path.Base(p)] = append(byPackage[path.Base(p)], p)
+	}
+
+	for name, ps := range byName {
+		if len(ps) == 1 {
+			paths[name] = ps[0]
+		}
+	}
+
+	std := make(map[string]bool)
+	for _, p := range stdImports {
+		std[importName(p)] = true
+	}
+
+	var imports []Import
+	for name, ps := range byPackage {
+		if len(ps) == 1 && !std[name] {
+			imports = append(imports, Import{Name: name, Path: ps[0]})
+		}
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		return imports[i].Path < imports[j].Path
+	})
+
+	return paths, imports
+}
+
+// qualify rewrites a type as written by parseGoType to refer to its package
+// by the name given in imports.
+func qualify(goType, importPath string, imports []Import) string {
+	for _, imp := range imports {
+		if imp.Path == importPath && imp.Name != importName(importPath) {
+			return strings.Replace(goType, importName(importPath)+".", imp.Name+".", 1)
+		}
+	}
+
+	return goType
+}
+
+// aliasName returns a name for p that is not taken: its package name
+// prefixed by the preceding path element, then numbered.
+func aliasName(p string, taken map[string]bool) string {
+	name := importName(p)
+
+	elems := strings.Split(p, "/")
+	if len(elems) > 1 {
+		if prefix := identifier(elems[len(elems)-2]); prefix != "" {
+			name = prefix + name
+		}
+	}
+
+	alias := name
+	for n := 2; taken[alias]; n++ {
+		alias = name + strconv.Itoa(n)
+	}
+
+	return alias
+}
+
+// importName returns the name a package is referred to by when imported
+// without an alias. It follows the usual conventions: a major version
+// suffix (/v2, .v3) and a go- prefix or -go suffix are not part of it.
+func importName(p string) string {
+	elems := strings.Split(p, "/")
+	name := elems[len(elems)-1]
+
+	if isMajorVersion(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	if dot := strings.LastIndex(name, "."); dot != -1 && isMajorVersion(name[dot+1:]) {
+		name = name[:dot]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+
+	return identifier(name)
+}
+
+// isMajorVersion reports whether s is a major version element such as v2.
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// identifier drops the characters of s that cannot appear in a Go
+// identifier and lower-cases it.
+func identifier(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r == '_' || unicode.IsLetter(r) || (unicode.IsDigit(r) && b.Len() > 0) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// isStdImport reports whether p is a standard library import path, which
+// has no dot in its first element.
+func isStdImport(p string) bool {
+	return !strings.Contains(strings.Split(p, "/")[0], ".")
+}
@@ -0,0 +1,110 @@
+package gen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// KeyData describes the CountBy and ExistsBy functions of a key of a table,
+// its primary key or a unique index.
+type KeyData struct {
+	// GoName joins the Go names of the columns with And, e.g.
+	// TenantIdAndEmail for CountByTenantIdAndEmail.
+	GoName string
+
+	// Columns are the key columns in key order, and Params the names of
+	// the parameters holding their values: key, or key1, key2, ... for
+	// several columns. Args are the arguments passing them to the queries,
+	// wrapped in sql.Named with PlaceholderNamed.
+	Columns []ColumnData
+	Params  []string
+	Args    []string
+
+	// CountQuery counts the rows with the key values, and ExistsQuery
+	// tells whether there is one.
+	CountQuery  string
+	ExistsQuery string
+}
+
+// keys returns the keys of t with columns: its primary key, then its
+// unique indexes by name. Partial indexes, indexes with expressions and
+// indexes on the columns of an earlier key are left out.
+func keys(t schema.Table, columns []ColumnData, opts Options) []KeyData {
+	var sets [][]string
+	var pk []ColumnData
+	for _, c := range columns {
+		if c.PrimaryKey > 0 {
+			pk = append(pk, c)
+		}
+	}
+	slices.SortStableFunc(pk, func(a, b ColumnData) int { return a.PrimaryKey - b.PrimaryKey })
+	if len(pk) > 0 {
+		var names []string
+		for _, c := range pk {
+			names = append(names, c.Name)
+		}
+		sets = append(sets, names)
+	}
+	for _, idx := range t.Indexes {
+		if idx.Unique && !idx.Partial && len(idx.Columns) > 0 {
+			sets = append(sets, idx.Columns)
+		}
+	}
+
+	byName := make(map[string]ColumnData, len(columns))
+	for _, c := range columns {
+		byName[c.Name] = c
+	}
+
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+
+	style := placeholderOf(opts)
+	seen := make(map[string]bool)
+	var result []KeyData
+	for _, names := range sets {
+		sorted := slices.Sorted(slices.Values(names))
+		if seen[strings.Join(sorted, "\x00")] {
+			continue
+		}
+		seen[strings.Join(sorted, "\x00")] = true
+
+		var k KeyData
+		var goNames, conds []string
+		for i, name := range names {
+			c, ok := byName[name]
+			if !ok {
+				break
+			}
+			param := "key"
+			if len(names) > 1 {
+				param = fmt.Sprintf("key%d", i+1)
+			}
+			arg := param
+			if style == PlaceholderNamed {
+				arg = fmt.Sprintf("sql.Named(\"p%d\", %s)", i+1, param)
+			}
+
+			k.Columns = append(k.Columns, c)
+			k.Params = append(k.Params, param)
+			k.Args = append(k.Args, arg)
+			goNames = append(goNames, c.GoName)
+			conds = append(conds, quote(c.Name)+" = "+placeholder(style, i+1))
+		}
+		if len(k.Columns) != len(names) {
+			continue
+		}
+
+		from := " FROM " + sqlName(t, opts) + " WHERE " + strings.Join(conds, " AND ")
+		k.GoName = strings.Join(goNames, "And")
+		k.CountQuery = "SELECT count(*)" + from
+		k.ExistsQuery = "SELECT EXISTS (SELECT 1" + from + ")"
+		result = append(result, k)
+	}
+
+	return result
+}
@@ -0,0 +1,55 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// linterRe matches the name of a golangci-lint linter.
+var linterRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// checkNolint reports an Options.Nolint entry that is not a linter name.
+func checkNolint(linters []string) error {
+	for _, l := range linters {
+		if !linterRe.MatchString(l) {
+			return fmt.Errorf("invalid nolint linter %q", l)
+		}
+	}
+
+	return nil
+}
+
+// addNolint puts a //nolint directive for linters on the line before the
+// package clause of src, which golangci-lint applies to the whole file.
+func addNolint(src string, linters []string) string {
+	if len(linters) == 0 {
+		return src
+	}
+
+	at := 0
+	if !strings.HasPrefix(src, "package ") {
+		at = strings.Index(src, "\npackage ") + 1
+	}
+
+	return src[:at] + "//nolint:" + strings.Join(linters, ",") + "\n" + src[at:]
+}
+
+// unstutter drops the name of package pkg from the front of the exported
+// name, the way linters flag it: users.UsersRole becomes users.Role. Names
+// that are the package name alone, or would not start with a capital
+// letter, are kept.
+func unstutter(name, pkg string) string {
+	pkg = path.Base(pkg)
+	if len(name) <= len(pkg) || !strings.HasPrefix(strings.ToLower(name), pkg) {
+		return name
+	}
+
+	rest := name[len(pkg):]
+	if rest[0] < 'A' || rest[0] > 'Z' {
+		return name
+	}
+
+	return rest
+}
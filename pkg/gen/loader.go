@@ -0,0 +1,145 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// LoaderData is the data of loader.tmpl.
+type LoaderData struct {
+	Header  string
+	Package string
+
+	// GoMinor is the minor version of Options.GoVersion.
+	GoMinor int
+
+	// Tables are the tables with a Row, in insert order.
+	Tables []LoaderTable
+}
+
+// LoaderTable is a table the fixtures package loads.
+type LoaderTable struct {
+	Schema    string
+	Name      string
+	Qualified string
+
+	// Shared is set when a table of another schema has the same name.
+	Shared bool
+
+	// Field names the table's rows in Set.
+	Field string
+
+	// Package is the name the file imports the table's package by.
+	Package string
+
+	Columns []LoaderColumn
+}
+
+// LoaderColumn is a column of a LoaderTable.
+type LoaderColumn struct {
+	ColumnData
+
+	// Required is set for a NOT NULL column the database cannot supply a
+	// value for, which every record must then set.
+	Required bool
+
+	// Array is set for an array column, which records may set to a list.
+	Array bool
+}
+
+// loaderImports are the packages loader.tmpl refers to outside stdImports.
+var loaderImports = []Import{
+	{Name: "encoding", Path: "encoding"},
+	{Name: "fs", Path: "io/fs"},
+	{Name: "yaml", Path: "gopkg.in/yaml.v3"},
+}
+
+// buildLoader renders the package of Options.FixturesPackage.
+func buildLoader(tmpl *template.Template, tables []schema.Table, opts Options) (string, error) {
+	ordered, _ := schema.InsertOrder(tables)
+
+	data := LoaderData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.FixturesPackage),
+		GoMinor: goMinorOf(opts),
+	}
+	imports := append([]Import(nil), loaderImports...)
+
+	// Tables of the same name in different schemas, and packages named like
+	// one the file imports, are told apart by their schema
+	names := make(map[string]int)
+	for _, t := range ordered {
+		names[t.Name]++
+	}
+	taken := map[string]bool{data.Package: true}
+	for _, imp := range imports {
+		taken[imp.Name] = true
+	}
+	for _, p := range stdImports {
+		taken[importName(p)] = true
+	}
+
+	for _, t := range ordered {
+		features, err := tableFeatures(t, opts)
+		if err != nil {
+			return "", err
+		}
+		if !features[FeatureRow] {
+			continue
+		}
+
+		importPath := ImportPath(t, opts)
+		if importPath == "" {
+			return "", fmt.Errorf("fixtures package imports the generated packages, set the module path of the output directory")
+		}
+
+		shared := names[t.Name] > 1
+		name := path.Base(importPath)
+		field := exported(identifierName(t.Name))
+		if shared {
+			field = exported(identifierName(t.Schema + "_" + t.Name))
+		}
+		if taken[name] || shared {
+			name = identifier(t.Schema) + name
+		}
+		taken[name] = true
+		imports = append(imports, Import{Name: name, Path: importPath})
+
+		lt := LoaderTable{
+			Schema:    t.Schema,
+			Name:      t.Name,
+			Qualified: sqlName(t, opts),
+			Shared:    shared,
+			Field:     field,
+			Package:   name,
+		}
+		for _, c := range tableData(t, name, features, opts).Columns {
+			lt.Columns = append(lt.Columns, LoaderColumn{
+				ColumnData: c,
+				Required:   !c.Nullable && c.Default == "" && !c.Generated,
+				Array:      strings.HasSuffix(c.Type, "[]"),
+			})
+		}
+		data.Tables = append(data.Tables, lt)
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "loader.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render fixtures package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), imports, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render fixtures package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.FixturesPackage, src)
+	}
+
+	return src, nil
+}
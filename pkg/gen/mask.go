@@ -0,0 +1,185 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Masking strategies of Row.Mask.
+const (
+	// MaskNull sets a nullable column to NULL.
+	MaskNull = "null"
+
+	// MaskRedact sets nullable columns to NULL, text to "REDACTED" and other
+	// values to their zero value.
+	MaskRedact = "redact"
+
+	// MaskHash replaces text with the hex SHA-256 of it, which keeps equal
+	// values equal.
+	MaskHash = "hash"
+
+	// MaskFake replaces text with a deterministic stand-in shaped after the
+	// column name: an address for email columns, a number for phone columns
+	// and a token otherwise.
+	MaskFake = "fake"
+)
+
+// Masking configures the Mask method generated on Row for anonymizing
+// production data.
+type Masking struct {
+	// Tags are the column tags (see Options.ColumnTags) whose columns are
+	// masked with Strategy. Defaults to pii.
+	Tags []string
+
+	// Strategy is the strategy of tagged columns, MaskRedact by default.
+	Strategy string
+
+	// Columns sets the strategy of the columns matching a table.column or
+	// schema.table.column pattern, tagged or not.
+	Columns map[string]string
+}
+
+// MaskedColumn is a column Row.Mask anonymizes.
+type MaskedColumn struct {
+	ColumnData
+
+	// Strategy is one of MaskNull, MaskRedact, MaskHash and MaskFake.
+	Strategy string
+}
+
+// maskedColumns returns the columns of a table Row.Mask anonymizes and
+// their strategy. Strategies a column's type cannot take are an error.
+func maskedColumns(t schema.Table, columns []ColumnData, opts Options) ([]MaskedColumn, error) {
+	m := opts.Masking
+	tags := m.Tags
+	if len(tags) == 0 {
+		tags = []string{"pii"}
+	}
+	strategy := m.Strategy
+	if strategy == "" {
+		strategy = MaskRedact
+	}
+
+	// Patterns are tried in order so the result does not depend on map order
+	patterns := make([]string, 0, len(m.Columns))
+	for p := range m.Columns {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+
+	var masked []MaskedColumn
+	for _, c := range columns {
+		s := ""
+		for _, tag := range tags {
+			if hasColumnTag(t, c.Column, opts.ColumnTags[tag]) {
+				s = strategy
+			}
+		}
+		for _, p := range patterns {
+			if hasColumnTag(t, c.Column, []string{p}) {
+				s = m.Columns[p]
+				break
+			}
+		}
+		if s == "" {
+			continue
+		}
+
+		if err := checkMaskStrategy(c, s); err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.Name, err)
+		}
+		masked = append(masked, MaskedColumn{ColumnData: c, Strategy: s})
+	}
+
+	return masked, nil
+}
+
+// checkMaskStrategy reports why a column cannot be masked with strategy.
+func checkMaskStrategy(c ColumnData, strategy string) error {
+	_, enum := enumType(c.Column)
+	text := c.ValueType == "string" && !enum
+
+	switch strategy {
+	case MaskNull:
+		if !c.Nullable {
+			return fmt.Errorf("mask strategy %s needs a nullable column", strategy)
+		}
+	case MaskRedact:
+		if enum && !c.Nullable {
+			return fmt.Errorf("mask strategy %s cannot produce a valid enum label, use null on a nullable column", strategy)
+		}
+	case MaskHash, MaskFake:
+		if !text {
+			return fmt.Errorf("mask strategy %s needs a text column", strategy)
+		}
+	default:
+		return fmt.Errorf("unknown mask strategy %q, expected one of %s", strategy, strings.Join([]string{MaskNull, MaskRedact, MaskHash, MaskFake}, ", "))
+	}
+
+	return nil
+}
+
+// MaskRows masks the rows of a table read from the database in place, like
+// Row.Mask masks a Row, for dumping fixtures. Rows hold a value per column
+// in column order as database/sql scans them into an any; see
+// FixtureTable. It does nothing without Options.Masking.
+func MaskRows(t schema.Table, rows [][]any, opts Options) error {
+	if opts.Masking == nil {
+		return nil
+	}
+
+	data := tableData(t, path.Base(PackagePath(t, opts)), nil, opts)
+	masked, err := maskedColumns(t, data.Columns, opts)
+	if err != nil {
+		return fmt.Errorf("table %s.%s: %w", t.Schema, t.Name, err)
+	}
+
+	for _, m := range masked {
+		i := slices.IndexFunc(t.Columns, func(c schema.Column) bool { return c.Name == m.Name })
+		for _, row := range rows {
+			if i >= len(row) || row[i] == nil {
+				continue
+			}
+
+			switch {
+			case m.Strategy == MaskNull, m.Strategy == MaskRedact && m.Nullable:
+				row[i] = nil
+			case m.Strategy == MaskRedact && m.ValueType == "string":
+				row[i] = "REDACTED"
+			case m.Strategy == MaskRedact:
+				row[i] = nil // the zero value of a NOT NULL column
+			case m.Strategy == MaskHash:
+				sum := sha256.Sum256([]byte(fixtureText(row[i])))
+				row[i] = hex.EncodeToString(sum[:])
+			case m.Strategy == MaskFake:
+				row[i] = maskFake(m.Name, fixtureText(row[i]))
+			}
+		}
+	}
+
+	return nil
+}
+
+// maskFake is the maskFake function of mask.tmpl, so dumped fixtures are
+// masked the same way as rows masked by Row.Mask.
+func maskFake(column, v string) string {
+	sum := sha256.Sum256([]byte(v))
+	token := hex.EncodeToString(sum[:6])
+
+	switch {
+	case strings.Contains(column, "email"):
+		return "user-" + token + "@example.com"
+	case strings.Contains(column, "phone"):
+		n := int(sum[0])<<16 | int(sum[1])<<8 | int(sum[2])
+		return fmt.Sprintf("+1555%07d", n%10000000)
+	default:
+		return token
+	}
+}
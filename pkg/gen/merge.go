@@ -0,0 +1,75 @@
+package gen
+
+import (
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// nonZeros maps column types to the expression Merge reports a value of
+// them as set with, keyed like comparableTypes.
+var nonZeros = map[string]string{
+	"bool":          "%[1]s",
+	"int16":         "%[1]s != 0",
+	"int32":         "%[1]s != 0",
+	"int64":         "%[1]s != 0",
+	"float32":       "%[1]s != 0",
+	"float64":       "%[1]s != 0",
+	"string":        `%[1]s != ""`,
+	"time.Duration": "%[1]s != 0",
+
+	"time.Time":                             "!%[1]s.IsZero()",
+	"github.com/shopspring/decimal.Decimal": "!%[1]s.IsZero()",
+	"github.com/google/uuid.UUID":           "%[1]s != [16]byte{}",
+	"encoding/json.RawMessage":              "%[1]s != nil",
+	"[]byte":                                "%[1]s != nil",
+
+	"BitString":    `%[1]s != ""`,
+	"XML":          `%[1]s != ""`,
+	"Money":        "!%[1]s.IsZero()",
+	"MoneyCents":   "%[1]s != 0",
+	"JSONMap":      "!%[1]s.IsZero()",
+	"Hstore":       "%[1]s != nil",
+	"NetAddr":      "%[1]s.IsValid()",
+	"NetPrefix":    "%[1]s.IsValid()",
+	"HardwareAddr": "%[1]s.HardwareAddr != nil",
+	"Point":        "%[1]s != (Point{})",
+	"Box":          "%[1]s != (Box{})",
+	"Circle":       "%[1]s != (Circle{})",
+	"Path":         "%[1]s.Points != nil",
+}
+
+// nonZero returns the format of the Go expression reporting whether a
+// non-NULL value of a column is set rather than its zero value, %[1]s being
+// the value. Types it knows nothing about are checked with reflect.
+func nonZero(c schema.Column, goType, importPath string) string {
+	if strings.HasPrefix(goType, "[]") {
+		return "%[1]s != nil"
+	}
+	if _, ok := enumType(c); ok && importPath == "" {
+		return `%[1]s != ""`
+	}
+
+	name := goType
+	if importPath != "" {
+		name = importPath + goType[strings.LastIndex(goType, "."):]
+	}
+	if format, ok := nonZeros[name]; ok {
+		return format
+	}
+
+	return "!reflect.ValueOf(%[1]s).IsZero()"
+}
+
+// negate returns the format of the negation of the boolean expression of
+// format, a format of nonZeros.
+func negate(format string) string {
+	switch {
+	case strings.HasPrefix(format, "!"):
+		return format[1:]
+	case strings.Contains(format, " != "):
+		return strings.Replace(format, " != ", " == ", 1)
+	}
+
+	return "!" + format
+}
@@ -0,0 +1,546 @@
+package gen
+
+import (
+	"go/token"
+	"io/fs"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+type Options struct {
+	// Types overrides Go types by PostgreSQL type ("citext") or by column
+	// ("users.metadata" or "public.users.metadata"). Values are Go types
+	// qualified by import path, e.g. "github.com/google/uuid.UUID".
+	Types map[string]string
+
+	// JSONMaps lists the json and jsonb columns holding objects, by column
+	// ("users.metadata" or "public.users.metadata") or by type ("jsonb").
+	// They map to a generated JSONMap with accessors instead of
+	// json.RawMessage.
+	JSONMaps []string
+
+	// Money is the mapping of money columns: "decimal" (default) for a
+	// generated Money type embedding decimal.Decimal, "cents" for a
+	// generated MoneyCents int64, or "string".
+	Money string
+
+	// ColumnTags groups columns under semantic tags (pii, audit), each
+	// listing path.Match patterns against table.column or
+	// schema.table.column. Every table gets a slice per tag.
+	ColumnTags map[string][]string
+
+	// Masking, when set, generates Row.Mask anonymizing the columns it
+	// selects.
+	Masking *Masking
+
+	// Initialisms are upper-cased as a whole in Go names (ID, URL).
+	Initialisms []string
+
+	// Rename maps a column ("email") or table column ("users.email") to a Go name.
+	Rename map[string]string
+
+	// PackagePrefix is prepended to every package name.
+	PackagePrefix string
+
+	// Layout is "flat" (default) or "schema", which nests packages under
+	// a directory per schema.
+	Layout string
+
+	// SearchPath, when set, leaves table names in generated SQL and name
+	// constants unqualified, so they resolve through the search_path of
+	// the session instead of naming their schema.
+	SearchPath bool
+
+	// ModulePath is the import path of the output directory, e.g.
+	// "github.com/acme/app/internal/tables". It lets generated code refer to
+	// other tables' packages; without it they cannot be imported.
+	ModulePath string
+
+	// OrderPackage, when set, generates a package at this path relative to
+	// the output directory listing the tables in foreign key order, see
+	// schema.InsertOrder.
+	OrderPackage string
+
+	// ResetPackage, when set, generates a package at this path relative to
+	// the output directory with ResetAll, which empties every table for
+	// integration tests. ResetCascade makes it also empty the tables
+	// outside the generated ones that reference them.
+	ResetPackage string
+	ResetCascade bool
+
+	// FixturesPackage, when set, generates a package at this path relative
+	// to the output directory with Load, which inserts the records of YAML
+	// or JSON fixture files into the tables with a Row. It imports the table
+	// packages, so ModulePath must be set.
+	FixturesPackage string
+
+	// StmtCachePackage, when set, generates a package at this path relative
+	// to the output directory with Cache, which prepares the statements of
+	// hand-written queries once and counts its hits.
+	StmtCachePackage string
+
+	// ReplicaPackage, when set, generates a package at this path relative
+	// to the output directory with DB, which sends reads to replicas and
+	// writes to the primary.
+	ReplicaPackage string
+
+	// RetryPackage, when set, generates a package at this path relative to
+	// the output directory with Policy, which runs queries and transactions
+	// with a timeout per attempt and retries serialization failures.
+	RetryPackage string
+
+	// Placeholder is the style of the placeholders in the SQL of Meta and
+	// of WherePackage: PlaceholderDollar (default), PlaceholderQuestion or
+	// PlaceholderNamed, or the name of a database or driver using one, such
+	// as mysql or sqlite. PostgreSQL-only SQL, such as AsOfQuery, and the
+	// fixtures package keep PlaceholderDollar.
+	Placeholder string
+
+	// WherePackage, when set, generates a package at this path relative to
+	// the output directory with Expr, which builds the conditions of WHERE
+	// clauses, and gives every table package a Where variable with a typed
+	// Column per column. The table packages import it from ModulePath, so
+	// it must be set.
+	WherePackage string
+
+	// TenantPackage, when set, generates the packages of schemas whose
+	// tables are structurally identical only once, for the first of them,
+	// and a package at this path relative to the output directory mapping
+	// tenants to their schema. The SQL of shared packages leaves table
+	// names unqualified, as with SearchPath, to run in any tenant's schema.
+	TenantPackage string
+
+	// GoVersion is the oldest Go version the generated code must build
+	// with, DefaultGoVersion when empty and at least MinGoVersion. Older
+	// versions get interface{} for any, no type parameters or slices
+	// package, and string for inet and cidr columns instead of net/netip
+	// based types.
+	GoVersion string
+
+	// Nolint lists the linters, or "all", that a //nolint directive on the
+	// package clause of every generated file turns off.
+	Nolint []string
+
+	// NoStutter drops the package name from the front of generated type
+	// names that would repeat it, such as the UsersRole enum of package
+	// users, which becomes Role.
+	NoStutter bool
+
+	// MaxLineLength, when positive, breaks generated lines longer than
+	// this many characters where Go allows it.
+	MaxLineLength int
+
+	// BuildTags is a build constraint expression, such as "!codeanalysis",
+	// put as a //go:build line on every generated file.
+	BuildTags string
+
+	// FieldOrder orders the fields of Row and of the column names struct:
+	// FieldOrderOrdinal (default), FieldOrderAlphabetical or
+	// FieldOrderPrimaryKey. Column lists, scans and everything else keep
+	// table order.
+	FieldOrder string
+
+	// Tags lists struct tag keys (json, db) added to Row fields with the
+	// column name as value.
+	Tags []string
+
+	// Features lists the artifacts generated for every table (see
+	// FeatureTypes and the other Feature constants), DefaultFeatures when
+	// empty.
+	Features []string
+
+	// TableFeatures overrides Features by table ("audit_log" or
+	// "public.audit_log"). A list made only of "+feature" and "-feature"
+	// items adds to and removes from Features; any other list replaces them.
+	// Tables left without any feature are not generated.
+	TableFeatures map[string][]string
+
+	// Notify maps tables ("users" or "public.users") with a trigger sending
+	// their rows as JSON with NOTIFY to the channel it uses. Their package
+	// gets Channel, DecodeNotification and Subscribe.
+	Notify map[string]string
+
+	// History maps tables ("users" or "public.users") to the history table
+	// keeping their past row versions ("users_history" or
+	// "audit.users_history"), or to "-" for none. Tables with a sys_period
+	// tstzrange column are paired with <table>_history without it. Their
+	// package gets HistoryTable, AsOfQuery and AsOf.
+	History map[string]string
+
+	// DTO maps tables ("users" or "public.users") to the columns of the DTO
+	// of the dto feature, or to the columns left out of it when every item
+	// is prefixed with "-". Tables not listed get every column.
+	DTO map[string][]string
+
+	// Enums documents the labels of enum types, keyed by type name
+	// ("order_status"). Their docs replace the comments of the labels in the
+	// schema.
+	Enums map[string]EnumDoc
+
+	// Sort maps tables ("users" or "public.users") to the columns OrderBy of
+	// the sort feature accepts: "column" in both directions, or "column asc"
+	// or "column desc" in one. Tables not listed sort by every column of a
+	// type with an ordering, e.g. not json.
+	Sort map[string][]string
+
+	// Filter maps tables ("users" or "public.users") to the columns Filter
+	// of the filter feature filters by. Tables not listed are filtered by
+	// the key columns of their indexes and primary key.
+	Filter map[string][]string
+
+	// Aggregates maps tables ("users" or "public.users") to the aggregates
+	// their package gets a function for: "function(column)" or "count(*)",
+	// optionally followed by "by" and columns to group by, e.g.
+	// "sum(amount) by status". The functions are count, sum, avg, min and
+	// max.
+	Aggregates map[string][]string
+
+	// Converters lists other builds of the same tables, such as other
+	// output profiles. The package of every table with a Row in both gets
+	// From<Name> and To<Name> converting its Row from and to theirs.
+	Converters []Converter
+
+	// Only, when set, limits the table packages built to the tables it
+	// returns true for, e.g. the ones changed since the last run. The other
+	// tables still count for everything spanning tables, such as
+	// OrderPackage and the imports of other tables' packages.
+	Only func(t schema.Table) bool
+
+	// NoExtraPackages leaves out the packages that are not a table's, such
+	// as WherePackage and OrderPackage, while the table packages still
+	// import them, e.g. to build tables one at a time next to extra packages
+	// built once.
+	NoExtraPackages bool
+
+	// Workers is the number of tables built concurrently. Values below 1
+	// build one table at a time.
+	Workers int
+
+	// Templates, when set, overrides the default templates: any *.tmpl file
+	// in it replaces the template of the same name (file.tmpl, imports.tmpl,
+	// types.tmpl, columns.tmpl, row.tmpl) and may add new ones.
+	Templates fs.FS
+
+	// Hooks let embedders adjust generation from Go code. Hooks may be
+	// called concurrently when Workers is above 1.
+
+	// OnTable is called for every table before generation. It may modify the
+	// table; returning false skips it.
+	OnTable func(t *schema.Table) bool
+
+	// OnColumn is called for every column of a kept table. It may modify the
+	// column; returning false leaves it out.
+	OnColumn func(t schema.Table, c *schema.Column) bool
+
+	// RenameFunc returns the Go name of a column given the name derived from
+	// Rename and Initialisms.
+	RenameFunc func(t schema.Table, c schema.Column, name string) string
+
+	// TypeMapFunc returns the Go type of a column in the form used by Types.
+	// It is consulted before Types; returning false falls back to them.
+	TypeMapFunc func(t schema.Table, c schema.Column) (string, bool)
+
+	// PostProcess rewrites the generated source of a package.
+	PostProcess func(pkg, src string) string
+
+	// shares maps the tenant schemas sharing packages to the schema they
+	// share, set by Build with TenantPackage.
+	shares map[string]string
+}
+
+// knownImports resolves the package qualifiers used by the default type
+// mapping, so overrides may also use the short "uuid.UUID" form.
+var knownImports = map[string]string{
+	"time":    "time",
+	"json":    "encoding/json",
+	"uuid":    "github.com/google/uuid",
+	"decimal": "github.com/shopspring/decimal",
+}
+
+// PackagePath returns the output path of a table's package relative to the
+// output directory. Its last element is the package name.
+func PackagePath(t schema.Table, opts Options) string {
+	name := packageName(opts.PackagePrefix + t.Name)
+
+	if opts.Layout == "schema" && t.Schema != "" {
+		return t.Schema + "/" + name
+	}
+
+	return name
+}
+
+// packageName turns a table name into a package name, that of localName
+// or, for a keyword such as type, the name with an x prefix.
+func packageName(name string) string {
+	if name = localName(name); token.IsKeyword(name) {
+		return "x" + name
+	}
+
+	return name
+}
+
+// localName turns a table name into one to build unexported identifiers
+// from: characters an identifier cannot hold become underscores, and a
+// name starting with a digit once underscores are dropped, such as 2fa,
+// gets an x prefix.
+func localName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+
+	if r, _ := utf8.DecodeRuneInString(strings.TrimLeft(name, "_")); !unicode.IsLetter(r) {
+		return "x" + name
+	}
+
+	return name
+}
+
+// ImportPath returns the import path of a table's package, or an empty
+// string without Options.ModulePath.
+func ImportPath(t schema.Table, opts Options) string {
+	if opts.ModulePath == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(opts.ModulePath, "/") + "/" + PackagePath(t, opts)
+}
+
+// columnType resolves the Go type of a column, without the pointer added for
+// nullable columns, and the import path it needs.
+func columnType(t schema.Table, c schema.Column, opts Options) (string, string) {
+	if override, ok := typeOverride(t, c, opts); ok {
+		return parseGoType(override)
+	}
+
+	if isJSONMap(t, c, opts) {
+		return "JSONMap", ""
+	}
+
+	if normalizeType(c.Type) == "money" {
+		switch opts.Money {
+		case "cents":
+			return "MoneyCents", ""
+		case "string":
+			return "string", ""
+		}
+	}
+
+	// The network types build on net/netip, which is Go 1.18
+	if t := normalizeType(c.Type); (t == "inet" || t == "cidr") && !goAtLeast(opts, 18) {
+		return "string", ""
+	}
+
+	// Enums are generated into the package, so they need no import
+	if name, ok := enumType(c); ok {
+		goType := enumGoName(t, name, opts)
+		goType = strings.Repeat("[]", strings.Count(c.Type, "[]")) + goType
+		return goType, ""
+	}
+
+	goType, _ := postgresTypeToGoType(c.Type)
+	return parseGoType(goType)
+}
+
+// isJSONMap reports whether a column is listed in Options.JSONMaps.
+func isJSONMap(t schema.Table, c schema.Column, opts Options) bool {
+	switch normalizeType(c.Type) {
+	case "json", "jsonb":
+	default:
+		return false
+	}
+
+	for _, key := range opts.JSONMaps {
+		switch key {
+		case t.Schema + "." + t.Name + "." + c.Name, t.Name + "." + c.Name, normalizeType(c.Type):
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeOverride returns the configured Go type of a column, if any.
+func typeOverride(t schema.Table, c schema.Column, opts Options) (string, bool) {
+	if opts.TypeMapFunc != nil {
+		if override, ok := opts.TypeMapFunc(t, c); ok {
+			return override, true
+		}
+	}
+
+	keys := []string{
+		t.Schema + "." + t.Name + "." + c.Name,
+		t.Name + "." + c.Name,
+		normalizeType(c.Type),
+	}
+
+	for _, key := range keys {
+		if override, ok := opts.Types[key]; ok {
+			return override, true
+		}
+	}
+
+	return "", false
+}
+
+// parseGoType splits a type such as "[]github.com/google/uuid.UUID" into the
+// type as written in code ("[]uuid.UUID") and its import path.
+func parseGoType(s string) (string, string) {
+	name := strings.TrimLeft(s, "*[]")
+	mods := s[:len(s)-len(name)]
+
+	dot := strings.LastIndex(name, ".")
+	if dot == -1 {
+		return s, ""
+	}
+
+	importPath := name[:dot]
+	if known, ok := knownImports[importPath]; ok {
+		importPath = known
+	}
+
+	return mods + importName(importPath) + "." + name[dot+1:], importPath
+}
+
+// goNames returns the Go identifiers used for the columns of t by column
+// name. Names the package of t declares for something else, such as Meta or
+// Row, get a Column suffix, as the type alias of the column would collide
+// with it.
+func goNames(t schema.Table, opts Options) map[string]string {
+	reserved := reservedNames(t, opts)
+	names := make(map[string]string, len(t.Columns))
+	for _, c := range t.Columns {
+		names[c.Name] = unreserved(baseName(t, c, opts), reserved)
+	}
+
+	return names
+}
+
+// baseName returns the Go name of a column from Rename, Initialisms and
+// RenameFunc.
+func baseName(t schema.Table, c schema.Column, opts Options) string {
+	name := defaultName(t, c, opts)
+
+	if opts.RenameFunc != nil {
+		name = opts.RenameFunc(t, c, name)
+	}
+
+	return name
+}
+
+// renamed reports whether Rename sets the Go name of a column, rather than
+// it being derived from the column name.
+func renamed(t schema.Table, c schema.Column, opts Options) bool {
+	_, ok := opts.Rename[t.Name+"."+c.Name]
+	_, all := opts.Rename[c.Name]
+
+	return ok || all
+}
+
+// defaultName derives a column's Go name from Rename and Initialisms.
+func defaultName(t schema.Table, c schema.Column, opts Options) string {
+	if name, ok := opts.Rename[t.Name+"."+c.Name]; ok {
+		return name
+	}
+	if name, ok := opts.Rename[c.Name]; ok {
+		return name
+	}
+
+	name := toPascalCase(c.Name)
+
+	// Upper-case configured initialisms, e.g. UserId -> UserID
+	if len(opts.Initialisms) > 0 {
+		var result strings.Builder
+		for _, part := range nameParts(c.Name) {
+			if isInitialism(part, opts.Initialisms) {
+				result.WriteString(strings.ToUpper(part))
+			} else {
+				result.WriteString(capitalizeFirst(part))
+			}
+		}
+		name = result.String()
+	}
+
+	return exported(name)
+}
+
+// exported returns name, or name with an X prefix when it does not start
+// with a letter, as from a column named 1st, so it is an identifier.
+func exported(name string) string {
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		return "X" + name
+	}
+
+	return name
+}
+
+// sanitized reports whether the Go name derived from a column name is not
+// just its words: the name holds characters an identifier cannot, besides
+// underscores, or does not start with a letter once they are dropped.
+func sanitized(name string) bool {
+	name = strings.TrimLeft(name, "_")
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		return true
+	}
+
+	return strings.ContainsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+func isInitialism(part string, initialisms []string) bool {
+	for _, i := range initialisms {
+		if strings.EqualFold(part, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyHooks runs OnTable and OnColumn, returning the tables to generate.
+// The input is never modified.
+func applyHooks(tables []schema.Table, opts Options) []schema.Table {
+	if opts.OnTable == nil && opts.OnColumn == nil {
+		return tables
+	}
+
+	var result []schema.Table
+	for _, t := range tables {
+		t.Columns = append([]schema.Column(nil), t.Columns...)
+
+		if opts.OnTable != nil && !opts.OnTable(&t) {
+			continue
+		}
+
+		if opts.OnColumn != nil {
+			columns := []schema.Column{}
+			for _, c := range t.Columns {
+				if opts.OnColumn(t, &c) {
+					columns = append(columns, c)
+				}
+			}
+			t.Columns = columns
+		}
+
+		result = append(result, t)
+	}
+
+	return result
+}
+
+// normalizeType lower-cases a PostgreSQL type and strips length modifiers.
+func normalizeType(pgType string) string {
+	normalizedType := strings.ToLower(strings.TrimSpace(pgType))
+
+	if idx := strings.Index(normalizedType, "("); idx != -1 {
+		normalizedType = normalizedType[:idx]
+	}
+
+	return normalizedType
+}
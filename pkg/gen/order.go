@@ -0,0 +1,58 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// OrderData is the data of order.tmpl.
+type OrderData struct {
+	Header  string
+	Package string
+
+	// Insert lists the schema.name of the tables in insert order, and
+	// Delete in the reverse order.
+	Insert []string
+	Delete []string
+
+	// Cycles are the tables referencing each other in a cycle, see
+	// schema.InsertOrder.
+	Cycles [][]string
+}
+
+// buildOrder renders the package of Options.OrderPackage.
+func buildOrder(tmpl *template.Template, tables []schema.Table, opts Options) (string, error) {
+	ordered, cycles := schema.InsertOrder(tables)
+
+	data := OrderData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.OrderPackage),
+		Cycles:  cycles,
+	}
+	for _, t := range ordered {
+		data.Insert = append(data.Insert, t.Schema+"."+t.Name)
+	}
+	data.Delete = slices.Clone(data.Insert)
+	slices.Reverse(data.Delete)
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "order.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render order package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render order package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.OrderPackage, src)
+	}
+
+	return src, nil
+}
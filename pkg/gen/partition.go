@@ -0,0 +1,389 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// PartitionData describes the partitions of a partitioned table.
+type PartitionData struct {
+	// Strategy is hash, list or range, and Key the partition key columns.
+	Strategy string
+	Key      []ColumnData
+
+	// Partitions are the partitions with the Go constant of their name.
+	Partitions []PartitionConst
+
+	// KeyType is the Go type of the key PartitionFor routes in Go, and
+	// Cases and Default the partitions it returns. KeyType is empty when
+	// the key is not a single integer, text, enum, date or timestamp
+	// column, or a bound could not be read.
+	KeyType string
+	Cases   []PartitionCase
+	Default string
+
+	// Query selects the partition of the key values $1, $2, ... of a hash
+	// partitioned table, or is empty. Params names the key parameters of
+	// the PartitionFor running it: key, or key1, key2, ... for several
+	// columns.
+	Query  string
+	Params []string
+}
+
+// PartitionConst is a partition and its Go constant.
+type PartitionConst struct {
+	GoName string
+
+	// Name is the quoted partition, qualified by its schema unless
+	// Options.SearchPath is set.
+	Name string
+
+	Bound string
+}
+
+// PartitionCase is a partition and the Go condition on key of the rows it
+// takes.
+type PartitionCase struct {
+	GoName    string
+	Condition string
+}
+
+// partitionBound is a parsed partition bound. From and To bound range
+// partitions and In lists the values of list partitions; nil values are
+// NULL, MINVALUE or MAXVALUE.
+type partitionBound struct {
+	Default            bool
+	In, From, To       []*string
+	Modulus, Remainder int
+}
+
+// partitionData returns the partitions of t with columns, or nil when t is
+// not partitioned or has no partitions.
+func partitionData(t schema.Table, columns []ColumnData, opts Options) *PartitionData {
+	p := t.Partitioning
+	if p == nil || len(p.Partitions) == 0 {
+		return nil
+	}
+
+	data := &PartitionData{Strategy: p.Strategy}
+	for _, name := range p.Key {
+		for _, c := range columns {
+			if c.Name == name {
+				data.Key = append(data.Key, c)
+			}
+		}
+	}
+
+	// Partitions of the same name in different schemas get their schema
+	// into the constant
+	names := make(map[string]int)
+	for _, part := range p.Partitions {
+		names[part.Name]++
+	}
+	for _, part := range p.Partitions {
+		goName := "Partition" + identifierName(part.Name)
+		if names[part.Name] > 1 {
+			goName = "Partition" + identifierName(part.Schema) + identifierName(part.Name)
+		}
+		data.Partitions = append(data.Partitions, PartitionConst{
+			GoName: goName,
+			Name:   sqlName(schema.Table{Schema: part.Schema, Name: part.Name}, opts),
+			Bound:  part.Bound,
+		})
+	}
+
+	if p.Strategy == "hash" {
+		data.Query = partitionQuery(t, data, opts)
+		for i := range data.Key {
+			if data.Query == "" {
+				break
+			}
+			if len(data.Key) == 1 {
+				data.Params = append(data.Params, "key")
+			} else {
+				data.Params = append(data.Params, fmt.Sprintf("key%d", i+1))
+			}
+		}
+	} else {
+		routePartitions(data)
+	}
+
+	return data
+}
+
+// routePartitions sets the KeyType, Cases and Default of data when the
+// partitions can be told apart in Go.
+func routePartitions(data *PartitionData) {
+	if len(data.Key) != 1 {
+		return
+	}
+	keyType, literal := partitionKeyType(data.Key[0].Column)
+	if keyType == "" {
+		return
+	}
+
+	var cases []PartitionCase
+	var def string
+	for _, part := range data.Partitions {
+		bound, err := parsePartitionBound(part.Bound)
+		if err != nil {
+			return
+		}
+		if bound.Default {
+			def = part.GoName
+			continue
+		}
+
+		var conditions []string
+		switch data.Strategy {
+		case "list":
+			var values []string
+			for _, v := range bound.In {
+				// NULL never matches a key
+				if v == nil {
+					continue
+				}
+				lit, ok := literal(*v)
+				if !ok {
+					return
+				}
+				values = append(values, partitionCompare(keyType, "==", lit))
+			}
+			if len(values) == 0 {
+				continue
+			}
+			conditions = append(conditions, strings.Join(values, " || "))
+		case "range":
+			if len(bound.From) != 1 || len(bound.To) != 1 {
+				return
+			}
+			if v := bound.From[0]; v != nil {
+				lit, ok := literal(*v)
+				if !ok {
+					return
+				}
+				conditions = append(conditions, partitionCompare(keyType, ">=", lit))
+			}
+			if v := bound.To[0]; v != nil {
+				lit, ok := literal(*v)
+				if !ok {
+					return
+				}
+				conditions = append(conditions, partitionCompare(keyType, "<", lit))
+			}
+			if len(conditions) == 0 {
+				conditions = append(conditions, "true")
+			}
+		default:
+			return
+		}
+
+		cases = append(cases, PartitionCase{GoName: part.GoName, Condition: strings.Join(conditions, " && ")})
+	}
+
+	data.KeyType = keyType
+	data.Cases = cases
+	data.Default = def
+}
+
+// partitionKeyType returns the Go type PartitionFor takes a key column as,
+// and the function turning a bound value into a Go literal of that type. The
+// type is empty for columns it cannot route.
+func partitionKeyType(c schema.Column) (string, func(string) (string, bool)) {
+	if len(c.Enum) > 0 {
+		return "string", stringLiteral
+	}
+
+	switch normalizeType(c.Type) {
+	case "smallint", "integer", "bigint", "int2", "int4", "int8":
+		return "int64", func(v string) (string, bool) {
+			n, err := strconv.ParseInt(v, 10, 64)
+			return strconv.FormatInt(n, 10), err == nil
+		}
+	case "text", "character varying", "varchar", "citext":
+		return "string", stringLiteral
+	case "date":
+		return "time.Time", timeLiteral("2006-01-02")
+	case "timestamp", "timestamp without time zone":
+		return "time.Time", timeLiteral("2006-01-02 15:04:05.999999")
+	case "timestamptz", "timestamp with time zone":
+		return "time.Time", timeLiteral("2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05.999999-07:00", "2006-01-02 15:04:05.999999-07:00:00")
+	}
+
+	return "", nil
+}
+
+func stringLiteral(v string) (string, bool) {
+	return strconv.Quote(v), true
+}
+
+// timeLiteral returns a function turning a value in one of layouts into a
+// time.Date call in UTC, values without a time zone being taken as UTC.
+func timeLiteral(layouts ...string) func(string) (string, bool) {
+	return func(v string) (string, bool) {
+		for _, layout := range layouts {
+			t, err := time.Parse(layout, v)
+			if err != nil {
+				continue
+			}
+			t = t.UTC()
+			return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, time.UTC)",
+				t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()), true
+		}
+		return "", false
+	}
+}
+
+// partitionCompare returns the Go expression comparing key to lit with op,
+// one of ==, >= and <.
+func partitionCompare(keyType, op, lit string) string {
+	if keyType != "time.Time" {
+		return "key " + op + " " + lit
+	}
+
+	switch op {
+	case "==":
+		return "key.Equal(" + lit + ")"
+	case ">=":
+		return "!key.Before(" + lit + ")"
+	}
+	return "key.Before(" + lit + ")"
+}
+
+// partitionQuery returns the query selecting the hash partition of t that
+// the key values $1, $2, ... go to, or empty when a bound could not be read
+// or the key has expressions.
+func partitionQuery(t schema.Table, data *PartitionData, opts Options) string {
+	if len(data.Key) == 0 || len(data.Key) != len(t.Partitioning.Key) {
+		return ""
+	}
+
+	var values []string
+	for _, part := range data.Partitions {
+		bound, err := parsePartitionBound(part.Bound)
+		if err != nil || bound.Default || bound.Modulus == 0 {
+			return ""
+		}
+		values = append(values, fmt.Sprintf("('%s', %d, %d)", strings.ReplaceAll(part.Name, "'", "''"), bound.Modulus, bound.Remainder))
+	}
+
+	args := []string{"'" + strings.ReplaceAll(sqlName(t, opts), "'", "''") + "'::regclass", "p.modulus", "p.remainder"}
+	for i, c := range data.Key {
+		args = append(args, fmt.Sprintf("$%d::%s", i+1, c.Type))
+	}
+
+	return "SELECT p.name FROM (VALUES " + strings.Join(values, ", ") + ") p(name, modulus, remainder)" +
+		" WHERE satisfies_hash_partition(" + strings.Join(args, ", ") + ")"
+}
+
+// parsePartitionBound parses a bound as pg_get_expr prints it: DEFAULT,
+// FOR VALUES IN (...), FOR VALUES FROM (...) TO (...) or FOR VALUES WITH
+// (modulus m, remainder r).
+func parsePartitionBound(bound string) (partitionBound, error) {
+	var b partitionBound
+	if bound == "DEFAULT" {
+		b.Default = true
+		return b, nil
+	}
+
+	rest, ok := strings.CutPrefix(bound, "FOR VALUES ")
+	if !ok {
+		return b, fmt.Errorf("unknown partition bound %q", bound)
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(rest, "IN "):
+		b.In, rest, err = boundValues(strings.TrimPrefix(rest, "IN "))
+	case strings.HasPrefix(rest, "FROM "):
+		if b.From, rest, err = boundValues(strings.TrimPrefix(rest, "FROM ")); err == nil {
+			rest, ok = strings.CutPrefix(rest, " TO ")
+			if !ok {
+				return b, fmt.Errorf("unknown partition bound %q", bound)
+			}
+			b.To, rest, err = boundValues(rest)
+		}
+	case strings.HasPrefix(rest, "WITH "):
+		_, err = fmt.Sscanf(strings.TrimPrefix(rest, "WITH "), "(modulus %d, remainder %d)", &b.Modulus, &b.Remainder)
+		rest = ""
+	default:
+		return b, fmt.Errorf("unknown partition bound %q", bound)
+	}
+	if err == nil && rest != "" {
+		err = fmt.Errorf("unexpected %q after partition bound", rest)
+	}
+
+	return b, err
+}
+
+// boundValues parses the parenthesized list of values at the start of s and
+// returns it with the rest of s. Quoted values are unquoted and NULL,
+// MINVALUE and MAXVALUE are nil.
+func boundValues(s string) ([]*string, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, s, fmt.Errorf("missing ( in partition bound %q", s)
+	}
+
+	var values []*string
+	i := 1
+	for {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("unterminated partition bound %q", s)
+		}
+
+		var value string
+		if s[i] == '\'' {
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, "", fmt.Errorf("unterminated string in partition bound %q", s)
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						i++
+					} else {
+						i++
+						break
+					}
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			values = append(values, &value)
+		} else {
+			end := strings.IndexAny(s[i:], ",)")
+			if end == -1 {
+				return nil, "", fmt.Errorf("unterminated partition bound %q", s)
+			}
+			value = strings.TrimSpace(s[i : i+end])
+			i += end
+			switch strings.ToUpper(value) {
+			case "NULL", "MINVALUE", "MAXVALUE":
+				values = append(values, nil)
+			default:
+				values = append(values, &value)
+			}
+		}
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("unterminated partition bound %q", s)
+		}
+		if s[i] == ')' {
+			return values, s[i+1:], nil
+		}
+		if s[i] != ',' {
+			return nil, "", fmt.Errorf("unexpected %q in partition bound %q", s[i], s)
+		}
+		i++
+	}
+}
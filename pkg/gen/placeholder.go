@@ -0,0 +1,64 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Placeholder styles of the SQL the generated code builds, see
+// Options.Placeholder.
+const (
+	PlaceholderDollar   = "dollar"   // $1, $2, ... as PostgreSQL drivers take them
+	PlaceholderQuestion = "question" // ? as MySQL and SQLite drivers take them
+	PlaceholderNamed    = "named"    // :p1, :p2, ... with sql.Named arguments
+)
+
+// placeholderAliases maps the names of databases and their drivers to their
+// placeholder style.
+var placeholderAliases = map[string]string{
+	"postgres":   PlaceholderDollar,
+	"postgresql": PlaceholderDollar,
+	"pgx":        PlaceholderDollar,
+	"pq":         PlaceholderDollar,
+	"mysql":      PlaceholderQuestion,
+	"mariadb":    PlaceholderQuestion,
+	"sqlite":     PlaceholderQuestion,
+	"sqlite3":    PlaceholderQuestion,
+}
+
+// placeholderStyle returns the style of Options.Placeholder, a style or the
+// name of a database or driver, PlaceholderDollar when it is empty.
+func placeholderStyle(name string) (string, error) {
+	switch name {
+	case "":
+		return PlaceholderDollar, nil
+	case PlaceholderDollar, PlaceholderQuestion, PlaceholderNamed:
+		return name, nil
+	}
+	if style, ok := placeholderAliases[strings.ToLower(name)]; ok {
+		return style, nil
+	}
+
+	return "", fmt.Errorf("unknown placeholder style %q, expected one of %s, or a database such as postgres, mysql or sqlite", name, strings.Join([]string{PlaceholderDollar, PlaceholderQuestion, PlaceholderNamed}, ", "))
+}
+
+// placeholderOf returns the placeholder style of opts, which Build checked.
+func placeholderOf(opts Options) string {
+	style, err := placeholderStyle(opts.Placeholder)
+	if err != nil {
+		return PlaceholderDollar
+	}
+	return style
+}
+
+// placeholder returns the nth placeholder of style, starting at 1.
+func placeholder(style string, n int) string {
+	switch style {
+	case PlaceholderQuestion:
+		return "?"
+	case PlaceholderNamed:
+		return ":p" + strconv.Itoa(n)
+	}
+	return "$" + strconv.Itoa(n)
+}
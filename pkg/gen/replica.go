@@ -0,0 +1,38 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// ReplicaData is the data of replica.tmpl.
+type ReplicaData struct {
+	Header  string
+	Package string
+}
+
+// buildReplica renders the package of Options.ReplicaPackage.
+func buildReplica(tmpl *template.Template, opts Options) (string, error) {
+	data := ReplicaData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.ReplicaPackage),
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "replica.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render replica package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render replica package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.ReplicaPackage, src)
+	}
+
+	return src, nil
+}
@@ -0,0 +1,132 @@
+package gen
+
+import "github.com/mymyka/tables/pkg/schema"
+
+// featureNames are the identifiers the features declare in the package of
+// every table generated with them.
+var featureNames = map[string][]string{
+	FeatureColumns: {"C", "Table", "Schema", "QualifiedName", "Column", "AllColumns", "QuoteIdentifier"},
+	FeatureMeta:    {"Meta"},
+	FeatureRow:     {"Row", "Validator", "Validate", "Merge"},
+	FeaturePgx:     {"RowTo", "RowToAddr", "QueueRows"},
+	FeatureDTO:     {"DTO", "ToDTO", "FromDTO"},
+	FeatureSort:    {"SortDirection", "SortAsc", "SortDesc", "SortColumns", "OrderBy"},
+	FeatureFilter:  {"Filter"},
+	FeatureKeys:    {"Querier"},
+}
+
+// helperNames are the identifiers the templates of helperTypes declare.
+var helperNames = map[string][]string{
+	"jsonmap":      {"JSONMap"},
+	"hstore":       {"Hstore"},
+	"money":        {"Money", "MoneyCents"},
+	"moneycents":   {"Money", "MoneyCents"},
+	"bitstring":    {"BitString", "BitStringFromUint64"},
+	"netaddr":      {"NetAddr", "NetPrefix", "HardwareAddr"},
+	"netprefix":    {"NetAddr", "NetPrefix", "HardwareAddr"},
+	"hardwareaddr": {"NetAddr", "NetPrefix", "HardwareAddr"},
+	"point":        {"Point", "Box", "Circle", "Path"},
+	"box":          {"Point", "Box", "Circle", "Path"},
+	"circle":       {"Point", "Box", "Circle", "Path"},
+	"path":         {"Point", "Box", "Circle", "Path"},
+	"xml":          {"XML"},
+}
+
+// reservedNames returns the identifiers the package of t declares besides
+// the type aliases of its columns, which the aliases must not take, or
+// nil without the types feature. History tables are taken for any table
+// with a tstzrange column, as pairing them needs the other tables.
+func reservedNames(t schema.Table, opts Options) map[string]bool {
+	features, err := tableFeatures(t, opts)
+	if err != nil || !features[FeatureTypes] {
+		return nil
+	}
+
+	reserved := make(map[string]bool)
+	reserve := func(names ...string) {
+		for _, name := range names {
+			reserved[name] = true
+		}
+	}
+
+	for f := range features {
+		reserve(featureNames[f]...)
+	}
+	if features[FeatureRow] && features[FeatureColumns] {
+		reserve("Change", "Changes", "Diff")
+	}
+	if features[FeatureColumns] {
+		for tag := range opts.ColumnTags {
+			reserve(tagGoName(tag, opts) + "Columns")
+		}
+		if len(opts.ColumnTags) > 0 {
+			reserve("TaggedColumns")
+		}
+		// Column constants are named after the columns
+		for _, c := range t.Columns {
+			reserve("Col" + baseName(t, c, opts))
+		}
+	}
+	if opts.WherePackage != "" {
+		reserve("Where")
+	}
+
+	_, notify := opts.Notify[t.Schema+"."+t.Name]
+	if _, ok := opts.Notify[t.Name]; ok {
+		notify = true
+	}
+	if features[FeatureRow] && notify {
+		reserve("Channel", "DecodeNotification", "Subscribe")
+	}
+
+	history, ok := opts.History[t.Schema+"."+t.Name]
+	if !ok {
+		history, ok = opts.History[t.Name]
+	}
+	for _, c := range t.Columns {
+		if c.Type == "tstzrange" {
+			ok = true
+		}
+	}
+	if features[FeatureRow] && ok && history != "-" {
+		reserve("HistoryTable", "AsOfQuery", "AsOf", "Querier", "QueueAsOf")
+	}
+
+	if p := t.Partitioning; p != nil {
+		reserve("PartitionFor", "PartitionQuery", "Querier")
+		for _, part := range p.Partitions {
+			reserve("Partition"+identifierName(part.Name), "Partition"+identifierName(part.Schema)+identifierName(part.Name))
+		}
+	}
+	if len(opts.Aggregates[t.Schema+"."+t.Name]) > 0 || len(opts.Aggregates[t.Name]) > 0 {
+		reserve("Querier")
+	}
+
+	for _, c := range t.Columns {
+		// The types of enums are named after the columns in turn
+		if _, ok := enumType(c); ok {
+			if _, ok := typeOverride(t, c, opts); !ok {
+				continue
+			}
+		}
+		goType, importPath := columnType(t, c, opts)
+		if helper, ok := columnHelper(goType, importPath); ok {
+			reserve(helperNames[helper]...)
+		}
+	}
+
+	return reserved
+}
+
+// reservedSuffix is appended to the Go names of columns that are
+// reservedNames.
+const reservedSuffix = "Column"
+
+// unreserved returns name, suffixed with reservedSuffix until it is none of
+// reserved.
+func unreserved(name string, reserved map[string]bool) string {
+	for reserved[name] {
+		name += reservedSuffix
+	}
+	return name
+}
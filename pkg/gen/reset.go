@@ -0,0 +1,65 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// ResetData is the data of reset.tmpl.
+type ResetData struct {
+	Header  string
+	Package string
+
+	// Tables are the quoted, schema-qualified names of the tables in delete
+	// order.
+	Tables []string
+
+	// Cascade is Options.ResetCascade.
+	Cascade bool
+
+	// Statement is the TRUNCATE statement of ResetAll, or empty without
+	// tables.
+	Statement string
+}
+
+// buildReset renders the package of Options.ResetPackage.
+func buildReset(tmpl *template.Template, tables []schema.Table, opts Options) (string, error) {
+	ordered, _ := schema.InsertOrder(tables)
+	slices.Reverse(ordered)
+
+	data := ResetData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.ResetPackage),
+		Cascade: opts.ResetCascade,
+	}
+	for _, t := range ordered {
+		data.Tables = append(data.Tables, sqlName(t, opts))
+	}
+	if len(data.Tables) > 0 {
+		data.Statement = "TRUNCATE " + strings.Join(data.Tables, ", ") + " RESTART IDENTITY"
+		if data.Cascade {
+			data.Statement += " CASCADE"
+		}
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "reset.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render reset package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render reset package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.ResetPackage, src)
+	}
+
+	return src, nil
+}
@@ -0,0 +1,38 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// RetryData is the data of retry.tmpl.
+type RetryData struct {
+	Header  string
+	Package string
+}
+
+// buildRetry renders the package of Options.RetryPackage.
+func buildRetry(tmpl *template.Template, opts Options) (string, error) {
+	data := RetryData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.RetryPackage),
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "retry.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render retry package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), []Import{{Name: "rand", Path: "math/rand"}}, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render retry package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.RetryPackage, src)
+	}
+
+	return src, nil
+}
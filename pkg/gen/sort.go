@@ -0,0 +1,86 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// unsortableTypes are the PostgreSQL types without a default ordering, or
+// none a client would sort a list by, which OrderBy leaves out unless
+// Options.Sort lists them.
+var unsortableTypes = map[string]bool{
+	"json":     true,
+	"jsonb":    true,
+	"xml":      true,
+	"hstore":   true,
+	"point":    true,
+	"line":     true,
+	"lseg":     true,
+	"box":      true,
+	"path":     true,
+	"polygon":  true,
+	"circle":   true,
+	"tsvector": true,
+	"tsquery":  true,
+}
+
+// SortColumn is a column OrderBy sorts by.
+type SortColumn struct {
+	ColumnData
+
+	// Asc and Desc are set for the directions the column sorts in.
+	Asc  bool
+	Desc bool
+}
+
+// sortColumns returns the columns of t that OrderBy accepts, in table
+// order: those of the entry of Options.Sort, "column" for both directions
+// or "column asc" and "column desc" for one, or every column of a type with
+// an ordering. Names of other columns are ignored, as entries keyed by name
+// apply to the tables of that name in every schema.
+func sortColumns(t schema.Table, columns []ColumnData, opts Options) ([]SortColumn, error) {
+	entry, ok := opts.Sort[t.Schema+"."+t.Name]
+	if !ok {
+		entry, ok = opts.Sort[t.Name]
+	}
+
+	listed := make(map[string]SortColumn)
+	for _, item := range entry {
+		fields := strings.Fields(item)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid sort column %q, expected a column optionally followed by asc or desc", item)
+		}
+
+		s := listed[fields[0]]
+		switch {
+		case len(fields) == 1:
+			s.Asc, s.Desc = true, true
+		case strings.EqualFold(fields[1], "asc"):
+			s.Asc = true
+		case strings.EqualFold(fields[1], "desc"):
+			s.Desc = true
+		default:
+			return nil, fmt.Errorf("invalid sort direction %q of column %s, expected asc or desc", fields[1], fields[0])
+		}
+		listed[fields[0]] = s
+	}
+
+	var sortable []SortColumn
+	for _, c := range columns {
+		s, found := listed[c.Name]
+		switch {
+		case ok && !found:
+			continue
+		case !ok && unsortableTypes[normalizeType(strings.TrimRight(c.Type, "[]"))]:
+			continue
+		case !ok:
+			s.Asc, s.Desc = true, true
+		}
+		s.ColumnData = c
+		sortable = append(sortable, s)
+	}
+
+	return sortable, nil
+}
@@ -0,0 +1,42 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// StmtCacheData is the data of stmtcache.tmpl.
+type StmtCacheData struct {
+	Header  string
+	Package string
+
+	// GoMinor is the minor version of Options.GoVersion.
+	GoMinor int
+}
+
+// buildStmtCache renders the package of Options.StmtCachePackage.
+func buildStmtCache(tmpl *template.Template, opts Options) (string, error) {
+	data := StmtCacheData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.StmtCachePackage),
+		GoMinor: goMinorOf(opts),
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "stmtcache.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render statement cache package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), []Import{{Name: "sync", Path: "sync"}, {Name: "atomic", Path: "sync/atomic"}}, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render statement cache package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.StmtCachePackage, src)
+	}
+
+	return src, nil
+}
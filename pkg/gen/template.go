@@ -0,0 +1,367 @@
+package gen
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// templatesFS holds the default templates. file.tmpl renders a whole package
+// and includes the others by file name.
+//
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// defaultTemplates are parsed once; Build clones them to apply overrides.
+var defaultTemplates = template.Must(template.New("").ParseFS(templatesFS, "templates/*.tmpl"))
+
+// TableData is passed to the templates for every table.
+type TableData struct {
+	// Header is the generated code marker line, without a newline.
+	Header string
+
+	// Hash is the schema.Table.Hash of the table, stamped into the header
+	// so a file shows which table definition it was generated from.
+	Hash string
+
+	// Package is the package name.
+	Package string
+
+	// ImportPath is the import path of the package, empty without
+	// Options.ModulePath.
+	ImportPath string
+
+	// Packages holds the import path of every generated table's package by
+	// schema.name, and by name when no other schema has a table of that
+	// name, e.g. {{index .Packages "users"}}. Empty without
+	// Options.ModulePath.
+	Packages map[string]string
+
+	Table schema.Table
+
+	// QualifiedName is the table name quoted and qualified by its schema,
+	// e.g. "analytics"."events", or only quoted with Options.SearchPath.
+	QualifiedName string
+
+	// SearchPath is set with Options.SearchPath.
+	SearchPath bool
+
+	// Placeholder is the placeholder style of Options.Placeholder, e.g.
+	// PlaceholderDollar.
+	Placeholder string
+
+	// GoMinor is the minor version of Options.GoVersion, for templates to
+	// leave out newer language and library features, e.g.
+	// {{if ge .GoMinor 18}}.
+	GoMinor int
+
+	// Imports lists the packages the column types need: standard library
+	// first, each group sorted by path. Colliding names are aliased.
+	Imports []Import
+
+	Columns []ColumnData
+
+	// Fields lists the columns in the order of struct fields, see
+	// Options.FieldOrder.
+	Fields []ColumnData
+
+	// Enums are the enum types of the columns, generated into the package.
+	Enums []EnumData
+
+	// Helpers holds the helper types the columns need generated into the
+	// package, e.g. {{if .Helpers.hstore}}.
+	Helpers map[string]bool
+
+	// PrimaryKey lists the primary key columns in key order.
+	PrimaryKey []ColumnData
+
+	// ColumnTags lists every tag of Options.ColumnTags by name, with the
+	// table's columns that carry it.
+	ColumnTags []ColumnTagData
+
+	// Masking is set when Options.Masking is, and Masked then lists the
+	// columns Row.Mask anonymizes.
+	Masking bool
+	Masked  []MaskedColumn
+
+	// DTO lists the fields of the DTO struct with the dto feature.
+	DTO []DTOField
+
+	// Sort lists the columns OrderBy sorts by, with the sort feature.
+	Sort []SortColumn
+
+	// Filter lists the fields of the Filter struct with the filter
+	// feature.
+	Filter []FilterField
+
+	// Keys lists the primary key and unique indexes with the keys
+	// feature.
+	Keys []KeyData
+
+	// Aggregates lists the functions of the table's Options.Aggregates.
+	Aggregates []AggregateData
+
+	// Where is the import path of the package of Options.WherePackage,
+	// which the Where variable refers to, or empty.
+	Where string
+
+	// Converters lists the functions converting Row from and to the Rows of
+	// Options.Converters.
+	Converters []ConverterData
+
+	// Features holds the features generated for the table, e.g.
+	// {{if .Features.row}}.
+	Features map[string]bool
+
+	// ColumnNamesType names the struct type of the C variable.
+	ColumnNamesType string
+
+	// NotifyChannel is the channel of Options.Notify for the table, or
+	// empty.
+	NotifyChannel string
+
+	// History describes the table's history table, or is nil for a table
+	// without one.
+	History *HistoryData
+
+	// Partitioning describes the partitions of a partitioned table, or is
+	// nil for other tables.
+	Partitioning *PartitionData
+}
+
+// ColumnData describes a column as generated.
+type ColumnData struct {
+	schema.Column
+
+	// GoName is the Go identifier of the column's type alias and Row field.
+	GoName string
+
+	// GoType is the Go type, a pointer for nullable columns.
+	GoType string
+
+	// ValueType is GoType without the pointer of nullable columns.
+	ValueType string
+
+	// Tags is the rendered struct tag of the Row field including its
+	// leading space, or empty.
+	Tags string
+
+	// DefaultValue is the Go constant of the column's default when it is a
+	// plain literal, e.g. "pending", or empty.
+	DefaultValue string
+
+	// Differ is the format of the expression Changes reports two non-NULL
+	// values of the column as different with, %[1]s and %[2]s being the
+	// values.
+	Differ string
+
+	// NonZero is the format of the expression Merge reports a non-NULL value
+	// of the column as set with, %[1]s being the value.
+	NonZero string
+
+	// Zero is the negation of NonZero, the format of the expression IsZero
+	// reports a non-NULL value of the column as unset with.
+	Zero string
+}
+
+// helperTypes maps the Go types generated into a package when a column
+// needs them to the name of their helper template.
+var helperTypes = map[string]string{
+	"JSONMap":      "jsonmap",
+	"Hstore":       "hstore",
+	"Money":        "money",
+	"MoneyCents":   "moneycents",
+	"BitString":    "bitstring",
+	"NetAddr":      "netaddr",
+	"NetPrefix":    "netprefix",
+	"HardwareAddr": "hardwareaddr",
+	"Point":        "point",
+	"Box":          "box",
+	"Circle":       "circle",
+	"Path":         "path",
+	"XML":          "xml",
+}
+
+// helperImports lists the packages outside stdImports a helper template
+// refers to. Templates qualify them with TableData.ImportName.
+var helperImports = map[string][]string{
+	"money":        {"github.com/shopspring/decimal"},
+	"netaddr":      {"net/netip"},
+	"netprefix":    {"net/netip"},
+	"hardwareaddr": {"net"},
+	"xml":          {"encoding/xml"},
+}
+
+// columnHelper returns the helper template of a resolved column type, if it
+// is generated into the package.
+func columnHelper(goType, importPath string) (string, bool) {
+	if importPath != "" {
+		return "", false
+	}
+
+	helper, ok := helperTypes[strings.TrimLeft(goType, "*[]")]
+	return helper, ok
+}
+
+// ImportName returns the name the file refers to an imported package by,
+// which differs from the package's own name when it was aliased.
+func (d TableData) ImportName(importPath string) string {
+	for _, imp := range d.Imports {
+		if imp.Path == importPath {
+			return imp.Name
+		}
+	}
+
+	return importName(importPath)
+}
+
+// qualifiedName returns the quoted name of t, qualified by its schema when
+// it has one.
+func qualifiedName(t schema.Table) string {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+
+	if t.Schema == "" {
+		return quote(t.Name)
+	}
+	return quote(t.Schema) + "." + quote(t.Name)
+}
+
+// unqualified reports whether generated SQL leaves the name of t
+// unqualified: with Options.SearchPath, and for the tables of the tenant
+// schemas sharing packages, whose SQL runs in the schema of every tenant.
+func unqualified(t schema.Table, opts Options) bool {
+	_, shared := opts.shares[t.Schema]
+	return opts.SearchPath || shared
+}
+
+// sqlName returns the name generated SQL refers to t by: its qualifiedName,
+// or its quoted name alone with Options.SearchPath and in the packages
+// tenant schemas share.
+func sqlName(t schema.Table, opts Options) string {
+	if unqualified(t, opts) {
+		t.Schema = ""
+	}
+	return qualifiedName(t)
+}
+
+// loadTemplates returns the default templates with any *.tmpl file in
+// overrides replacing the template of the same name.
+func loadTemplates(overrides fs.FS) (*template.Template, error) {
+	if overrides == nil {
+		return defaultTemplates, nil
+	}
+
+	tmpl, err := defaultTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := fs.Glob(overrides, "*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	if len(matches) == 0 {
+		return tmpl, nil
+	}
+
+	if tmpl, err = tmpl.ParseFS(overrides, matches...); err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// tableData prepares the template data of a table.
+func tableData(t schema.Table, pkg string, features map[string]bool, opts Options) TableData {
+	data := TableData{
+		Header:          strings.TrimSuffix(Header(), "\n"),
+		Hash:            t.Hash(),
+		Package:         pkg,
+		ImportPath:      ImportPath(t, opts),
+		Table:           t,
+		QualifiedName:   sqlName(t, opts),
+		SearchPath:      unqualified(t, opts),
+		GoMinor:         goMinorOf(opts),
+		Placeholder:     placeholderOf(opts),
+		Imports:         buildImports(t, opts),
+		ColumnNamesType: localName(t.Name) + "ColumnNames",
+		Features:        features,
+		Enums:           buildEnums(t, opts),
+		Helpers:         make(map[string]bool),
+		Where:           whereImport(opts),
+	}
+	if channel, ok := opts.Notify[t.Schema+"."+t.Name]; ok {
+		data.NotifyChannel = channel
+	} else {
+		data.NotifyChannel = opts.Notify[t.Name]
+	}
+
+	// RowTo refers to pgx, and pgx.RowToStructByName to db tags
+	tags := opts.Tags
+	if features[FeaturePgx] {
+		data.Imports = append(data.Imports, Import{Name: "pgx", Path: "github.com/jackc/pgx/v5"})
+		if !slices.Contains(tags, "db") {
+			tags = append(slices.Clone(tags), "db")
+		}
+	}
+
+	names := goNames(t, opts)
+	for _, c := range t.Columns {
+		goType, importPath := columnType(t, c, opts)
+		diff := differ(c, goType, importPath, opts)
+		set := nonZero(c, goType, importPath)
+		goType = qualify(goType, importPath, data.Imports)
+		if helper, ok := columnHelper(goType, importPath); ok {
+			data.Helpers[helper] = true
+		}
+		valueType := goType
+		if c.Nullable {
+			goType = "*" + goType
+		}
+
+		data.Columns = append(data.Columns, ColumnData{
+			Column:       c,
+			GoName:       names[c.Name],
+			GoType:       goType,
+			ValueType:    valueType,
+			Tags:         buildTags(c, tags),
+			DefaultValue: defaultValue(c, valueType),
+			Differ:       diff,
+			NonZero:      set,
+			Zero:         negate(set),
+		})
+	}
+
+	// A Default<Column> constant would collide with a column of that name
+	taken := make(map[string]bool)
+	for _, c := range data.Columns {
+		taken[c.GoName] = true
+	}
+	for i, c := range data.Columns {
+		if taken["Default"+c.GoName] {
+			data.Columns[i].DefaultValue = ""
+		}
+	}
+
+	data.Fields = orderFields(data.Columns, opts.FieldOrder)
+	data.Partitioning = partitionData(t, data.Columns, opts)
+
+	for _, c := range data.Columns {
+		if c.PrimaryKey > 0 {
+			data.PrimaryKey = append(data.PrimaryKey, c)
+		}
+	}
+	sort.SliceStable(data.PrimaryKey, func(i, j int) bool {
+		return data.PrimaryKey[i].PrimaryKey < data.PrimaryKey[j].PrimaryKey
+	})
+
+	return data
+}
@@ -0,0 +1,130 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// TenantData is the data of tenant.tmpl.
+type TenantData struct {
+	Header  string
+	Package string
+
+	// Schemas lists every schema sharing the packages of another, in
+	// order, with that schema.
+	Schemas []TenantSchema
+}
+
+// TenantSchema is a tenant schema and the schema whose packages it shares,
+// which may be itself.
+type TenantSchema struct {
+	Name   string
+	Shares string
+}
+
+// tenantSchemas groups the schemas whose tables are structurally identical,
+// returning the schema whose packages each shares by name. Only schemas
+// sharing with at least one other are included; the first in order of each
+// group shares its own.
+func tenantSchemas(tables []schema.Table) map[string]string {
+	shapes := make(map[string][]string)
+	for _, t := range tables {
+		shapes[t.Schema] = append(shapes[t.Schema], t.Name+":"+tenantShape(t))
+	}
+
+	var names []string
+	for name := range shapes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	first := make(map[string]string)
+	shares := make(map[string]string)
+	for _, name := range names {
+		shape := shapes[name]
+		slices.Sort(shape)
+		key := strings.Join(shape, ",")
+
+		if f, ok := first[key]; ok {
+			shares[f] = f
+			shares[name] = f
+		} else {
+			first[key] = name
+		}
+	}
+
+	return shares
+}
+
+// tenantShape returns the hash of t with its schema left out, references
+// to its own schema included.
+func tenantShape(t schema.Table) string {
+	own := t.Schema
+	t.Schema = ""
+	t.ForeignKeys = slices.Clone(t.ForeignKeys)
+	for i, fk := range t.ForeignKeys {
+		if fk.RefSchema == own {
+			t.ForeignKeys[i].RefSchema = ""
+		}
+	}
+	if t.Partitioning != nil {
+		p := *t.Partitioning
+		p.Partitions = slices.Clone(p.Partitions)
+		for i, part := range p.Partitions {
+			if part.Schema == own {
+				p.Partitions[i].Schema = ""
+			}
+		}
+		t.Partitioning = &p
+	}
+
+	return t.Hash()
+}
+
+// dedupeTenants leaves out the tables of the schemas sharing the packages
+// of another schema.
+func dedupeTenants(tables []schema.Table, shares map[string]string) []schema.Table {
+	var result []schema.Table
+	for _, t := range tables {
+		if s, ok := shares[t.Schema]; !ok || s == t.Schema {
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
+
+// buildTenants renders the package of Options.TenantPackage.
+func buildTenants(tmpl *template.Template, shares map[string]string, opts Options) (string, error) {
+	data := TenantData{
+		Header:  strings.TrimSuffix(Header(), "\n"),
+		Package: path.Base(opts.TenantPackage),
+	}
+	for name, s := range shares {
+		data.Schemas = append(data.Schemas, TenantSchema{Name: name, Shares: s})
+	}
+	slices.SortFunc(data.Schemas, func(a, b TenantSchema) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "tenant.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render tenant package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render tenant package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.TenantPackage, src)
+	}
+
+	return src, nil
+}
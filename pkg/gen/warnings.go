@@ -0,0 +1,141 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// Kinds of Warning.
+const (
+	WarnUnmappedType        = "unmapped_type"
+	WarnNameCollision       = "name_collision"
+	WarnTruncatedIdentifier = "truncated_identifier"
+	WarnInvalidIdentifier   = "invalid_identifier"
+)
+
+// maxIdentifier is the length in bytes PostgreSQL truncates identifiers to,
+// NAMEDATALEN - 1.
+const maxIdentifier = 63
+
+// Warning is something generation got past that may not give the code
+// expected, such as a column type with no Go mapping.
+type Warning struct {
+	Kind    string `json:"kind"`
+	Table   string `json:"table,omitempty"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// FindWarnings lists what generating tables with opts warns about: the
+// columns of FindUnmapped, tables generated into the same package, columns
+// given the same Go name or one the package declares, such as Meta, names
+// that are no Go identifiers, such as 2fa, and names as long as PostgreSQL
+// allows, which it likely truncated when they were created.
+func FindWarnings(tables []schema.Table, opts Options) []Warning {
+	var warnings []Warning
+
+	for _, u := range FindUnmapped(tables, opts) {
+		warnings = append(warnings, Warning{
+			Kind:    WarnUnmappedType,
+			Table:   u.Schema + "." + u.Table,
+			Column:  u.Column,
+			Message: fmt.Sprintf("type %q has no Go mapping, generated as %s", u.Type, u.GoType),
+		})
+	}
+
+	tables = applyHooks(tables, opts)
+	if opts.TenantPackage != "" {
+		tables = dedupeTenants(tables, tenantSchemas(tables))
+	}
+
+	packages := make(map[string]string, len(tables))
+	for _, t := range tables {
+		name := t.Schema + "." + t.Name
+		pkg := PackagePath(t, opts)
+		if other, ok := packages[pkg]; ok {
+			warnings = append(warnings, Warning{
+				Kind:    WarnNameCollision,
+				Table:   name,
+				Message: fmt.Sprintf("generated into the same package %s as %s", pkg, other),
+			})
+			continue
+		}
+		packages[pkg] = name
+	}
+
+	for _, t := range tables {
+		name := t.Schema + "." + t.Name
+		if len(t.Name) == maxIdentifier {
+			warnings = append(warnings, Warning{
+				Kind:    WarnTruncatedIdentifier,
+				Table:   name,
+				Message: fmt.Sprintf("table name is %d bytes long and may have been truncated", maxIdentifier),
+			})
+		}
+
+		if pkg := path.Base(PackagePath(t, opts)); pkg != opts.PackagePrefix+t.Name {
+			warnings = append(warnings, Warning{
+				Kind:    WarnInvalidIdentifier,
+				Table:   name,
+				Message: fmt.Sprintf("table name is not a Go package name, generated as package %s", pkg),
+			})
+		}
+
+		reserved, names := reservedNames(t, opts), goNames(t, opts)
+		fields := make(map[string]string, len(t.Columns))
+		for _, c := range t.Columns {
+			if sanitized(c.Name) && !renamed(t, c, opts) {
+				warnings = append(warnings, Warning{
+					Kind:    WarnInvalidIdentifier,
+					Table:   name,
+					Column:  c.Name,
+					Message: fmt.Sprintf("column name is not a Go identifier, generated as %s", names[c.Name]),
+				})
+			}
+
+			if len(c.Name) == maxIdentifier {
+				warnings = append(warnings, Warning{
+					Kind:    WarnTruncatedIdentifier,
+					Table:   name,
+					Column:  c.Name,
+					Message: fmt.Sprintf("column name is %d bytes long and may have been truncated", maxIdentifier),
+				})
+			}
+
+			if base := baseName(t, c, opts); reserved[base] {
+				warnings = append(warnings, Warning{
+					Kind:    WarnNameCollision,
+					Table:   name,
+					Column:  c.Name,
+					Message: fmt.Sprintf("Go name %s is declared by the package for something else, generated as %s", base, unreserved(base, reserved)),
+				})
+			}
+
+			field := names[c.Name]
+			if other, ok := fields[field]; ok {
+				warnings = append(warnings, Warning{
+					Kind:    WarnNameCollision,
+					Table:   name,
+					Column:  c.Name,
+					Message: fmt.Sprintf("Go name %s is also the name of column %s", field, other),
+				})
+				continue
+			}
+			fields[field] = c.Name
+		}
+
+		for _, fk := range t.ForeignKeys {
+			if len(fk.Name) == maxIdentifier {
+				warnings = append(warnings, Warning{
+					Kind:    WarnTruncatedIdentifier,
+					Table:   name,
+					Message: fmt.Sprintf("foreign key name %s is %d bytes long and may have been truncated", fk.Name, maxIdentifier),
+				})
+			}
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,58 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// WhereData is the data of where.tmpl.
+type WhereData struct {
+	Header  string
+	Package string
+
+	// GoMinor is the minor version of Options.GoVersion. Columns are typed
+	// with a type parameter from Go 1.18 on.
+	GoMinor int
+
+	// Placeholder is the placeholder style of Options.Placeholder, which
+	// Build renders.
+	Placeholder string
+}
+
+// buildWhere renders the package of Options.WherePackage.
+func buildWhere(tmpl *template.Template, opts Options) (string, error) {
+	data := WhereData{
+		Header:      strings.TrimSuffix(Header(), "\n"),
+		Package:     path.Base(opts.WherePackage),
+		GoMinor:     goMinorOf(opts),
+		Placeholder: placeholderOf(opts),
+	}
+
+	var block strings.Builder
+	if err := tmpl.ExecuteTemplate(&block, "where.tmpl", data); err != nil {
+		return "", fmt.Errorf("failed to render where package: %w", err)
+	}
+
+	src, err := emit(data.Package+".go", block.String(), nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render where package: %w", err)
+	}
+
+	if opts.PostProcess != nil {
+		src = opts.PostProcess(opts.WherePackage, src)
+	}
+
+	return src, nil
+}
+
+// whereImport returns the import path of the package of
+// Options.WherePackage, or an empty string when there is none.
+func whereImport(opts Options) string {
+	if opts.WherePackage == "" || opts.ModulePath == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(opts.ModulePath, "/") + "/" + opts.WherePackage
+}
@@ -0,0 +1,284 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// minChunk is the shortest piece wrapLines splits a string literal into, so
+// a literal starting far right is not cut into slivers.
+const minChunk = 20
+
+// edit replaces src[from:to] with text.
+type edit struct {
+	from, to int
+	text     string
+}
+
+// wrapLines breaks the lines of gofmt-formatted src longer than width
+// characters, a tab counting as one like lll does. Element lists of
+// composite literals, calls, parameters and case clauses go one per line,
+// chains of +, && and || break before the operand that would overflow,
+// string literals are split into a concatenation and comments are
+// reflowed. Lines none of these shortens, such as a long identifier, are
+// left alone.
+func wrapLines(src string, width int) (string, error) {
+	for {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+		if err != nil {
+			return "", fmt.Errorf("generated invalid Go: %w", err)
+		}
+
+		var edits []edit
+		for i, line := range strings.Split(src, "\n") {
+			if utf8.RuneCountInString(line) <= width {
+				continue
+			}
+			if edits = wrapLine(fset, file, src, i+1, width); edits != nil {
+				break
+			}
+		}
+		if edits == nil {
+			return src, nil
+		}
+
+		before := src
+
+		// Later edits first, so earlier offsets stay valid
+		sort.Slice(edits, func(i, j int) bool { return edits[i].from > edits[j].from })
+		for _, e := range edits {
+			src = src[:e.from] + e.text + src[e.to:]
+		}
+
+		formatted, err := format.Source([]byte(src))
+		if err != nil {
+			return "", fmt.Errorf("generated invalid Go: %w", err)
+		}
+		// gofmt joining the lines back would loop forever
+		if string(formatted) == before {
+			return src, nil
+		}
+		src = string(formatted)
+	}
+}
+
+// wrapLine returns the edits breaking the outermost node that lies on line
+// and can be broken, or nil when there is none.
+func wrapLine(fset *token.FileSet, file *ast.File, src string, line, width int) []edit {
+	lineOf := func(p token.Pos) int { return fset.Position(p).Line }
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+	column := func(p token.Pos) int { return fset.Position(p).Column }
+	onLine := func(n ast.Node) bool { return lineOf(n.Pos()) == line && lineOf(n.End()) == line }
+
+	indent := 0
+	start := offset(fset.File(file.Pos()).LineStart(line))
+	for start+indent < len(src) && src[start+indent] == '\t' {
+		indent++
+	}
+
+	var best ast.Node
+	var edits []edit
+	consider := func(n ast.Node, e []edit) {
+		if e == nil {
+			return
+		}
+		if best == nil || n.Pos() < best.Pos() || n.Pos() == best.Pos() && n.End() > best.End() {
+			best, edits = n, e
+		}
+	}
+
+	skip := make(map[ast.Node]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || skip[n] {
+			return n != nil
+		}
+		if lineOf(n.Pos()) > line || lineOf(n.End()) < line {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.Field:
+			// Struct tags cannot be concatenations
+			if n.Tag != nil {
+				skip[n.Tag] = true
+			}
+		case *ast.CompositeLit:
+			if onLine(n) {
+				consider(n, listEdits(offset, n.Elts, token.NoPos))
+			}
+		case *ast.CallExpr:
+			if onLine(n) {
+				consider(n, listEdits(offset, n.Args, n.Ellipsis))
+			}
+		case *ast.FieldList:
+			if onLine(n) && n.Opening.IsValid() && len(n.List) > 1 {
+				var e []edit
+				for _, f := range n.List {
+					e = append(e, edit{from: offset(f.Pos()), to: offset(f.Pos()), text: "\n"})
+				}
+				last := n.List[len(n.List)-1]
+				e = append(e, edit{from: offset(last.End()), to: offset(last.End()), text: ",\n"})
+				consider(n, e)
+			}
+		case *ast.CaseClause:
+			if len(n.List) > 1 && lineOf(n.List[0].Pos()) == line && lineOf(n.List[len(n.List)-1].End()) == line {
+				var e []edit
+				for _, x := range n.List[1:] {
+					e = append(e, edit{from: offset(x.Pos()), to: offset(x.Pos()), text: "\n"})
+				}
+				consider(n, e)
+			}
+		case *ast.BinaryExpr:
+			if onLine(n) && (n.Op == token.ADD || n.Op == token.LAND || n.Op == token.LOR) {
+				operands := chainOperands(n, n.Op, skip)
+				consider(n, chainEdits(operands, n.Op, column, offset, indent, width))
+			}
+		case *ast.BasicLit:
+			if n.Kind == token.STRING && onLine(n) {
+				consider(n, stringEdits(n, column(n.Pos()), offset, indent, width))
+			}
+		}
+		return true
+	})
+	if edits != nil {
+		return edits
+	}
+
+	// Whole-line comments are reflowed when no code can be broken
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if lineOf(c.Pos()) == line && column(c.Pos()) == indent+1 {
+				return commentEdits(c, offset, indent, width)
+			}
+		}
+	}
+
+	return nil
+}
+
+// listEdits puts each element of a list on its own line, with a trailing
+// comma after the last one or after its ellipsis. Lists of fewer than two
+// elements are left alone.
+func listEdits(offset func(token.Pos) int, elts []ast.Expr, ellipsis token.Pos) []edit {
+	if len(elts) < 2 {
+		return nil
+	}
+
+	var e []edit
+	for _, x := range elts {
+		e = append(e, edit{from: offset(x.Pos()), to: offset(x.Pos()), text: "\n"})
+	}
+	last := offset(elts[len(elts)-1].End())
+	if ellipsis.IsValid() {
+		last = offset(ellipsis) + len("...")
+	}
+	e = append(e, edit{from: last, to: last, text: ",\n"})
+
+	return e
+}
+
+// chainOperands flattens a chain of op, marking the inner links of the
+// chain so they are not considered on their own.
+func chainOperands(x ast.Expr, op token.Token, skip map[ast.Node]bool) []ast.Expr {
+	b, ok := x.(*ast.BinaryExpr)
+	if !ok || b.Op != op {
+		return []ast.Expr{x}
+	}
+	skip[b] = true
+
+	return append(chainOperands(b.X, op, skip), chainOperands(b.Y, op, skip)...)
+}
+
+// chainEdits breaks a chain of op before each operand that would end past
+// width, the operator staying at the end of the line before.
+func chainEdits(operands []ast.Expr, op token.Token, column, offset func(token.Pos) int, indent, width int) []edit {
+	var e []edit
+	shift := 0
+	for i, x := range operands {
+		end := column(x.End()) - 1 - shift
+		if i < len(operands)-1 {
+			end += len(op.String()) + 1
+		}
+		if i > 0 && end > width {
+			e = append(e, edit{from: offset(x.Pos()), to: offset(x.Pos()), text: "\n"})
+			shift = column(x.Pos()) - (indent + 2)
+		}
+	}
+
+	return e
+}
+
+// stringEdits splits a string literal starting at col into a concatenation
+// of pieces ending at spaces, each fitting width when possible.
+func stringEdits(lit *ast.BasicLit, col int, offset func(token.Pos) int, indent, width int) []edit {
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil
+	}
+	quote := strconv.Quote
+	if strings.HasPrefix(lit.Value, "`") {
+		quote = func(s string) string { return "`" + s + "`" }
+	}
+
+	// Pieces end with " +", and the ones after the first are indented
+	limit := max(width-(col-1)-2, minChunk)
+	var chunks []string
+	var chunk string
+	for _, word := range strings.SplitAfter(value, " ") {
+		if chunk != "" && utf8.RuneCountInString(quote(chunk+word)) > limit {
+			chunks = append(chunks, chunk)
+			chunk = ""
+			limit = max(width-(indent+1)-2, minChunk)
+		}
+		chunk += word
+	}
+	chunks = append(chunks, chunk)
+	if len(chunks) < 2 {
+		return nil
+	}
+
+	for i, c := range chunks {
+		chunks[i] = quote(c)
+	}
+
+	return []edit{{from: offset(lit.Pos()), to: offset(lit.End()), text: strings.Join(chunks, " +\n")}}
+}
+
+// commentEdits reflows a // comment on a line of its own into lines of at
+// most width. Directives and indented code blocks are left alone.
+func commentEdits(c *ast.Comment, offset func(token.Pos) int, indent, width int) []edit {
+	text, ok := strings.CutPrefix(c.Text, "// ")
+	if !ok || strings.HasPrefix(text, " ") {
+		return nil
+	}
+
+	prefix := strings.Repeat("\t", indent) + "// "
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && utf8.RuneCountInString(prefix+line+" "+word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	lines = append(lines, line)
+	if len(lines) < 2 {
+		return nil
+	}
+
+	return []edit{{from: offset(c.Pos()), to: offset(c.End()), text: "// " + strings.Join(lines, "\n"+prefix)}}
+}
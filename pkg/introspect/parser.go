@@ -0,0 +1,869 @@
+// Package introspect reads tables and columns from a PostgreSQL database.
+package introspect
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+type Options struct {
+	// Schemas to read tables from. Defaults to public.
+	Schemas []string
+
+	// Include keeps only matching tables, Exclude drops matching tables.
+	// Patterns use path.Match syntax against the table name or schema.name.
+	Include []string
+	Exclude []string
+
+	// Progress, when set, is called as each table finishes with the number
+	// of tables read so far and the total to read.
+	Progress func(done, total int)
+
+	// KeepGoing reads the tables one at a time when reading them together
+	// fails, and skips the tables that cannot be read instead of failing.
+	KeepGoing bool
+
+	// Skip, when set, is called for every table skipped by KeepGoing.
+	Skip func(schemaName, tableName string, err error)
+
+	// Stats also reads the approximate row count and size of every table.
+	Stats bool
+}
+
+type SchemaParser struct {
+	db     *sql.DB
+	opts   Options
+	server *Server
+}
+
+func NewSchemaParser(db *sql.DB, opts Options) *SchemaParser {
+	if len(opts.Schemas) == 0 {
+		opts.Schemas = []string{"public"}
+	}
+
+	return &SchemaParser{db: db, opts: opts}
+}
+
+func (si *SchemaParser) GetTables() ([]schema.Table, error) {
+	server, err := si.Server()
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := si.readAll(server)
+	if err != nil && si.opts.KeepGoing {
+		slog.Warn("Failed to read tables together, reading them one by one", "error", err)
+		tables, err = si.readEach(server)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := si.readForeignKeys(tables); err != nil {
+		if !si.opts.KeepGoing {
+			return nil, err
+		}
+		slog.Warn("Skipping foreign keys", "error", err)
+	}
+
+	if err := si.readIndexes(tables, server); err != nil {
+		if !si.opts.KeepGoing {
+			return nil, err
+		}
+		slog.Warn("Skipping indexes", "error", err)
+	}
+
+	if err := si.readComments(tables); err != nil {
+		if !si.opts.KeepGoing {
+			return nil, err
+		}
+		slog.Warn("Skipping comments", "error", err)
+	}
+
+	if err := si.readEnumComments(tables); err != nil {
+		if !si.opts.KeepGoing {
+			return nil, err
+		}
+		slog.Warn("Skipping enum label comments", "error", err)
+	}
+
+	if !server.Partitioning() {
+		slog.Debug("Skipping partitions, the server has no declarative partitioning", "version", server)
+	} else if err := si.readPartitions(tables); err != nil {
+		if !si.opts.KeepGoing {
+			return nil, err
+		}
+		slog.Warn("Skipping partitions", "error", err)
+	}
+
+	if si.opts.Stats {
+		if err := si.readStats(tables); err != nil {
+			return nil, err
+		}
+	}
+
+	return tables, nil
+}
+
+// readStats sets the Stats of tables. The row count comes from pg_class,
+// falling back to the live tuples of pg_stat_user_tables for tables that
+// were never vacuumed or analyzed.
+func (si *SchemaParser) readStats(tables []schema.Table) error {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			COALESCE(NULLIF(c.reltuples, -1)::bigint, s.n_live_tup, 0),
+			pg_catalog.pg_table_size(c.oid),
+			pg_catalog.pg_indexes_size(c.oid)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = ANY($1)
+	`
+
+	slog.Debug("Querying table statistics", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query table statistics: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var stats schema.Stats
+		if err := rows.Scan(&schemaName, &tableName, &stats.Rows, &stats.TableBytes, &stats.IndexBytes); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if i, ok := index[schemaName+"."+tableName]; ok {
+			tables[i].Stats = &stats
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read table statistics: %w", err)
+	}
+
+	return nil
+}
+
+// readForeignKeys adds the foreign keys of the configured schemas to the
+// tables they belong to. Key columns are listed in constraint order, which
+// information_schema cannot tell for composite keys.
+func (si *SchemaParser) readForeignKeys(tables []schema.Table) error {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			con.conname,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(con.conkey) WITH ORDINALITY k(attnum, i)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.i
+			),
+			rn.nspname,
+			rc.relname,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(con.confkey) WITH ORDINALITY k(attnum, i)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.i
+			)
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE con.contype = 'f' AND n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname, con.conname
+	`
+
+	slog.Debug("Querying foreign keys", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var fk schema.ForeignKey
+		if err := rows.Scan(&schemaName, &tableName, &fk.Name, pq.Array(&fk.Columns), &fk.RefSchema, &fk.RefTable, pq.Array(&fk.RefColumns)); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if i, ok := index[schemaName+"."+tableName]; ok {
+			tables[i].ForeignKeys = append(tables[i].ForeignKeys, fk)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	return nil
+}
+
+// readIndexes adds the indexes of the configured schemas to the tables they
+// belong to. Indexes with an expression in their key get no Columns.
+func (si *SchemaParser) readIndexes(tables []schema.Table, server Server) error {
+	keyColumns := "i.indnatts"
+	if server.CoveringIndexes() {
+		keyColumns = "i.indnkeyatts"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			n.nspname,
+			c.relname,
+			ic.relname,
+			CASE WHEN 0 = ANY(i.indkey) THEN ARRAY[]::text[] ELSE ARRAY(
+				SELECT a.attname::text
+				FROM unnest(i.indkey) WITH ORDINALITY k(attnum, i)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+				WHERE k.i <= %s
+				ORDER BY k.i
+			) END,
+			i.indisunique,
+			i.indisprimary,
+			i.indpred IS NOT NULL
+		FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_class c ON c.oid = i.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname, ic.relname
+	`, keyColumns)
+
+	slog.Debug("Querying indexes", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var idx schema.Index
+		if err := rows.Scan(&schemaName, &tableName, &idx.Name, pq.Array(&idx.Columns), &idx.Unique, &idx.Primary, &idx.Partial); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if len(idx.Columns) == 0 {
+			idx.Columns = nil
+		}
+
+		if i, ok := index[schemaName+"."+tableName]; ok {
+			tables[i].Indexes = append(tables[i].Indexes, idx)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	return nil
+}
+
+// readComments sets the comments of tables and of their columns.
+func (si *SchemaParser) readComments(tables []schema.Table) error {
+	query := `
+		SELECT n.nspname, c.relname, COALESCE(a.attname, ''), d.description
+		FROM pg_catalog.pg_description d
+		JOIN pg_catalog.pg_class c ON c.oid = d.objoid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = d.objsubid
+		WHERE d.classoid = 'pg_catalog.pg_class'::regclass
+			AND c.relkind IN ('r', 'p') AND n.nspname = ANY($1)
+			AND (d.objsubid = 0 OR a.attname IS NOT NULL)
+	`
+
+	slog.Debug("Querying comments", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	for rows.Next() {
+		var schemaName, tableName, columnName, comment string
+		if err := rows.Scan(&schemaName, &tableName, &columnName, &comment); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		i, ok := index[schemaName+"."+tableName]
+		if !ok {
+			continue
+		}
+		if columnName == "" {
+			tables[i].Comment = comment
+			continue
+		}
+		for j := range tables[i].Columns {
+			if tables[i].Columns[j].Name == columnName {
+				tables[i].Columns[j].Comment = comment
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read comments: %w", err)
+	}
+
+	return nil
+}
+
+// readEnumComments sets the EnumComments of the enum columns of tables.
+// COMMENT ON cannot document enum labels, but their pg_enum rows can have
+// descriptions like any catalog object, which migrations may insert.
+func (si *SchemaParser) readEnumComments(tables []schema.Table) error {
+	query := `
+		SELECT n.nspname, c.relname, a.attname, e.enumlabel, d.description
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		JOIN pg_catalog.pg_enum e ON e.enumtypid = CASE WHEN t.typcategory = 'A' THEN t.typelem ELSE t.oid END
+		JOIN pg_catalog.pg_description d ON d.classoid = 'pg_catalog.pg_enum'::regclass AND d.objoid = e.oid
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = ANY($1)
+			AND a.attnum > 0 AND NOT a.attisdropped
+	`
+
+	slog.Debug("Querying enum label comments", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query enum label comments: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	for rows.Next() {
+		var schemaName, tableName, columnName, label, comment string
+		if err := rows.Scan(&schemaName, &tableName, &columnName, &label, &comment); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		i, ok := index[schemaName+"."+tableName]
+		if !ok {
+			continue
+		}
+		for j := range tables[i].Columns {
+			c := &tables[i].Columns[j]
+			if c.Name != columnName {
+				continue
+			}
+			if c.EnumComments == nil {
+				c.EnumComments = make(map[string]string)
+			}
+			c.EnumComments[label] = comment
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read enum label comments: %w", err)
+	}
+
+	return nil
+}
+
+// readPartitions sets the Partitioning of the partitioned tables among
+// tables, with their partitions in the configured schemas. Partitions are
+// tables too and stay in tables when selected.
+func (si *SchemaParser) readPartitions(tables []schema.Table) error {
+	query := `
+		SELECT
+			n.nspname,
+			c.relname,
+			pt.partstrat,
+			CASE WHEN 0 = ANY(pt.partattrs::int2[]) THEN ARRAY[]::text[] ELSE ARRAY(
+				SELECT a.attname::text
+				FROM unnest(pt.partattrs::int2[]) WITH ORDINALITY k(attnum, i)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+				ORDER BY k.i
+			) END
+		FROM pg_catalog.pg_partitioned_table pt
+		JOIN pg_catalog.pg_class c ON c.oid = pt.partrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ANY($1)
+	`
+
+	slog.Debug("Querying partitioned tables", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query partitioned tables: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	strategies := map[string]string{"h": "hash", "l": "list", "r": "range"}
+	for rows.Next() {
+		var schemaName, tableName, strategy string
+		var key []string
+		if err := rows.Scan(&schemaName, &tableName, &strategy, pq.Array(&key)); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if i, ok := index[schemaName+"."+tableName]; ok {
+			tables[i].Partitioning = &schema.Partitioning{Strategy: strategies[strategy], Key: key}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read partitioned tables: %w", err)
+	}
+
+	return si.readPartitionBounds(tables, index)
+}
+
+// readPartitionBounds adds their partitions to the partitioned tables, whose
+// positions in tables index holds by schema.name.
+func (si *SchemaParser) readPartitionBounds(tables []schema.Table, index map[string]int) error {
+	query := `
+		SELECT
+			pn.nspname,
+			p.relname,
+			n.nspname,
+			c.relname,
+			pg_catalog.pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_catalog.pg_inherits i
+		JOIN pg_catalog.pg_class p ON p.oid = i.inhparent
+		JOIN pg_catalog.pg_namespace pn ON pn.oid = p.relnamespace
+		JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE p.relkind = 'p' AND c.relispartition AND pn.nspname = ANY($1) AND n.nspname = ANY($1)
+		ORDER BY pn.nspname, p.relname, n.nspname, c.relname
+	`
+
+	slog.Debug("Querying partitions", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return fmt.Errorf("failed to query partitions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schemaName, tableName string
+		var partition schema.Partition
+		if err := rows.Scan(&schemaName, &tableName, &partition.Schema, &partition.Name, &partition.Bound); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if i, ok := index[schemaName+"."+tableName]; ok && tables[i].Partitioning != nil {
+			tables[i].Partitioning.Partitions = append(tables[i].Partitioning.Partitions, partition)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	return nil
+}
+
+// columnFields selects the attributes of a column c that scanColumn reads
+// from server. Queries using it join primaryKeyJoin.
+//
+// information_schema reports enums and other user-defined types as
+// USER-DEFINED and every array as ARRAY, so their type is taken from the
+// underlying udt_name instead: order_status, or int4[] for its _int4 array
+// type. Arrays get a [] per dimension declared in pg_attribute.attndims,
+// which information_schema leaves out, and at least one. Enum columns and
+// arrays of enums also get the labels of the enum. Identity and generated
+// columns are only looked for on servers that have them. The attributes of
+// a column missing from a LEFT JOIN read as empty and false.
+func columnFields(server Server) string {
+	var generated []string
+	if server.Identity() {
+		generated = append(generated, "c.is_identity = 'YES'")
+	}
+	if server.GeneratedColumns() {
+		generated = append(generated, "c.is_generated = 'ALWAYS'")
+	}
+	if len(generated) == 0 {
+		generated = append(generated, "false")
+	}
+
+	return `
+			COALESCE(c.column_name, ''),
+			COALESCE(CASE c.data_type
+				WHEN 'USER-DEFINED' THEN c.udt_name
+				WHEN 'ARRAY' THEN substr(c.udt_name, 2) || repeat('[]', GREATEST((
+					SELECT a.attndims
+					FROM pg_catalog.pg_attribute a
+					JOIN pg_catalog.pg_class ac ON ac.oid = a.attrelid
+					JOIN pg_catalog.pg_namespace an ON an.oid = ac.relnamespace
+					WHERE an.nspname = c.table_schema AND ac.relname = c.table_name AND a.attname = c.column_name
+				), 1))
+				ELSE c.data_type
+			END, ''),
+			COALESCE(c.is_nullable = 'YES', false),
+			c.column_default,
+			COALESCE(` + strings.Join(generated, " OR ") + `, false),
+			COALESCE(pk.ordinal_position, 0),
+			(
+				SELECT array_agg(e.enumlabel ORDER BY e.enumsortorder)
+				FROM pg_catalog.pg_type ut
+				JOIN pg_catalog.pg_namespace un ON un.oid = ut.typnamespace
+				JOIN pg_catalog.pg_enum e ON e.enumtypid = CASE WHEN ut.typcategory = 'A' THEN ut.typelem ELSE ut.oid END
+				WHERE un.nspname = c.udt_schema AND ut.typname = c.udt_name
+			)`
+}
+
+// primaryKeyJoin joins the position of a column c in its table's primary key.
+const primaryKeyJoin = `
+		LEFT JOIN (
+			SELECT k.table_schema, k.table_name, k.column_name, k.ordinal_position
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage k
+				ON k.constraint_schema = tc.constraint_schema AND k.constraint_name = tc.constraint_name AND k.table_name = tc.table_name
+			WHERE tc.constraint_type = 'PRIMARY KEY'
+		) pk ON pk.table_schema = c.table_schema AND pk.table_name = c.table_name AND pk.column_name = c.column_name`
+
+// scanColumn reads the columnFields of a row after the values scanned into
+// dest.
+func scanColumn(rows *sql.Rows, dest ...any) (schema.Column, error) {
+	var c schema.Column
+	var def sql.NullString
+	dest = append(dest, &c.Name, &c.Type, &c.Nullable, &def, &c.Generated, &c.PrimaryKey, pq.Array(&c.Enum))
+
+	if err := rows.Scan(dest...); err != nil {
+		return c, fmt.Errorf("failed to scan row: %w", err)
+	}
+	c.Default = def.String
+
+	return c, nil
+}
+
+// readAll reads every table of server with a single query, tables without
+// columns included.
+func (si *SchemaParser) readAll(server Server) ([]schema.Table, error) {
+	query := `
+		SELECT
+			t.table_schema,
+			t.table_name,` + columnFields(server) + `
+		FROM
+			information_schema.tables t
+		LEFT JOIN
+			information_schema.columns c ON t.table_schema = c.table_schema AND t.table_name = c.table_name` + primaryKeyJoin + `
+		WHERE
+			t.table_schema = ANY($1)
+			AND t.table_type = 'BASE TABLE'
+		ORDER BY
+			t.table_schema, t.table_name, c.ordinal_position
+	`
+
+	total := 0
+	if si.opts.Progress != nil {
+		var err error
+		if total, err = si.countTables(); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Debug("Querying columns", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema: %w", err)
+	}
+	defer rows.Close()
+
+	tablesMap := make(map[string]*schema.Table)
+	var keys []string
+	var tables []schema.Table
+
+	for rows.Next() {
+		var schemaName, tableName string
+
+		column, err := scanColumn(rows, &schemaName, &tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !si.selected(schemaName, tableName) {
+			continue
+		}
+
+		// Get or create table
+		key := schemaName + "." + tableName
+		table, exists := tablesMap[key]
+		if !exists {
+			// Rows are ordered by table, so a new table means the previous one is complete
+			if si.opts.Progress != nil && len(tablesMap) > 0 {
+				si.opts.Progress(len(tablesMap), total)
+			}
+
+			table = &schema.Table{Schema: schemaName, Name: tableName, Columns: []schema.Column{}}
+			tablesMap[key] = table
+			keys = append(keys, key)
+		}
+
+		// A table without columns has a single row without one
+		if column.Name != "" {
+			table.Columns = append(table.Columns, column)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	if si.opts.Progress != nil {
+		si.opts.Progress(len(tablesMap), total)
+	}
+
+	// Convert map to slice in a stable order
+	for _, key := range keys {
+		table := tablesMap[key]
+		slog.Debug("Introspected table", "schema", table.Schema, "table", table.Name, "columns", len(table.Columns))
+		tables = append(tables, *table)
+	}
+
+	// The database collation may order names differently than Go does
+	schema.Sort(tables)
+
+	return tables, nil
+}
+
+// readEach reads the tables of server one query at a time, skipping those
+// that fail.
+func (si *SchemaParser) readEach(server Server) ([]schema.Table, error) {
+	names, err := si.selectedTables()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []schema.Table
+	for i, name := range names {
+		table, err := si.readTable(server, name[0], name[1])
+		if err != nil {
+			slog.Warn("Skipping table", "schema", name[0], "table", name[1], "error", err)
+			if si.opts.Skip != nil {
+				si.opts.Skip(name[0], name[1], err)
+			}
+		} else {
+			tables = append(tables, table)
+		}
+
+		if si.opts.Progress != nil {
+			si.opts.Progress(i+1, len(names))
+		}
+	}
+
+	schema.Sort(tables)
+
+	return tables, nil
+}
+
+// readTable reads the columns of a single table of server.
+func (si *SchemaParser) readTable(server Server, schemaName, tableName string) (schema.Table, error) {
+	query := `
+		SELECT` + columnFields(server) + `
+		FROM information_schema.columns c` + primaryKeyJoin + `
+		WHERE c.table_schema = $1 AND c.table_name = $2
+		ORDER BY c.ordinal_position
+	`
+
+	table := schema.Table{Schema: schemaName, Name: tableName, Columns: []schema.Column{}}
+
+	rows, err := si.db.Query(query, schemaName, tableName)
+	if err != nil {
+		return table, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		column, err := scanColumn(rows)
+		if err != nil {
+			return table, err
+		}
+
+		table.Columns = append(table.Columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return table, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	return table, nil
+}
+
+// ListSchemas returns the names of all non-system schemas.
+func (si *SchemaParser) ListSchemas() ([]string, error) {
+	query := `
+		SELECT schema_name
+		FROM information_schema.schemata
+		WHERE schema_name NOT IN ('information_schema', 'pg_catalog', 'pg_toast')
+			AND schema_name NOT LIKE 'pg_temp_%'
+			AND schema_name NOT LIKE 'pg_toast_temp_%'
+		ORDER BY schema_name
+	`
+
+	return si.queryNames(query)
+}
+
+// ListTables returns the names of the tables in the configured schemas,
+// without applying the include and exclude filters.
+func (si *SchemaParser) ListTables() ([]string, error) {
+	query := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = ANY($1) AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`
+
+	return si.queryNames(query, pq.Array(si.opts.Schemas))
+}
+
+// ListQualifiedTables returns the schema.table names of the tables in the
+// configured schemas, without applying the include and exclude filters.
+func (si *SchemaParser) ListQualifiedTables() ([]string, error) {
+	query := `
+		SELECT table_schema || '.' || table_name
+		FROM information_schema.tables
+		WHERE table_schema = ANY($1) AND table_type = 'BASE TABLE'
+		ORDER BY table_schema, table_name
+	`
+
+	return si.queryNames(query, pq.Array(si.opts.Schemas))
+}
+
+func (si *SchemaParser) queryNames(query string, args ...any) ([]string, error) {
+	slog.Debug("Querying names", "sql", query)
+
+	rows, err := si.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// countTables counts the tables GetTables will read, for progress reporting.
+func (si *SchemaParser) countTables() (int, error) {
+	names, err := si.selectedTables()
+	return len(names), err
+}
+
+// selectedTables returns the schema and name of every table GetTables reads.
+func (si *SchemaParser) selectedTables() ([][2]string, error) {
+	query := `
+		SELECT table_schema, table_name
+		FROM information_schema.tables
+		WHERE table_schema = ANY($1) AND table_type = 'BASE TABLE'
+		ORDER BY table_schema, table_name
+	`
+
+	slog.Debug("Listing tables", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var names [][2]string
+	for rows.Next() {
+		var schemaName, tableName string
+		if err := rows.Scan(&schemaName, &tableName); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if si.selected(schemaName, tableName) {
+			names = append(names, [2]string{schemaName, tableName})
+		}
+	}
+
+	return names, rows.Err()
+}
+
+// selected applies the include and exclude filters to a table.
+func (si *SchemaParser) selected(schemaName, tableName string) bool {
+	return Selected(si.opts.Include, si.opts.Exclude, schemaName, tableName)
+}
+
+// Select filters already introspected tables with include and exclude
+// patterns, as Options.Include and Options.Exclude do during introspection.
+func Select(tables []schema.Table, include, exclude []string) []schema.Table {
+	var result []schema.Table
+	for _, t := range tables {
+		if Selected(include, exclude, t.Schema, t.Name) {
+			result = append(result, t)
+		}
+	}
+
+	return result
+}
+
+// Selected reports whether the include and exclude patterns keep a table.
+func Selected(include, exclude []string, schemaName, tableName string) bool {
+	if len(include) > 0 && !matchAny(include, schemaName, tableName) {
+		return false
+	}
+
+	return !matchAny(exclude, schemaName, tableName)
+}
+
+func matchAny(patterns []string, schemaName, tableName string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, tableName); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, schemaName+"."+tableName); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,172 @@
+package introspect
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+// catalogs lists the catalog relations introspection reads.
+var catalogs = []string{
+	"pg_catalog.pg_namespace",
+	"pg_catalog.pg_class",
+	"pg_catalog.pg_attribute",
+	"pg_catalog.pg_type",
+	"pg_catalog.pg_enum",
+	"pg_catalog.pg_constraint",
+	"pg_catalog.pg_inherits",
+	"pg_catalog.pg_description",
+	"pg_catalog.pg_partitioned_table",
+	"information_schema.schemata",
+	"information_schema.tables",
+	"information_schema.columns",
+	"information_schema.table_constraints",
+	"information_schema.key_column_usage",
+}
+
+// Problem is a privilege the database role lacks to introspect the
+// configured schemas.
+type Problem struct {
+	// Object is the catalog, schema or table concerned, e.g.
+	// pg_catalog.pg_class, schema billing or table billing.invoices.
+	Object string `json:"object"`
+
+	// Reason tells what is wrong, e.g. no USAGE privilege.
+	Reason string `json:"reason"`
+
+	// Grant is the statement giving the role what it lacks, empty when no
+	// grant can.
+	Grant string `json:"grant,omitempty"`
+
+	// Fatal marks a problem introspection fails on. The others leave
+	// tables out of the result without an error.
+	Fatal bool `json:"fatal"`
+}
+
+// Preflight checks that the role of the connection can read the catalogs
+// introspection queries and every configured schema, and can see every
+// selected table, which information_schema silently hides otherwise. It
+// returns the problems found, none when introspection can read everything.
+func (si *SchemaParser) Preflight() ([]Problem, error) {
+	var role string
+	if err := si.db.QueryRow(`SELECT current_user`).Scan(&role); err != nil {
+		return nil, fmt.Errorf("failed to query the current role: %w", err)
+	}
+	grantee := pq.QuoteIdentifier(role)
+
+	relations := catalogs
+	if si.opts.Stats {
+		relations = append(relations[:len(relations):len(relations)], "pg_catalog.pg_stat_user_tables")
+	}
+
+	// to_regclass skips the catalogs an older server does not have
+	query := `
+		SELECT rel
+		FROM unnest($1::text[]) rel
+		WHERE to_regclass(rel) IS NOT NULL
+			AND NOT has_table_privilege(to_regclass(rel), 'SELECT')
+	`
+
+	slog.Debug("Checking catalog privileges", "sql", query)
+
+	denied, err := si.queryNames(query, pq.Array(relations))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check catalog privileges: %w", err)
+	}
+
+	var problems []Problem
+	for _, rel := range denied {
+		problems = append(problems, Problem{
+			Object: rel,
+			Reason: "no SELECT privilege",
+			Grant:  fmt.Sprintf("GRANT SELECT ON %s TO %s;", rel, grantee),
+			Fatal:  true,
+		})
+	}
+	// Tables cannot be checked without the catalogs
+	if len(problems) > 0 {
+		return problems, nil
+	}
+
+	query = `
+		SELECT s, n.oid IS NOT NULL, COALESCE(has_schema_privilege(n.oid, 'USAGE'), false)
+		FROM unnest($1::text[]) s
+		LEFT JOIN pg_catalog.pg_namespace n ON n.nspname = s
+		ORDER BY s
+	`
+
+	slog.Debug("Checking schema privileges", "sql", query, "schemas", si.opts.Schemas)
+
+	rows, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema privileges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var exists, usage bool
+		if err := rows.Scan(&name, &exists, &usage); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		switch {
+		case !exists:
+			problems = append(problems, Problem{Object: "schema " + name, Reason: "does not exist"})
+		case !usage:
+			problems = append(problems, Problem{
+				Object: "schema " + name,
+				Reason: "no USAGE privilege",
+				Grant:  fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s;", pq.QuoteIdentifier(name), grantee),
+				Fatal:  true,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check schema privileges: %w", err)
+	}
+
+	// information_schema shows the tables the role owns or has any
+	// privilege on
+	query = `
+		SELECT n.nspname, c.relname
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = ANY($1)
+			AND has_schema_privilege(n.oid, 'USAGE')
+			AND NOT pg_has_role(c.relowner, 'USAGE')
+			AND NOT has_table_privilege(c.oid, 'SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER')
+			AND NOT has_any_column_privilege(c.oid, 'SELECT, INSERT, UPDATE, REFERENCES')
+		ORDER BY n.nspname, c.relname
+	`
+
+	slog.Debug("Checking table privileges", "sql", query, "schemas", si.opts.Schemas)
+
+	hidden, err := si.db.Query(query, pq.Array(si.opts.Schemas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check table privileges: %w", err)
+	}
+	defer hidden.Close()
+
+	for hidden.Next() {
+		var schemaName, tableName string
+		if err := hidden.Scan(&schemaName, &tableName); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if !si.selected(schemaName, tableName) {
+			continue
+		}
+
+		problems = append(problems, Problem{
+			Object: "table " + schemaName + "." + tableName,
+			Reason: "no privilege at all, so it is left out",
+			Grant:  fmt.Sprintf("GRANT SELECT ON %s.%s TO %s;", pq.QuoteIdentifier(schemaName), pq.QuoteIdentifier(tableName), grantee),
+		})
+	}
+	if err := hidden.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check table privileges: %w", err)
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,70 @@
+package introspect
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// MinServerVersion is the oldest PostgreSQL release introspection
+// supports, as a server_version_num. Older servers are read as far as they
+// can be, leaving out what they lack.
+const MinServerVersion = 100000
+
+// Server is the version of a PostgreSQL server, as its server_version_num,
+// e.g. 160002 for 16.2. Its methods tell the features the server has.
+type Server struct {
+	Version int
+}
+
+// String returns the version as PostgreSQL prints it.
+func (s Server) String() string {
+	if s.Version >= 100000 {
+		return fmt.Sprintf("%d.%d", s.Version/10000, s.Version%10000)
+	}
+
+	return fmt.Sprintf("%d.%d.%d", s.Version/10000, s.Version/100%100, s.Version%100)
+}
+
+// Identity reports whether the server has identity columns.
+func (s Server) Identity() bool {
+	return s.Version >= 100000
+}
+
+// Partitioning reports whether the server has declarative partitioning.
+func (s Server) Partitioning() bool {
+	return s.Version >= 100000
+}
+
+// CoveringIndexes reports whether the server has indexes with INCLUDE
+// columns, which pg_index tells apart from key columns with indnkeyatts.
+func (s Server) CoveringIndexes() bool {
+	return s.Version >= 110000
+}
+
+// GeneratedColumns reports whether the server has stored generated
+// columns.
+func (s Server) GeneratedColumns() bool {
+	return s.Version >= 120000
+}
+
+// Multiranges reports whether the server has multirange types.
+func (s Server) Multiranges() bool {
+	return s.Version >= 140000
+}
+
+// Server returns the version of the database server, read once.
+func (si *SchemaParser) Server() (Server, error) {
+	if si.server != nil {
+		return *si.server, nil
+	}
+
+	var s Server
+	if err := si.db.QueryRow(`SELECT current_setting('server_version_num')::int`).Scan(&s.Version); err != nil {
+		return s, fmt.Errorf("failed to query the server version: %w", err)
+	}
+	si.server = &s
+
+	slog.Debug("Detected server", "version", s)
+
+	return s, nil
+}
@@ -0,0 +1,111 @@
+// Package plugin defines the contract between tables and external generators.
+//
+// A plugin is an executable named tables-gen-<name> on PATH. tables writes a
+// Request as JSON to its stdin and reads a Response as JSON from its stdout;
+// anything the plugin writes to stderr is passed through. A plugin fails by
+// exiting non-zero or by setting Response.Error.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/mymyka/tables/pkg/schema"
+)
+
+// ProtocolVersion is sent with every request and bumped on incompatible
+// changes to Request or Response.
+const ProtocolVersion = 1
+
+// Prefix is prepended to a plugin name to find its executable.
+const Prefix = "tables-gen-"
+
+// Request is sent to a plugin on stdin.
+type Request struct {
+	ProtocolVersion int `json:"protocol_version"`
+
+	// TablesVersion is the version of tables running the plugin.
+	TablesVersion string `json:"tables_version"`
+
+	// SchemaVersion is the schema.Version that Tables follow.
+	SchemaVersion int `json:"schema_version"`
+
+	// Tables are the introspected tables, ordered by schema and name, in
+	// the same form as in a schema.Document.
+	Tables []schema.Table `json:"tables"`
+
+	// Options are the plugin options from the config file.
+	Options map[string]string `json:"options"`
+}
+
+// Response is read from a plugin's stdout.
+type Response struct {
+	// Files to write, with slash-separated paths relative to the plugin's
+	// output directory.
+	Files []File `json:"files"`
+
+	// Error, when set, fails the run with this message.
+	Error string `json:"error,omitempty"`
+}
+
+// File is a file produced by a plugin.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Run executes the named plugin with req and returns its files keyed by path.
+func Run(name string, req Request) (map[string]string, error) {
+	bin, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s not found: %w", name, err)
+	}
+
+	req.ProtocolVersion = ProtocolVersion
+	req.SchemaVersion = schema.Version
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(bin)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %w", name, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response of plugin %s: %w", name, err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", name, resp.Error)
+	}
+
+	files := make(map[string]string, len(resp.Files))
+	for _, f := range resp.Files {
+		if !validPath(f.Path) {
+			return nil, fmt.Errorf("plugin %s returned invalid path %q", name, f.Path)
+		}
+		files[f.Path] = f.Content
+	}
+
+	return files, nil
+}
+
+// validPath reports whether p is a relative path that stays inside the
+// output directory.
+func validPath(p string) bool {
+	clean := path.Clean(p)
+	return p != "" && !path.IsAbs(p) && clean != "." && clean != ".." && !strings.HasPrefix(clean, "../")
+}
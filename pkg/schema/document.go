@@ -0,0 +1,64 @@
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Version is the version of the serialized schema written by Marshal.
+//
+// Compatibility rules: within a version, fields are only ever added, never
+// renamed, removed or given a different meaning, and readers ignore fields
+// they do not know. An incompatible change bumps Version, and Unmarshal keeps
+// reading every older version. Documents newer than Version are rejected
+// rather than misread.
+const Version = 1
+
+// JSONSchema is the JSON Schema of Document.
+//
+//go:embed schema.json
+var JSONSchema []byte
+
+// Document is the serialized form of a schema, as stored in snapshots and
+// exports and sent to plugins.
+type Document struct {
+	// Version is the format version, see Version. Documents written before
+	// the format was versioned have none and read as version 1.
+	Version int `json:"version"`
+
+	// Tables are ordered by schema and name.
+	Tables []Table `json:"tables"`
+}
+
+// Marshal encodes tables as an indented Document of the current version.
+// Tables are sorted, so equal schemas encode to the same bytes.
+func Marshal(tables []Table) ([]byte, error) {
+	sorted := slices.Clone(tables)
+	Sort(sorted)
+
+	data, err := json.MarshalIndent(Document{Version: Version, Tables: sorted}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// Unmarshal decodes a Document of any supported version and returns its
+// tables sorted by schema and name.
+func Unmarshal(data []byte) ([]Table, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode schema: %w", err)
+	}
+
+	if doc.Version > Version {
+		return nil, fmt.Errorf("schema version %d is newer than the supported version %d, upgrade tables", doc.Version, Version)
+	}
+
+	Sort(doc.Tables)
+
+	return doc.Tables, nil
+}
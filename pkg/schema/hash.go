@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+)
+
+// Hash returns a canonical hash of tables, "sha256:" followed by hex digits.
+// It depends only on the schema, not on the order tables were read in, on
+// the serialization format version or on comments, so equal schemas hash
+// equal.
+func Hash(tables []Table) string {
+	sorted := slices.Clone(tables)
+	Sort(sorted)
+	for i := range sorted {
+		sorted[i] = sorted[i].definition()
+	}
+
+	return hashJSON(sorted)
+}
+
+// Hash returns the canonical hash of the table's definition.
+func (t Table) Hash() string {
+	return hashJSON(t.definition())
+}
+
+// definition returns t without its statistics and comments, which describe
+// the table rather than define it.
+func (t Table) definition() Table {
+	t.Stats = nil
+	t.Comment = ""
+	if slices.ContainsFunc(t.Columns, func(c Column) bool { return c.Comment != "" }) {
+		t.Columns = slices.Clone(t.Columns)
+		for i := range t.Columns {
+			t.Columns[i].Comment = ""
+		}
+	}
+
+	return t
+}
+
+func hashJSON(v any) string {
+	// Tables are plain data, so encoding cannot fail
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
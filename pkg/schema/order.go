@@ -0,0 +1,147 @@
+package schema
+
+import "slices"
+
+// InsertOrder returns tables ordered so that every table comes after the
+// tables its foreign keys reference, which is the order to insert rows in;
+// deleting goes the other way. Ties are broken by schema and name, and
+// references to the table itself or to tables not in tables are ignored.
+//
+// Tables on a reference cycle cannot be ordered that way. They are kept
+// together, ordered by name, and every cycle is returned as the sorted
+// schema.name of its tables.
+func InsertOrder(tables []Table) ([]Table, [][]string) {
+	sorted := slices.Clone(tables)
+	Sort(sorted)
+
+	index := make(map[string]int, len(sorted))
+	for i, t := range sorted {
+		index[t.Schema+"."+t.Name] = i
+	}
+
+	refs := make([][]int, len(sorted))
+	for i, t := range sorted {
+		for _, fk := range t.ForeignKeys {
+			j, ok := index[fk.RefSchema+"."+fk.RefTable]
+			if ok && j != i && !slices.Contains(refs[i], j) {
+				refs[i] = append(refs[i], j)
+			}
+		}
+	}
+
+	components := stronglyConnected(refs)
+	component := make([]int, len(sorted))
+	for c, members := range components {
+		for _, i := range members {
+			component[i] = c
+		}
+	}
+
+	// Count the components each component references, then place the
+	// components whose references are all placed, the first by name first
+	pending := make([]int, len(components))
+	referencedBy := make([][]int, len(components))
+	for i, targets := range refs {
+		for _, j := range targets {
+			from, to := component[i], component[j]
+			if from != to {
+				pending[from]++
+				referencedBy[to] = append(referencedBy[to], from)
+			}
+		}
+	}
+
+	var ready []int
+	for c := range components {
+		if pending[c] == 0 {
+			ready = append(ready, c)
+		}
+	}
+
+	var cycles [][]string
+	ordered := make([]Table, 0, len(sorted))
+	for len(ready) > 0 {
+		next := 0
+		for k, c := range ready {
+			if components[c][0] < components[ready[next]][0] {
+				next = k
+			}
+		}
+		c := ready[next]
+		ready = slices.Delete(ready, next, next+1)
+
+		members := components[c]
+		if len(members) > 1 {
+			var names []string
+			for _, i := range members {
+				names = append(names, sorted[i].Schema+"."+sorted[i].Name)
+			}
+			cycles = append(cycles, names)
+		}
+		for _, i := range members {
+			ordered = append(ordered, sorted[i])
+		}
+
+		for _, from := range referencedBy[c] {
+			pending[from]--
+			if pending[from] == 0 {
+				ready = append(ready, from)
+			}
+		}
+	}
+
+	return ordered, cycles
+}
+
+// stronglyConnected returns the strongly connected components of a graph
+// given as the edges of every node, each as its nodes in ascending order.
+func stronglyConnected(edges [][]int) [][]int {
+	n := len(edges)
+	order := make([]int, n) // visit order plus one, 0 for unvisited
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	var stack []int
+	var components [][]int
+	visited := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		visited++
+		order[v], low[v] = visited, visited
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range edges[v] {
+			if order[w] == 0 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], order[w])
+			}
+		}
+
+		if low[v] != order[v] {
+			return
+		}
+		var component []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		slices.Sort(component)
+		components = append(components, component)
+	}
+
+	for v := range n {
+		if order[v] == 0 {
+			visit(v)
+		}
+	}
+
+	return components
+}
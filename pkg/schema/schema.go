@@ -1,12 +1,151 @@
+// Package schema is the model of an introspected database schema shared by
+// the generator, snapshots, diffs, exports and plugins. Its JSON form is a
+// versioned contract, see Document.
 package schema
 
+import (
+	"slices"
+	"sort"
+)
+
+// Column is a table column.
 type Column struct {
-	Name     string
-	Type     string
-	Nullable bool
+	Name string `json:"name"`
+
+	// Type is the PostgreSQL type. Arrays are their element type followed
+	// by [] per dimension, e.g. int4[] or text[][].
+	Type string `json:"type"`
+
+	Nullable bool `json:"nullable"`
+
+	// Default is the default expression of the column as PostgreSQL
+	// reports it, e.g. 'pending'::order_status or now(), or empty.
+	Default string `json:"default,omitempty"`
+
+	// Generated is set for identity and generated columns, whose values
+	// the database assigns.
+	Generated bool `json:"generated,omitempty"`
+
+	// PrimaryKey is the position of the column in the table's primary key,
+	// starting at 1, or 0 when it is not part of it.
+	PrimaryKey int `json:"primary_key,omitempty"`
+
+	// Enum lists the labels of the enum type of the column, or of its
+	// elements for an array of enums, in their declared order.
+	Enum []string `json:"enum,omitempty"`
+
+	// EnumComments maps labels of Enum to their comments, the descriptions
+	// of their pg_enum rows. They end up in the doc comments of generated
+	// enum constants, so unlike Comment hashes include them.
+	EnumComments map[string]string `json:"enum_comments,omitempty"`
+
+	// Comment is the COMMENT ON COLUMN of the column. Comments document
+	// the schema without defining it, so Equal and hashes ignore them.
+	Comment string `json:"comment,omitempty"`
+}
+
+// Equal reports whether c and o define the same column.
+func (c Column) Equal(o Column) bool {
+	return c.Name == o.Name && c.Type == o.Type && c.Nullable == o.Nullable && c.Default == o.Default &&
+		c.Generated == o.Generated && c.PrimaryKey == o.PrimaryKey && slices.Equal(c.Enum, o.Enum)
+}
+
+// ForeignKey is a foreign key constraint of a table.
+type ForeignKey struct {
+	Name string `json:"name"`
+
+	// Columns are the referencing columns in constraint order.
+	Columns []string `json:"columns"`
+
+	// RefSchema and RefTable name the referenced table, and RefColumns its
+	// columns matching Columns.
+	RefSchema  string   `json:"ref_schema"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+}
+
+// Index is an index of a table.
+type Index struct {
+	Name string `json:"name"`
+
+	// Columns lists the key columns in index order, leaving out INCLUDE
+	// columns. It is empty when the key has expressions.
+	Columns []string `json:"columns,omitempty"`
+
+	// Unique is set for unique indexes, Primary for the index of the
+	// primary key, and Partial for indexes with a WHERE predicate, which
+	// only cover some rows.
+	Unique  bool `json:"unique,omitempty"`
+	Primary bool `json:"primary,omitempty"`
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Partitioning describes how a partitioned table splits its rows.
+type Partitioning struct {
+	// Strategy is hash, list or range.
+	Strategy string `json:"strategy"`
+
+	// Key lists the partition key columns in key order. It is empty when
+	// the key has expressions.
+	Key []string `json:"key,omitempty"`
+
+	// Partitions are ordered by schema and name.
+	Partitions []Partition `json:"partitions,omitempty"`
 }
 
+// Partition is a partition of a partitioned table.
+type Partition struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+
+	// Bound is the partition bound as PostgreSQL reports it, e.g.
+	// FOR VALUES FROM ('2024-01-01') TO ('2024-02-01') or DEFAULT.
+	Bound string `json:"bound"`
+}
+
+// Table is a table with its columns in ordinal order.
 type Table struct {
-	Name    string
-	Columns []Column
+	Schema  string   `json:"schema"`
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+
+	// ForeignKeys are ordered by constraint name.
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+
+	// Indexes are ordered by index name, including those of the primary
+	// key and of unique constraints.
+	Indexes []Index `json:"indexes,omitempty"`
+
+	// Partitioning is set for partitioned tables.
+	Partitioning *Partitioning `json:"partitioning,omitempty"`
+
+	// Comment is the COMMENT ON TABLE of the table. Like the comments of
+	// columns, hashes ignore it.
+	Comment string `json:"comment,omitempty"`
+
+	// Stats are only read on request. They are not part of the table's
+	// definition, so hashes leave them out.
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats are approximate size statistics of a table.
+type Stats struct {
+	// Rows is the row count estimated by the planner statistics.
+	Rows int64 `json:"rows"`
+
+	// TableBytes is the on-disk size of the table including TOAST, and
+	// IndexBytes the size of its indexes.
+	TableBytes int64 `json:"table_bytes"`
+	IndexBytes int64 `json:"index_bytes"`
+}
+
+// Sort orders tables by schema and name. Columns keep their ordinal order,
+// which is the order of the table definition.
+func Sort(tables []Table) {
+	sort.SliceStable(tables, func(i, j int) bool {
+		if tables[i].Schema != tables[j].Schema {
+			return tables[i].Schema < tables[j].Schema
+		}
+		return tables[i].Name < tables[j].Name
+	})
 }
@@ -0,0 +1,42 @@
+package writer_test
+
+import (
+	"testing"
+
+	"github.com/mymyka/tables/internal/synthetic"
+	"github.com/mymyka/tables/pkg/gen"
+	"github.com/mymyka/tables/pkg/writer"
+)
+
+// BenchmarkWrite measures writing generated packages into an empty
+// directory, and again with nothing changed, which reads every file but
+// writes none.
+func BenchmarkWrite(b *testing.B) {
+	c, err := gen.Build(synthetic.Tables(100, 20), gen.Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("empty", func(b *testing.B) {
+		for b.Loop() {
+			b.StopTimer()
+			dir := b.TempDir()
+			b.StartTimer()
+			if _, err := writer.Write(dir, c, writer.Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unchanged", func(b *testing.B) {
+		dir := b.TempDir()
+		if _, err := writer.Write(dir, c, writer.Options{}); err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			if _, err := writer.Write(dir, c, writer.Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
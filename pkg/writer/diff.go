@@ -0,0 +1,113 @@
+package writer
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// Change describes how a generated file on disk differs from fresh output.
+type Change struct {
+	Path string
+	// Kind is "added", "changed", "removed" or "renamed".
+	Kind string
+	// From is the old path of a renamed file.
+	From    string
+	Added   int
+	Removed int
+}
+
+// Diff compares freshly generated content with the files under root without
+// writing anything. Removed files are generated files a Write would prune.
+func Diff(root string, c map[string]string) ([]Change, error) {
+	return DiffMoves(root, c, nil, "")
+}
+
+// DiffMoves is Diff for a generate that first moves the packages of renamed
+// tables, given as old package path to new one, and writes files with a
+// suffix (see Options.FileSuffix): a file removed from one package and added
+// to the other is reported as renamed.
+func DiffMoves(root string, c map[string]string, moves map[string]string, suffix string) ([]Change, error) {
+	var changes []Change
+	current := make(map[string]bool)
+
+	for _, pkg := range Packages(c) {
+		content := c[pkg]
+		fullPath := FilePath(root, pkg, suffix)
+		current[fullPath] = true
+
+		existing, err := os.ReadFile(fullPath)
+		if os.IsNotExist(err) {
+			changes = append(changes, Change{Path: fullPath, Kind: "added", Added: countLines(content)})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if string(existing) != content {
+			added, removed := lineDelta(string(existing), content)
+			changes = append(changes, Change{Path: fullPath, Kind: "changed", Added: added, Removed: removed})
+		}
+	}
+
+	stale, err := staleFiles(root, current)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stale {
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{Path: path, Kind: "removed", Removed: countLines(string(existing))})
+	}
+
+	for from, to := range moves {
+		fromPath, toPath := FilePath(root, from, suffix), FilePath(root, to, suffix)
+		removed := slices.IndexFunc(changes, func(ch Change) bool { return ch.Kind == "removed" && ch.Path == fromPath })
+		added := slices.IndexFunc(changes, func(ch Change) bool { return ch.Kind == "added" && ch.Path == toPath })
+		if removed < 0 || added < 0 {
+			continue
+		}
+
+		existing, err := os.ReadFile(fromPath)
+		if err != nil {
+			return nil, err
+		}
+		plus, minus := lineDelta(string(existing), c[to])
+		changes[added] = Change{Path: toPath, Kind: "renamed", From: fromPath, Added: plus, Removed: minus}
+		changes = slices.Delete(changes, removed, removed+1)
+	}
+
+	return changes, nil
+}
+
+// lineDelta counts lines only present in new (added) and only present in old
+// (removed), ignoring their order. It is a summary, not a patch.
+func lineDelta(old, new string) (int, int) {
+	counts := make(map[string]int)
+	for _, line := range strings.Split(old, "\n") {
+		counts[line]++
+	}
+
+	added := 0
+	for _, line := range strings.Split(new, "\n") {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added++
+		}
+	}
+
+	removed := 0
+	for _, n := range counts {
+		removed += n
+	}
+
+	return added, removed
+}
+
+func countLines(s string) int {
+	return strings.Count(s, "\n")
+}
@@ -0,0 +1,36 @@
+package writer
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// WriteFiles writes files keyed by slash-separated path relative to root,
+// such as the output of a plugin. Unchanged files are skipped; nothing is
+// pruned, since the caller owns the layout.
+func WriteFiles(root string, files map[string]string) (Result, error) {
+	var result Result
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		fullPath := filepath.Join(root, filepath.FromSlash(p))
+
+		o, err := writeFile(fullPath, files[p])
+		if err != nil {
+			return result, err
+		}
+
+		if o == written {
+			result.Written = append(result.Written, fullPath)
+		} else {
+			result.Skipped = append(result.Skipped, fullPath)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package writer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the name of the manifest written into the output
+// directory.
+const ManifestFile = "tables.manifest.json"
+
+// Manifest records what the generated code in a directory was generated
+// from, so it can be checked for drift without regenerating it.
+type Manifest struct {
+	// ToolVersion is the version of tables that generated the code.
+	ToolVersion string `json:"tool_version"`
+
+	// SchemaHash is the schema.Hash of all generated tables.
+	SchemaHash string `json:"schema_hash"`
+
+	// OptionsHash fingerprints the settings that shape the code, such as
+	// type mappings, naming and templates.
+	OptionsHash string `json:"options_hash"`
+
+	// Tables maps every generated table, as schema.name, to its hash.
+	Tables map[string]string `json:"tables"`
+}
+
+// WriteManifest writes m into root, leaving the file alone when it already
+// has the content. It reports whether the file was written.
+func WriteManifest(root string, m Manifest) (bool, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	o, err := writeFile(filepath.Join(root, ManifestFile), string(append(data, '\n')))
+	if err != nil {
+		return false, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return o == written, nil
+}
+
+// ReadManifest reads the manifest in root. It returns nil without an error
+// when there is none.
+func ReadManifest(root string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(root, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", ManifestFile, err)
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,169 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dependency is a third-party module that generated code may import.
+type Dependency struct {
+	Path     string
+	Version  string
+	Sum      string
+	GoModSum string
+
+	// Requires holds the go.sum lines of the go.mod files of the modules it
+	// requires, which the go command reads to load its module graph.
+	Requires []string
+
+	// Indirect lists the modules providing the packages it imports, which
+	// go.mod requires as indirect dependencies.
+	Indirect []Dependency
+}
+
+// dependencies pins the modules generated code can reference, with their
+// go.sum hashes so the output module builds without a network round trip.
+var dependencies = []Dependency{
+	{
+		Path:     "github.com/google/uuid",
+		Version:  "v1.6.0",
+		Sum:      "h1:NIvaJDMOsjHA8n1jAhLSgzrAzy1Hgr+hNrb57e+94F0=",
+		GoModSum: "h1:TIyPZe4MgqvfeYDBFedMoGGpEw/LqOeaOT+nhxU+yHo=",
+	},
+	{
+		Path:     "github.com/shopspring/decimal",
+		Version:  "v1.4.0",
+		Sum:      "h1:bxl37RwXBklmTi0C79JfXCEBD1cqqHt0bbgBAGFp81k=",
+		GoModSum: "h1:gawqmDU56v4yIKSwfBSFip1HdCCXN8/+DMd9qYNcwME=",
+	},
+	{
+		Path:     "gopkg.in/yaml.v3",
+		Version:  "v3.0.1",
+		Sum:      "h1:fxVm/GzAzEWqLHuvctI91KS9hhNmmWOoWu0XTYJS7CA=",
+		GoModSum: "h1:K4uyk7z7BCEPqu6E+C64Yfv1cQ7kz7rIZviUmN+EgEM=",
+		Requires: []string{
+			"gopkg.in/check.v1 v0.0.0-20161208181325-20d25e280405/go.mod h1:Co6ibVJAznAaIkqp8huTwlJQCZ016jof/cbN4VW5Yz0=",
+		},
+	},
+	{
+		Path:     "github.com/jackc/pgx/v5",
+		Version:  "v5.7.2",
+		Sum:      "h1:mLoDLV6sonKlvjIEsV56SkWNCnuNv531l94GaIzO+XI=",
+		GoModSum: "h1:ncY89UGWxg82EykZUwSpUKEfccBGGYq1xjrOpsbsfGQ=",
+		Indirect: []Dependency{
+			{
+				Path:     "github.com/jackc/pgpassfile",
+				Version:  "v1.0.0",
+				Sum:      "h1:/6Hmqy13Ss2zCq62VdNG8tM1wchn8zjSGOBJ6icpsIM=",
+				GoModSum: "h1:CEx0iS5ambNFdcRtxPj5JhEz+xB6uRky5eyVu/W2HEg=",
+			},
+			{
+				Path:     "github.com/jackc/pgservicefile",
+				Version:  "v0.0.0-20240606120523-5a60cdf6a761",
+				Sum:      "h1:iCEnooe7UlwOQYpKFhBabPMi4aNAfoODPEFNiAnClxo=",
+				GoModSum: "h1:5TJZWKEWniPve33vlWYSoGYefn3gLQRzjfDlhSJ9ZKM=",
+			},
+			{
+				Path:     "golang.org/x/crypto",
+				Version:  "v0.31.0",
+				Sum:      "h1:ihbySMvVjLAeSH1IbfcRTkD/iNscyz8rGzjF/E5hV6U=",
+				GoModSum: "h1:kDsLvtWBEx7MV9tJOj9bnXsPbxwJQ6csT/x4KIN4Ssk=",
+			},
+			{
+				Path:     "golang.org/x/text",
+				Version:  "v0.21.0",
+				Sum:      "h1:zyQAAkrwaneQ066sspRyJaG9VNi/YJ1NfzcGB3hZ/qo=",
+				GoModSum: "h1:4IBbMaMmOPCJ8SecivzSH54+73PCFmPWxNTLm+vZkEQ=",
+			},
+		},
+	},
+}
+
+// goVersion is the go directive written into bootstrapped modules.
+const goVersion = "1.21"
+
+// WriteModule writes go.mod and go.sum into root so the generated packages
+// form a standalone module named modulePath. Only dependencies actually
+// imported by the generated content are required.
+func WriteModule(root string, modulePath string, c map[string]string) error {
+	return WriteModuleGo(root, modulePath, "", c)
+}
+
+// WriteModuleGo is WriteModule declaring the Go version the generated code
+// targets, such as 1.18, in the go directive. An empty version declares the
+// default one.
+func WriteModuleGo(root string, modulePath string, version string, c map[string]string) error {
+	version = strings.TrimPrefix(version, "go")
+	if version == "" {
+		version = goVersion
+	}
+	// Go versions before 1.21 only accept language versions, without the
+	// patch release
+	if parts := strings.Split(version, "."); len(parts) == 3 {
+		if minor, err := strconv.Atoi(parts[1]); err == nil && minor < 21 {
+			version = parts[0] + "." + parts[1]
+		}
+	}
+
+	dirPath := filepath.Clean(root)
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return err
+	}
+
+	// Collect dependencies used by any generated file
+	var used []Dependency
+	for _, dep := range dependencies {
+		for _, content := range c {
+			if strings.Contains(content, "\""+dep.Path+"\"") {
+				used = append(used, dep)
+				break
+			}
+		}
+	}
+
+	sort.Slice(used, func(i, j int) bool { return used[i].Path < used[j].Path })
+
+	var mod strings.Builder
+	mod.WriteString("module " + modulePath + "\n\n")
+	mod.WriteString("go " + version + "\n")
+
+	if len(used) > 0 {
+		mod.WriteString("\nrequire (\n")
+		for _, dep := range used {
+			mod.WriteString("\t" + dep.Path + " " + dep.Version + "\n")
+		}
+		mod.WriteString(")\n")
+	}
+
+	var indirect []Dependency
+	for _, dep := range used {
+		indirect = append(indirect, dep.Indirect...)
+	}
+	sort.Slice(indirect, func(i, j int) bool { return indirect[i].Path < indirect[j].Path })
+	if len(indirect) > 0 {
+		mod.WriteString("\nrequire (\n")
+		for _, dep := range indirect {
+			mod.WriteString("\t" + dep.Path + " " + dep.Version + " // indirect\n")
+		}
+		mod.WriteString(")\n")
+	}
+
+	var sum strings.Builder
+	for _, dep := range append(used, indirect...) {
+		sum.WriteString(dep.Path + " " + dep.Version + " " + dep.Sum + "\n")
+		sum.WriteString(dep.Path + " " + dep.Version + "/go.mod " + dep.GoModSum + "\n")
+		for _, line := range dep.Requires {
+			sum.WriteString(line + "\n")
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dirPath, "go.mod"), []byte(mod.String()), 0644); err != nil {
+		return err
+	}
+
+	// An empty go.sum is valid, but don't leave a stale one behind either
+	return os.WriteFile(filepath.Join(dirPath, "go.sum"), []byte(sum.String()), 0644)
+}
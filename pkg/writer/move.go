@@ -0,0 +1,86 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Move moves the package from to the path to under root, for a renamed
+// table, so version control sees the files renamed rather than deleted and
+// created. Files named after the package, such as the generated file and
+// the <table>_ext.go extension, are renamed after the new package, and the
+// package clause of hand-written files is updated. Nothing is moved unless
+// from has a generated file and to has none; it reports whether it moved.
+// suffix is the generated file suffix, see Options.FileSuffix.
+func Move(root, from, to, suffix string) (bool, error) {
+	fromFile, toFile := FilePath(root, from, suffix), FilePath(root, to, suffix)
+
+	generated, err := IsGenerated(fromFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil || !generated {
+		return false, err
+	}
+	if _, err := os.Stat(toFile); err == nil {
+		return false, nil
+	}
+
+	fromDir, toDir := filepath.Dir(fromFile), filepath.Dir(toFile)
+	if err := os.MkdirAll(toDir, 0755); err != nil {
+		return false, err
+	}
+
+	oldName, newName := filepath.Base(fromDir), filepath.Base(toDir)
+	clause := regexp.MustCompile(`(?m)^package ` + regexp.QuoteMeta(oldName) + `(_test)?$`)
+
+	entries, err := os.ReadDir(fromDir)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		name := e.Name()
+		if rest, ok := strings.CutPrefix(name, oldName); ok && (rest == ".go" || strings.HasPrefix(rest, "_")) {
+			name = newName + rest
+		}
+		source, target := filepath.Join(fromDir, e.Name()), filepath.Join(toDir, name)
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+
+		if err := os.Rename(source, target); err != nil {
+			return true, err
+		}
+
+		// The generated file is rewritten anyway
+		if filepath.Ext(name) != ".go" || source == fromFile {
+			continue
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return true, err
+		}
+		if m := clause.FindSubmatchIndex(data); m != nil {
+			updated := "package " + newName
+			if m[2] >= 0 {
+				updated += "_test"
+			}
+			if err := os.WriteFile(target, []byte(string(data[:m[0]])+updated+string(data[m[1]:])), 0644); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	// Drop the old directory once nothing is left in it
+	if entries, err := os.ReadDir(fromDir); err == nil && len(entries) == 0 {
+		os.Remove(fromDir)
+	}
+
+	return true, nil
+}
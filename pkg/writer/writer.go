@@ -0,0 +1,321 @@
+// Package writer writes generated packages to disk, leaving unchanged and
+// hand-written files alone and pruning packages of dropped tables.
+package writer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// generatedRe matches the marker line of files generated by tables, the
+// generator's Header. Files generated by other tools never match, so an
+// output directory shared with them is safe to prune.
+var generatedRe = regexp.MustCompile(`^// Code generated by tables( .*)?\. DO NOT EDIT\.$`)
+
+// Result lists the files touched by a Write.
+type Result struct {
+	// Written files were created or changed.
+	Written []string `json:"written"`
+	// Skipped files already had the generated content, or are extensions.
+	Skipped []string `json:"skipped"`
+	// Pruned files were generated for tables that no longer exist.
+	Pruned []string `json:"pruned"`
+	// Failed files could not be written; only set with Options.KeepGoing.
+	Failed []Failure `json:"failed,omitempty"`
+}
+
+// Failure is a file that could not be written.
+type Failure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+type Options struct {
+	// Progress, when set, is called after each file with the number of
+	// files handled so far and the total.
+	Progress func(done, total int)
+
+	// Workers is the number of files written concurrently. Values below 1
+	// write one file at a time.
+	Workers int
+
+	// KeepGoing records files that fail to write in Result.Failed and
+	// carries on with the others instead of returning the first error.
+	KeepGoing bool
+
+	// Unchanged lists packages left out of the content that are up to date
+	// on disk, so they are neither written nor pruned.
+	Unchanged []string
+
+	// FileSuffix is appended to the name of the generated file of each
+	// package, see CheckFileSuffix.
+	FileSuffix string
+}
+
+// outcome is what writing a single file did.
+type outcome int
+
+const (
+	written outcome = iota
+	skipped
+)
+
+// Write writes the package sources of c under root, which is absolute or
+// relative to the working directory, and prunes generated packages that are
+// no longer in c.
+func Write(root string, c map[string]string, opts Options) (Result, error) {
+	var result Result
+
+	pkgs := Packages(c)
+
+	current := make(map[string]bool)
+	for _, pkg := range pkgs {
+		current[FilePath(root, pkg, opts.FileSuffix)] = true
+	}
+	for _, pkg := range opts.Unchanged {
+		current[FilePath(root, pkg, opts.FileSuffix)] = true
+	}
+
+	// Files are independent, so they are written by a pool of workers
+	var mu sync.Mutex
+	var firstErr error
+	done := 0
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pkg := range jobs {
+				fullPath := FilePath(root, pkg, opts.FileSuffix)
+				o, err := writeFile(fullPath, c[pkg])
+
+				mu.Lock()
+				switch {
+				case err != nil && opts.KeepGoing:
+					result.Failed = append(result.Failed, Failure{Path: fullPath, Error: err.Error()})
+				case err != nil:
+					if firstErr == nil {
+						firstErr = err
+					}
+				case o == written:
+					result.Written = append(result.Written, fullPath)
+				default:
+					result.Skipped = append(result.Skipped, fullPath)
+				}
+
+				done++
+				if opts.Progress != nil {
+					opts.Progress(done, len(c))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, pkg := range pkgs {
+		jobs <- pkg
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return result, firstErr
+	}
+
+	// Workers finish in any order
+	sort.Strings(result.Written)
+	sort.Strings(result.Skipped)
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].Path < result.Failed[j].Path
+	})
+
+	stale, err := staleFiles(root, current)
+	if err != nil {
+		return result, err
+	}
+
+	if err := prune(stale); err != nil {
+		return result, err
+	}
+	result.Pruned = stale
+
+	return result, nil
+}
+
+// Read returns the generated file of the packages in pkgs under root,
+// keyed by package path like the content given to Write with the file
+// suffix. Packages without a file are left out.
+func Read(root string, pkgs []string, suffix string) (map[string]string, error) {
+	c := make(map[string]string, len(pkgs))
+	for _, pkg := range pkgs {
+		data, err := os.ReadFile(FilePath(root, pkg, suffix))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c[pkg] = string(data)
+	}
+
+	return c, nil
+}
+
+// writeFile writes one generated file unless it is an extension or already
+// has the content.
+func writeFile(fullPath, content string) (outcome, error) {
+	// Create directory structure: root/pkg/
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return 0, err
+	}
+
+	// Hand-written extensions are never touched
+	if IsExtension(fullPath) {
+		return skipped, nil
+	}
+
+	// Leave unchanged files alone so their mtime stays put
+	if existing, err := os.ReadFile(fullPath); err == nil && string(existing) == content {
+		return skipped, nil
+	}
+
+	// Create or overwrite file
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return 0, err
+	}
+
+	// Write content to file
+	_, err = file.WriteString(content)
+	file.Close() // Close immediately after writing
+
+	if err != nil {
+		return 0, err
+	}
+
+	return written, nil
+}
+
+// Packages returns the package paths of generated content in sorted order.
+func Packages(c map[string]string) []string {
+	pkgs := make([]string, 0, len(c))
+	for pkg := range c {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	return pkgs
+}
+
+// FilePath returns the generated file of a package: root/pkg/name.go, or
+// root/pkg/name<suffix>.go.
+func FilePath(root, pkg, suffix string) string {
+	dirPath := filepath.Join(root, filepath.FromSlash(pkg))
+	return filepath.Join(dirPath, filepath.Base(dirPath)+suffix+".go")
+}
+
+// CheckFileSuffix reports a generated file suffix that would turn the file
+// into something else: a path, a test file or an extension. Suffixes naming
+// an operating system or architecture, such as _linux, make the go tool
+// build the file only there.
+func CheckFileSuffix(suffix string) error {
+	switch {
+	case suffix == "":
+		return nil
+	case strings.ContainsAny(suffix, `/\.`):
+		return fmt.Errorf("invalid file suffix %q, it cannot contain a path or extension", suffix)
+	case strings.HasSuffix(suffix, "_test"):
+		return fmt.Errorf("invalid file suffix %q, it would make generated files tests", suffix)
+	case strings.HasSuffix(suffix, "_ext"):
+		return fmt.Errorf("invalid file suffix %q, it is reserved for extensions", suffix)
+	}
+
+	return nil
+}
+
+// IsExtension reports whether path is a hand-written <table>_ext.go file:
+// one named after the package directory it is in, next to the generated
+// <table>.go. The generated file of a table named audit_ext,
+// audit_ext/audit_ext.go, is not one.
+func IsExtension(path string) bool {
+	return filepath.Base(path) == filepath.Base(filepath.Dir(path))+"_ext.go"
+}
+
+// staleFiles lists generated files under root that are not part of the
+// current output, e.g. packages of dropped tables. Extension files and files
+// without the generated marker are never listed.
+func staleFiles(root string, current map[string]bool) ([]string, error) {
+	var stale []string
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".go" || IsExtension(path) || current[path] {
+			return nil
+		}
+
+		generated, err := IsGenerated(path)
+		if err != nil {
+			return err
+		}
+		if generated {
+			stale = append(stale, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return stale, err
+}
+
+// prune removes stale generated files and their emptied package directories.
+func prune(stale []string) error {
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		// Drop the package directory once nothing is left in it
+		dir := filepath.Dir(path)
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			os.Remove(dir)
+		}
+	}
+
+	return nil
+}
+
+// IsGenerated reports whether the file carries the generated code marker
+// before its package clause.
+func IsGenerated(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if generatedRe.MatchString(line) {
+			return true, nil
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+
+	return false, scanner.Err()
+}